@@ -9,6 +9,9 @@ import (
 	"runtime"
 	"strings"
 	"testing"
+	"time"
+
+	"github.com/romanitalian/osheet2xlsx/v3/internal/osheet"
 )
 
 func goRun(args ...string) *exec.Cmd {
@@ -73,6 +76,134 @@ func TestCLI_Convert_OK(t *testing.T) {
 	}
 }
 
+func TestCLI_Convert_DottedName(t *testing.T) {
+	if testing.Short() {
+		t.Skip("short")
+	}
+	tmp := t.TempDir()
+	in := filepath.Join(tmp, "Q1 1.5 report.v2.osheet")
+	makeOsheet(t, in)
+
+	// Direct conversion writes into the working directory, so run a built binary there
+	bin := filepath.Join(t.TempDir(), "osheet2xlsx")
+	if out, err := exec.Command("go", "build", "-o", bin, "..").CombinedOutput(); err != nil {
+		t.Fatalf("build failed: %v (%s)", err, string(out))
+	}
+	cmd := exec.Command(bin, in)
+	cmd.Dir = tmp
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("direct convert failed: %v (%s)", err, string(out))
+	}
+	outDir := filepath.Join(tmp, "out")
+	if out, err := goRun("convert", tmp, "--out-dir", outDir).CombinedOutput(); err != nil {
+		t.Fatalf("batch convert failed: %v (%s)", err, string(out))
+	}
+	for _, want := range []string{filepath.Join(tmp, "Q1 1.5 report.v2.xlsx"), filepath.Join(outDir, "Q1 1.5 report.v2.xlsx")} {
+		if _, err := os.Stat(want); err != nil {
+			t.Errorf("output not created: %v", err)
+		}
+	}
+}
+
+func TestCLI_Convert_Properties(t *testing.T) {
+	if testing.Short() {
+		t.Skip("short")
+	}
+	tmp := t.TempDir()
+	in := filepath.Join(tmp, "in.osheet")
+	xlsxPath := filepath.Join(tmp, "out.xlsx")
+	back := filepath.Join(tmp, "back.osheet")
+	makeOsheet(t, in)
+
+	cmd := goRun("convert", in, "--out", xlsxPath, "--title", "Ledger", "--author", "Records",
+		"--property", "Batch:number=7", "--property", "Dept=Finance", "--provenance")
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("convert failed: %v (%s)", err, string(out))
+	}
+	if out, err := goRun("reverse", xlsxPath, "--out", back).CombinedOutput(); err != nil {
+		t.Fatalf("reverse failed: %v (%s)", err, string(out))
+	}
+	book, err := osheet.ReadBook(back)
+	if err != nil {
+		t.Fatalf("read reversed: %v", err)
+	}
+	if book.Title != "Ledger" || book.Properties.Author != "Records" {
+		t.Errorf("title = %q, author = %q", book.Title, book.Properties.Author)
+	}
+	custom := map[string]interface{}{}
+	for _, c := range book.Properties.Custom {
+		custom[c.Name] = c.Value
+	}
+	if custom["Batch"] != 7.0 || custom["Dept"] != "Finance" || custom["SourceFile"] != "in.osheet" || custom["ConvertedBy"] != "osheet2xlsx dev" {
+		t.Errorf("custom properties = %+v", custom)
+	}
+	if at, ok := custom["ConvertedAt"].(time.Time); !ok || time.Since(at) > time.Hour {
+		t.Errorf("ConvertedAt = %v", custom["ConvertedAt"])
+	}
+
+	if out, err := goRun("convert", in, "--out", xlsxPath, "--overwrite", "--property", "Batch:int=7").CombinedOutput(); err == nil {
+		t.Fatalf("expected bad --property to fail (out=%s)", string(out))
+	}
+}
+
+func TestCLI_Reverse_RoundTrip(t *testing.T) {
+	if testing.Short() {
+		t.Skip("short")
+	}
+	tmp := t.TempDir()
+	in := filepath.Join(tmp, "in.osheet")
+	xlsxPath := filepath.Join(tmp, "mid.xlsx")
+	back := filepath.Join(tmp, "back.osheet")
+	makeOsheet(t, in)
+
+	if out, err := goRun("convert", in, "--out", xlsxPath).CombinedOutput(); err != nil {
+		t.Fatalf("convert failed: %v (%s)", err, string(out))
+	}
+	if out, err := goRun("import", xlsxPath, "--out", back).CombinedOutput(); err != nil {
+		t.Fatalf("import failed: %v (%s)", err, string(out))
+	}
+	book, err := osheet.ReadBook(back)
+	if err != nil {
+		t.Fatalf("read reversed: %v", err)
+	}
+	if len(book.Sheets) != 1 || book.Sheets[0].Name != "S" || book.Sheets[0].Cells[1][0].Formula != "SUM(A1:B1)" {
+		t.Fatalf("reversed book = %+v", book.Sheets)
+	}
+	// A second run must refuse to overwrite
+	if out, err := goRun("reverse", xlsxPath, "--out", back).CombinedOutput(); err == nil {
+		t.Fatalf("expected overwrite refusal (out=%s)", string(out))
+	}
+
+	binPath := filepath.Join(tmp, "back_bin.osheet")
+	if out, err := goRun("reverse", xlsxPath, "--binary", "--out", binPath).CombinedOutput(); err != nil {
+		t.Fatalf("reverse --binary failed: %v (%s)", err, string(out))
+	}
+	if format, err := osheet.DetectFormat(binPath); err != nil || format != osheet.FormatBinary {
+		t.Fatalf("DetectFormat = %v, %v; want Binary", format, err)
+	}
+}
+
+func TestCLI_Inspect_CountsAnnotations(t *testing.T) {
+	if testing.Short() {
+		t.Skip("short")
+	}
+	in := filepath.Join(t.TempDir(), "notes.osheet")
+	book := &osheet.Book{Sheets: []osheet.Sheet{{Name: "S", Cells: [][]osheet.Cell{{
+		{Type: osheet.ValueString, StringValue: "T-1", Link: &osheet.Hyperlink{URL: "https://example.com/T-1"}, Comment: &osheet.Comment{Text: "ok"}},
+		{Type: osheet.ValueString, StringValue: "T-2", Link: &osheet.Hyperlink{Location: "S!A1"}},
+	}}}}}
+	if err := osheet.WriteBook(book, in); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	out, err := goRun("inspect", in, "--json").CombinedOutput()
+	if err != nil {
+		t.Fatalf("inspect failed: %v (%s)", err, string(out))
+	}
+	if !strings.Contains(string(out), `"hyperlinks":2,"comments":1`) {
+		t.Fatalf("inspect output lacks counts: %s", string(out))
+	}
+}
+
 func makeOsheet(t *testing.T, path string) {
 	t.Helper()
 	f, err := os.Create(path)
@@ -4,6 +4,8 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strconv"
+	"strings"
 	"sync"
 	"time"
 
@@ -11,8 +13,12 @@ import (
 
 	appcfg "github.com/romanitalian/osheet2xlsx/v3/internal/config"
 	appconvert "github.com/romanitalian/osheet2xlsx/v3/internal/convert"
+	appcsv "github.com/romanitalian/osheet2xlsx/v3/internal/csv"
 	appfs "github.com/romanitalian/osheet2xlsx/v3/internal/fs"
+	appjson "github.com/romanitalian/osheet2xlsx/v3/internal/json"
 	applog "github.com/romanitalian/osheet2xlsx/v3/internal/log"
+	"github.com/romanitalian/osheet2xlsx/v3/internal/osheet"
+	"github.com/romanitalian/osheet2xlsx/v3/internal/xlsx"
 )
 
 type convertOptions struct {
@@ -26,6 +32,56 @@ type convertOptions struct {
 	dryRun    bool
 	progress  bool
 	failFast  bool
+	// streamThreshold is the per-sheet cell count that switches to streaming writes.
+	streamThreshold int
+	// stream pipes rows from the reader into the streaming writer.
+	stream bool
+	// preserveDirs mirrors the input tree under outDir instead of flattening it.
+	preserveDirs bool
+	// nameTemplate renders output paths; see nameTemplate for placeholders.
+	nameTemplate string
+	// format names the output format (xlsx, csv, tsv).
+	format string
+	// sheet converts only the named sheet.
+	sheet string
+	csv   csvFlags
+	// ndjsonHeader keys ndjson rows by the first row's values.
+	ndjsonHeader bool
+	// formulas is keep, cache or values (xlsx output).
+	formulas string
+	// numberLocale selects how numeric text is read (osheet.NumberLocale*).
+	numberLocale string
+	// asTable turns data under a detected header row into an Excel table.
+	asTable bool
+	// stripProtection drops sheet and workbook protection (xlsx output).
+	stripProtection bool
+	props           propFlags
+	// provenance stamps the source file, conversion time and tool version
+	// as custom document properties.
+	provenance bool
+}
+
+// propFlags holds document property overrides as given on the command line.
+type propFlags struct {
+	title       string
+	author      string
+	description string
+	keywords    string
+	created     string
+	modified    string
+	// custom holds name=value pairs; see options.
+	custom []string
+}
+
+// csvFlags holds the csv/tsv dialect as given on the command line.
+type csvFlags struct {
+	delimiter  string
+	quote      string
+	lineEnding string
+	bom        bool
+	dates      string
+	trueValue  string
+	falseValue string
 }
 
 func newConvertCmd() *cobra.Command {
@@ -65,6 +121,66 @@ func newConvertCmd() *cobra.Command {
 			if !cmd.Flags().Changed("fail-fast") && cfg.Convert.FailFast {
 				opts.failFast = true
 			}
+			if !cmd.Flags().Changed("stream-threshold") && cfg.Convert.StreamThreshold != 0 {
+				opts.streamThreshold = cfg.Convert.StreamThreshold
+			}
+			if !cmd.Flags().Changed("stream") && cfg.Convert.Stream {
+				opts.stream = true
+			}
+			if !cmd.Flags().Changed("preserve-dirs") && cfg.Convert.PreserveDirs {
+				opts.preserveDirs = true
+			}
+			if !cmd.Flags().Changed("name-template") && cfg.Convert.NameTemplate != "" {
+				opts.nameTemplate = cfg.Convert.NameTemplate
+			}
+			if !cmd.Flags().Changed("format") && cfg.Convert.Format != "" {
+				opts.format = cfg.Convert.Format
+			}
+			if !cmd.Flags().Changed("sheet") && cfg.Convert.Sheet != "" {
+				opts.sheet = cfg.Convert.Sheet
+			}
+			if !cmd.Flags().Changed("formulas") && cfg.Convert.Formulas != "" {
+				opts.formulas = cfg.Convert.Formulas
+			}
+			if !cmd.Flags().Changed("number-locale") && cfg.Convert.NumberLocale != "" {
+				opts.numberLocale = cfg.Convert.NumberLocale
+			}
+			if !cmd.Flags().Changed("csv-delimiter") && cfg.Convert.CSV.Delimiter != "" {
+				opts.csv.delimiter = cfg.Convert.CSV.Delimiter
+			}
+			if !cmd.Flags().Changed("csv-quote") && cfg.Convert.CSV.Quote != "" {
+				opts.csv.quote = cfg.Convert.CSV.Quote
+			}
+			if !cmd.Flags().Changed("csv-line-ending") && cfg.Convert.CSV.LineEnding != "" {
+				opts.csv.lineEnding = cfg.Convert.CSV.LineEnding
+			}
+			if !cmd.Flags().Changed("csv-bom") && cfg.Convert.CSV.BOM {
+				opts.csv.bom = true
+			}
+			if !cmd.Flags().Changed("csv-dates") && cfg.Convert.CSV.Dates != "" {
+				opts.csv.dates = cfg.Convert.CSV.Dates
+			}
+			if !cmd.Flags().Changed("csv-true") && cfg.Convert.CSV.True != "" {
+				opts.csv.trueValue = cfg.Convert.CSV.True
+			}
+			if !cmd.Flags().Changed("csv-false") && cfg.Convert.CSV.False != "" {
+				opts.csv.falseValue = cfg.Convert.CSV.False
+			}
+			if !cmd.Flags().Changed("ndjson-header") && cfg.Convert.NDJSONHeader {
+				opts.ndjsonHeader = true
+			}
+			if !cmd.Flags().Changed("as-table") && cfg.Convert.AsTable {
+				opts.asTable = true
+			}
+			if !cmd.Flags().Changed("strip-protection") && cfg.Convert.StripProtection {
+				opts.stripProtection = true
+			}
+			if !cmd.Flags().Changed("author") && cfg.Convert.Author != "" {
+				opts.props.author = cfg.Convert.Author
+			}
+			if !cmd.Flags().Changed("provenance") && cfg.Convert.Provenance {
+				opts.provenance = true
+			}
 			if len(args) == 1 {
 				opts.inputPath = args[0]
 			}
@@ -75,17 +191,18 @@ func newConvertCmd() *cobra.Command {
 
 			// Decide single vs batch
 			var inputs []string
+			root := opts.inputPath
+			if root == "" {
+				root = "."
+			}
 			if opts.inputPath != "" {
 				st, err := os.Stat(opts.inputPath)
 				if err == nil && !st.IsDir() {
 					inputs = []string{opts.inputPath}
+					root = filepath.Dir(opts.inputPath)
 				}
 			}
 			if len(inputs) == 0 {
-				root := opts.inputPath
-				if root == "" {
-					root = "."
-				}
 				found, err := appfs.ListInputs(root, opts.pattern, opts.recursive)
 				if err != nil {
 					return err
@@ -97,6 +214,17 @@ func newConvertCmd() *cobra.Command {
 				return fmt.Errorf("no inputs found")
 			}
 
+			convOpts, err := opts.convertOptions()
+			if err != nil {
+				return err
+			}
+
+			// Resolve every output up front so clashes are reported before anything is written
+			plan, err := planOutputs(inputs, root, opts)
+			if err != nil {
+				return err
+			}
+
 			var hadErrors bool
 			var errMu sync.Mutex
 			workerCount := opts.parallel
@@ -104,30 +232,11 @@ func newConvertCmd() *cobra.Command {
 				workerCount = 1
 			}
 
-			type job struct{ in string }
-			jobs := make(chan job)
+			jobs := make(chan plannedOutput)
 			var wg sync.WaitGroup
 
-			runOne := func(in string) {
-				outPath := opts.out
-				if outPath == "" {
-					base := filepath.Base(in)
-					ext := filepath.Ext(base)
-					name := base[:len(base)-len(ext)]
-					outName := name + ".xlsx"
-					if opts.outDir != "" {
-						// Protect against path traversal: ensure the final path stays within outDir
-						cleanDir := filepath.Clean(opts.outDir)
-						candidate := filepath.Join(cleanDir, outName)
-						if !isWithinDir(candidate, cleanDir) {
-							logger.Error("invalid output path")
-							return
-						}
-						outPath = candidate
-					} else {
-						outPath = outName
-					}
-				}
+			runOne := func(p plannedOutput) {
+				in, outPath := p.in, p.out
 				if opts.dryRun {
 					fmt.Fprintf(getOutputWriter(), "DRY-RUN: would convert %s -> %s\n", in, outPath)
 					return
@@ -135,7 +244,9 @@ func newConvertCmd() *cobra.Command {
 				if jsonLog {
 					fmt.Fprintf(getOutputWriter(), `{"event":"convert_start","input":"%s","output":"%s"}`+"\n", in, outPath)
 				}
-				produced, err := appconvert.ConvertSingle(in, outPath, opts.overwrite)
+				inOpts := convOpts
+				inOpts.Warn = warnFor(in)
+				produced, err := appconvert.Convert(in, outPath, inOpts)
 				if err != nil {
 					errMu.Lock()
 					hadErrors = true
@@ -147,10 +258,13 @@ func newConvertCmd() *cobra.Command {
 					}
 					return
 				}
-				if jsonLog {
-					fmt.Fprintf(getOutputWriter(), `{"event":"convert_ok","input":"%s","output":"%s"}`+"\n", in, produced)
-				} else {
-					fmt.Fprintf(getOutputWriter(), "OK: %s -> %s\n", in, produced)
+				// Per-sheet formats produce several files; report each one
+				for _, out := range produced {
+					if jsonLog {
+						fmt.Fprintf(getOutputWriter(), `{"event":"convert_ok","input":"%s","output":"%s"}`+"\n", in, out)
+					} else {
+						fmt.Fprintf(getOutputWriter(), "OK: %s -> %s\n", in, out)
+					}
 				}
 			}
 
@@ -160,7 +274,7 @@ func newConvertCmd() *cobra.Command {
 			var totalDone int
 			var start time.Time
 			if showProgress {
-				total = len(inputs)
+				total = len(plan)
 				start = time.Now()
 				fmt.Fprint(getOutputWriter(), "Progress: 0/", total, " (0%) | 0.0 it/s | elapsed 0s | eta --\r")
 			}
@@ -193,11 +307,11 @@ func newConvertCmd() *cobra.Command {
 			}
 
 			if workerCount == 1 {
-				for _, in := range inputs {
+				for _, p := range plan {
 					if opts.failFast && hadErrors {
 						break
 					}
-					runOne(in)
+					runOne(p)
 					incr()
 				}
 			} else {
@@ -212,13 +326,13 @@ func newConvertCmd() *cobra.Command {
 							if opts.failFast && failed {
 								return
 							}
-							runOne(j.in)
+							runOne(j)
 							incr()
 						}
 					}()
 				}
-				for _, in := range inputs {
-					jobs <- job{in: in}
+				for _, p := range plan {
+					jobs <- p
 				}
 				close(jobs)
 				wg.Wait()
@@ -244,10 +358,185 @@ func newConvertCmd() *cobra.Command {
 	cmd.Flags().BoolVar(&opts.dryRun, "dry-run", false, "do not write files, only report")
 	cmd.Flags().BoolVar(&opts.progress, "progress", false, "show progress bar for TTY")
 	cmd.Flags().BoolVar(&opts.failFast, "fail-fast", false, "stop batch on first error")
+	cmd.Flags().BoolVar(&opts.stream, "stream", false, "read and write row by row to keep memory flat on huge inputs")
+	cmd.Flags().BoolVar(&opts.preserveDirs, "preserve-dirs", false, "mirror input subdirectories under --out-dir (batch)")
+	cmd.Flags().StringVar(&opts.nameTemplate, "name-template", "", "output path template under --out-dir, e.g. \"{reldir}/{name}_{date}.xlsx\"")
+	cmd.Flags().StringVar(&opts.format, "format", "", "output format: "+strings.Join(appconvert.FormatNames(), ", ")+" (default xlsx)")
+	cmd.Flags().StringVar(&opts.sheet, "sheet", "", "convert only the sheet with this name")
+	cmd.Flags().StringVar(&opts.csv.delimiter, "csv-delimiter", "", "csv field delimiter, one character or \"tab\" (default \",\")")
+	cmd.Flags().StringVar(&opts.csv.quote, "csv-quote", "", "csv quoting: minimal, all or nonnumeric (default minimal)")
+	cmd.Flags().StringVar(&opts.csv.lineEnding, "csv-line-ending", "", "csv line endings: lf or crlf (default lf)")
+	cmd.Flags().BoolVar(&opts.csv.bom, "csv-bom", false, "prefix csv files with a UTF-8 BOM")
+	cmd.Flags().StringVar(&opts.csv.dates, "csv-dates", "", "csv date encoding: iso or serial (default iso)")
+	cmd.Flags().StringVar(&opts.csv.trueValue, "csv-true", "", "csv spelling of true (default TRUE)")
+	cmd.Flags().StringVar(&opts.csv.falseValue, "csv-false", "", "csv spelling of false (default FALSE)")
+	cmd.Flags().BoolVar(&opts.ndjsonHeader, "ndjson-header", false, "ndjson: use the first row as field names and emit objects")
+	cmd.Flags().IntVar(&opts.streamThreshold, "stream-threshold", 0, "cells per sheet above which the streaming writer is used (0=default, -1=never)")
+	cmd.Flags().StringVar(&opts.formulas, "formulas", "", "xlsx formula results: keep (formulas only), cache (formulas with computed values) or values (computed values only) (default keep)")
+	cmd.Flags().StringVar(&opts.numberLocale, "number-locale", "", "how numeric text is read: en reads a comma before three-digit groups (\"1,200\") as a thousands separator (default: a lone comma is a decimal separator)")
+	cmd.Flags().BoolVar(&opts.asTable, "as-table", false, "xlsx: turn data under a detected header row into a filterable Excel table")
+	cmd.Flags().BoolVar(&opts.stripProtection, "strip-protection", false, "xlsx: drop sheet and workbook protection so the output is editable")
+	cmd.Flags().StringVar(&opts.props.title, "title", "", "document title property")
+	cmd.Flags().StringVar(&opts.props.author, "author", "", "document author property")
+	cmd.Flags().StringVar(&opts.props.description, "description", "", "document description property")
+	cmd.Flags().StringVar(&opts.props.keywords, "keywords", "", "document keywords property")
+	cmd.Flags().StringVar(&opts.props.created, "created", "", "document creation time (RFC 3339 or YYYY-MM-DD)")
+	cmd.Flags().StringVar(&opts.props.modified, "modified", "", "document modification time (RFC 3339 or YYYY-MM-DD)")
+	cmd.Flags().StringArrayVar(&opts.props.custom, "property", nil, "custom document property name=value; name:number=, name:bool= and name:date= set typed values (repeatable)")
+	cmd.Flags().BoolVar(&opts.provenance, "provenance", false, "stamp SourceFile, ConvertedAt and ConvertedBy custom document properties")
 
 	return cmd
 }
 
+// convertOptions maps CLI options onto pipeline options.
+func (o *convertOptions) convertOptions() (appconvert.Options, error) {
+	if _, err := appconvert.LookupFormat(o.format); err != nil {
+		return appconvert.Options{}, err
+	}
+	csvOpts, err := o.csv.options()
+	if err != nil {
+		return appconvert.Options{}, err
+	}
+	if err := (xlsx.Options{Formulas: o.formulas}).Validate(); err != nil {
+		return appconvert.Options{}, fmt.Errorf("formulas argument: %w", err)
+	}
+	if o.formulas != "" && o.formulas != xlsx.FormulasKeep && o.format != "" && !strings.EqualFold(o.format, "xlsx") {
+		return appconvert.Options{}, fmt.Errorf("invalid --formulas argument %q: applies to xlsx output only", o.formulas)
+	}
+	if err := (osheet.ReadOptions{NumberLocale: o.numberLocale}).Validate(); err != nil {
+		return appconvert.Options{}, fmt.Errorf("number-locale argument: %w", err)
+	}
+	if o.asTable && o.format != "" && !strings.EqualFold(o.format, "xlsx") {
+		return appconvert.Options{}, fmt.Errorf("--as-table applies to xlsx output only")
+	}
+	props, err := o.props.options()
+	if err != nil {
+		return appconvert.Options{}, err
+	}
+	var provenance string
+	if o.provenance {
+		provenance = "osheet2xlsx " + version
+	}
+	return appconvert.Options{
+		Overwrite:       o.overwrite,
+		StreamThreshold: o.streamThreshold,
+		Stream:          o.stream,
+		Format:          o.format,
+		Sheet:           o.sheet,
+		CSV:             csvOpts,
+		JSON:            appjson.Options{Header: o.ndjsonHeader},
+		Formulas:        o.formulas,
+		NumberLocale:    o.numberLocale,
+		AsTable:         o.asTable,
+		StripProtection: o.stripProtection,
+		Title:           o.props.title,
+		Properties:      props,
+		Provenance:      provenance,
+	}, nil
+}
+
+// warnFor reports conversion warnings for one input as WARN log lines or,
+// in JSON mode, as convert_warning events.
+func warnFor(input string) func(string) {
+	return func(warning string) {
+		if jsonLog {
+			fmt.Fprintf(getOutputWriter(), `{"event":"convert_warning","input":"%s","warning":%q}`+"\n", input, warning)
+			return
+		}
+		applog.Get().Warn(fmt.Sprintf("%s: %s", input, warning))
+	}
+}
+
+// options converts the flag strings into csv writer options.
+func (f csvFlags) options() (appcsv.Options, error) {
+	opts := appcsv.Options{
+		Quote: strings.ToLower(f.quote),
+		BOM:   f.bom,
+		Dates: strings.ToLower(f.dates),
+		True:  f.trueValue,
+		False: f.falseValue,
+	}
+	switch strings.ToLower(f.delimiter) {
+	case "":
+	case "tab", `\t`:
+		opts.Delimiter = '\t'
+	default:
+		r := []rune(f.delimiter)
+		if len(r) != 1 {
+			return opts, fmt.Errorf("invalid --csv-delimiter argument %q: want a single character", f.delimiter)
+		}
+		opts.Delimiter = r[0]
+	}
+	switch strings.ToLower(f.lineEnding) {
+	case "", "lf":
+	case "crlf":
+		opts.CRLF = true
+	default:
+		return opts, fmt.Errorf("invalid --csv-line-ending argument %q: want lf or crlf", f.lineEnding)
+	}
+	if err := opts.Validate(); err != nil {
+		return opts, fmt.Errorf("csv options argument: %w", err)
+	}
+	return opts, nil
+}
+
+// options converts the flag strings into document properties. Custom
+// values are text unless the name ends in :number, :bool or :date.
+func (f propFlags) options() (osheet.DocProperties, error) {
+	props := osheet.DocProperties{
+		Author:      f.author,
+		Description: f.description,
+		Keywords:    f.keywords,
+	}
+	var err error
+	if props.Created, err = parsePropertyTime("created", f.created); err != nil {
+		return props, err
+	}
+	if props.Modified, err = parsePropertyTime("modified", f.modified); err != nil {
+		return props, err
+	}
+	for _, kv := range f.custom {
+		name, text, ok := strings.Cut(kv, "=")
+		name = strings.TrimSpace(name)
+		if !ok || name == "" {
+			return props, fmt.Errorf("invalid --property argument %q: want name=value", kv)
+		}
+		var value interface{} = text
+		if base, typ, typed := strings.Cut(name, ":"); typed {
+			name = strings.TrimSpace(base)
+			switch strings.ToLower(typ) {
+			case "number":
+				value, err = strconv.ParseFloat(strings.TrimSpace(text), 64)
+			case "bool":
+				value, err = strconv.ParseBool(strings.TrimSpace(text))
+			case "date":
+				value, err = parsePropertyTime("property", text)
+			default:
+				return props, fmt.Errorf("invalid --property argument %q: type must be number, bool or date", kv)
+			}
+			if err != nil || name == "" {
+				return props, fmt.Errorf("invalid --property argument %q: not a %s", kv, typ)
+			}
+		}
+		props.Custom = append(props.Custom, osheet.CustomProperty{Name: name, Value: value})
+	}
+	return props, nil
+}
+
+// parsePropertyTime reads an RFC 3339 time or a date; empty is the zero time.
+func parsePropertyTime(flag, value string) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return time.Time{}, nil
+	}
+	for _, layout := range []string{time.RFC3339, "2006-01-02T15:04:05", "2006-01-02"} {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t.UTC(), nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid --%s argument %q: want RFC 3339 or YYYY-MM-DD", flag, value)
+}
+
 // formatDuration prints durations as H:MM:SS or M:SS or S
 func formatDuration(d time.Duration) string {
 	if d < 0 {
@@ -23,6 +23,7 @@ func newInspectCmd() *cobra.Command {
 			}
 			// Prefer real parse for sheet names
 			if b, err := osheet.ReadBook(path); err == nil && len(b.Sheets) > 0 {
+				links, comments := countAnnotations(b)
 				if jsonLog {
 					// print structured info to stdout
 					fmt.Fprintf(getOutputWriter(), "{\"event\":\"inspect\",\"sheets\":%d,\"names\":[", len(b.Sheets))
@@ -32,12 +33,13 @@ func newInspectCmd() *cobra.Command {
 						}
 						fmt.Fprintf(getOutputWriter(), "\"%s\"", b.Sheets[i].Name)
 					}
-					fmt.Fprintln(getOutputWriter(), "]}")
+					fmt.Fprintf(getOutputWriter(), "],\"hyperlinks\":%d,\"comments\":%d}\n", links, comments)
 				} else {
 					fmt.Fprintf(getOutputWriter(), "sheets: %d\n", len(b.Sheets))
 					for i := 0; i < len(b.Sheets); i++ {
 						fmt.Fprintf(getOutputWriter(), "- %s\n", b.Sheets[i].Name)
 					}
+					fmt.Fprintf(getOutputWriter(), "hyperlinks: %d\ncomments: %d\n", links, comments)
 				}
 				return nil
 			}
@@ -57,3 +59,20 @@ func newInspectCmd() *cobra.Command {
 	}
 	return cmd
 }
+
+// countAnnotations returns the number of cells carrying a hyperlink and a comment.
+func countAnnotations(b *osheet.Book) (links, comments int) {
+	for i := range b.Sheets {
+		for _, row := range b.Sheets[i].Cells {
+			for _, cell := range row {
+				if cell.Link != nil {
+					links++
+				}
+				if cell.Comment != nil {
+					comments++
+				}
+			}
+		}
+	}
+	return links, comments
+}
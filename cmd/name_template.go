@@ -0,0 +1,153 @@
+package cmd
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	appconvert "github.com/romanitalian/osheet2xlsx/v3/internal/convert"
+	appcsv "github.com/romanitalian/osheet2xlsx/v3/internal/csv"
+	"github.com/romanitalian/osheet2xlsx/v3/internal/osheet"
+)
+
+// Default output name templates; --preserve-dirs switches to the mirrored one.
+// The output format's extension is appended by render.
+const (
+	defaultNameTemplate   = "{name}"
+	preserveNameTemplate  = "{reldir}/{name}"
+	nameTemplateDateStyle = "2006-01-02"
+)
+
+var (
+	templatePlaceholder = regexp.MustCompile(`\{[^{}]*\}`)
+	// unsafeNameChars are replaced in placeholder values so a value never adds path segments.
+	unsafeNameChars = regexp.MustCompile(`[/\\:*?"<>|\x00-\x1f]`)
+)
+
+// nameTemplate renders output paths from placeholders:
+// {name} input base name without extension, {dir} input's parent directory name,
+// {reldir} input directory relative to the scan root, {sheet} the name of each
+// sheet (per-sheet formats only), {date} conversion date (YYYY-MM-DD), {hash8}
+// first 8 hex digits of the input's SHA-256 and {title} the book title (falls
+// back to {name}).
+type nameTemplate struct {
+	tmpl string
+	date string
+	// ext is appended (with a dot) unless the rendered name already ends in it.
+	ext string
+	// needBook and needHash avoid opening inputs for templates that do not use them.
+	needBook bool
+	needHash bool
+}
+
+// parseNameTemplate validates placeholders up front so a typo fails before any conversion.
+func parseNameTemplate(tmpl string, format appconvert.Format, now time.Time) (*nameTemplate, error) {
+	if strings.TrimSpace(tmpl) == "" {
+		return nil, fmt.Errorf("invalid --name-template argument: empty")
+	}
+	t := &nameTemplate{tmpl: tmpl, date: now.Format(nameTemplateDateStyle), ext: format.Ext}
+	for _, ph := range templatePlaceholder.FindAllString(tmpl, -1) {
+		switch ph {
+		case "{name}", "{dir}", "{reldir}", "{date}":
+		case "{sheet}":
+			// One file holds every sheet unless the format writes a file per sheet
+			if !format.PerSheet {
+				return nil, fmt.Errorf("invalid --name-template argument: {sheet} needs a per-sheet format (csv or tsv)")
+			}
+		case "{title}":
+			t.needBook = true
+		case "{hash8}":
+			t.needHash = true
+		default:
+			return nil, fmt.Errorf("invalid --name-template argument: unknown placeholder %s", ph)
+		}
+	}
+	return t, nil
+}
+
+// render returns the output path for in, relative to the output directory.
+// The format's extension is appended unless the template already ends in
+// it, so dots in input names (report.v2) are kept. book is the metadata of in,
+// read when needBook is set; {sheet} is left for the per-sheet writer to
+// expand.
+func (t *nameTemplate) render(in string, root string, book *osheet.Book) (string, error) {
+	base := filepath.Base(in)
+	name := base[:len(base)-len(filepath.Ext(base))]
+
+	rel, err := filepath.Rel(filepath.Clean(root), filepath.Dir(filepath.Clean(in)))
+	if err != nil {
+		return "", fmt.Errorf("cannot make %s relative to %s: %w", in, root, err)
+	}
+	if rel == "." {
+		rel = ""
+	}
+
+	values := map[string]string{
+		"{name}":   name,
+		"{dir}":    filepath.Base(filepath.Dir(filepath.Clean(in))),
+		"{reldir}": filepath.ToSlash(rel),
+		"{date}":   t.date,
+		"{sheet}":  appcsv.SheetPlaceholder,
+	}
+	if t.needBook {
+		title := book.Title
+		if title == "" {
+			title = name
+		}
+		values["{title}"] = title
+	}
+	if t.needHash {
+		sum, err := fileHash(in)
+		if err != nil {
+			return "", err
+		}
+		values["{hash8}"] = sum[:8]
+	}
+
+	out := templatePlaceholder.ReplaceAllStringFunc(t.tmpl, func(ph string) string {
+		v := values[ph]
+		if ph == "{reldir}" {
+			return v
+		}
+		return unsafeNameChars.ReplaceAllString(v, "_")
+	})
+	// An empty {reldir} may leave a leading slash; outputs are always anchored by the caller
+	out = filepath.Clean(filepath.FromSlash(strings.TrimLeft(out, "/")))
+	if out == "." {
+		return "", fmt.Errorf("name template renders an empty file name")
+	}
+	if !strings.EqualFold(filepath.Ext(out), "."+t.ext) {
+		out += "." + t.ext
+	}
+	return out, nil
+}
+
+// bookMeta reads the title and sheet names of in using the metadata-only reader.
+func bookMeta(in string) (*osheet.Book, error) {
+	br, err := osheet.OpenBookReader(in)
+	if err != nil {
+		return nil, fmt.Errorf("read metadata: %w", err)
+	}
+	defer func() { _ = br.Close() }()
+	return br.Book(), nil
+}
+
+// fileHash returns the hex SHA-256 of a file's contents.
+func fileHash(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
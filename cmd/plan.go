@@ -0,0 +1,140 @@
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+
+	appconvert "github.com/romanitalian/osheet2xlsx/v3/internal/convert"
+	"github.com/romanitalian/osheet2xlsx/v3/internal/osheet"
+)
+
+// plannedOutput pairs an input file with the output path it will be converted to.
+type plannedOutput struct {
+	in  string
+	out string
+	// files lists every file written for out; per-sheet formats write several.
+	files []string
+}
+
+// planOutputs resolves the output path of every input before any conversion starts.
+// root is the directory inputs were discovered from; it anchors {reldir} and --preserve-dirs.
+func planOutputs(inputs []string, root string, opts *convertOptions) ([]plannedOutput, error) {
+	tmpl := opts.nameTemplate
+	if tmpl == "" {
+		tmpl = defaultNameTemplate
+		if opts.preserveDirs {
+			tmpl = preserveNameTemplate
+		}
+	}
+	format, err := appconvert.LookupFormat(opts.format)
+	if err != nil {
+		return nil, err
+	}
+	names, err := parseNameTemplate(tmpl, format, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	needBook := names.needBook && opts.out == ""
+
+	plan := make([]plannedOutput, 0, len(inputs))
+	var invalid []string
+	for _, in := range inputs {
+		// The metadata serves both the template and the per-sheet file list
+		var book *osheet.Book
+		if needBook || format.PerSheet {
+			if book, err = bookMeta(in); err != nil && needBook {
+				invalid = append(invalid, fmt.Sprintf("  %s: %v", in, err))
+				continue
+			}
+		}
+		out, err := outputPathFor(in, root, names, book, opts)
+		if err != nil {
+			invalid = append(invalid, fmt.Sprintf("  %s: %v", in, err))
+			continue
+		}
+		files, err := appconvert.Outputs(book, out, appconvert.Options{Format: opts.format, Sheet: opts.sheet})
+		if err != nil {
+			return nil, err
+		}
+		plan = append(plan, plannedOutput{in: in, out: out, files: files})
+	}
+	if len(invalid) > 0 {
+		return nil, fmt.Errorf("invalid output path for %d input(s):\n%s", len(invalid), strings.Join(invalid, "\n"))
+	}
+	if clashes := findCollisions(plan); len(clashes) > 0 {
+		return nil, fmt.Errorf("%w: %d output(s) claimed by more than one input:\n%s",
+			ErrOutputCollision, len(clashes), strings.Join(clashes, "\n"))
+	}
+	return plan, nil
+}
+
+// outputPathFor computes the output path for a single input.
+func outputPathFor(in string, root string, names *nameTemplate, book *osheet.Book, opts *convertOptions) (string, error) {
+	if opts.out != "" {
+		return opts.out, nil
+	}
+	rendered, err := names.render(in, root, book)
+	if err != nil {
+		return "", err
+	}
+
+	if opts.outDir == "" {
+		candidate := filepath.Clean(rendered)
+		// Without an out dir the outputs are rooted at the working directory
+		if !isWithinDir(candidate, ".") {
+			return "", fmt.Errorf("output escapes working directory")
+		}
+		return candidate, nil
+	}
+	// Protect against path traversal: ensure the final path stays within outDir
+	cleanDir := filepath.Clean(opts.outDir)
+	candidate := filepath.Join(cleanDir, rendered)
+	if !isWithinDir(candidate, cleanDir) {
+		return "", fmt.Errorf("output escapes %s", cleanDir)
+	}
+	return candidate, nil
+}
+
+// foldOutputCase reports whether output paths differing only in case name the
+// same file, as on the default macOS and Windows filesystems.
+var foldOutputCase = runtime.GOOS == "darwin" || runtime.GOOS == "windows"
+
+// findCollisions reports every output file claimed by more than one input.
+// Cleaned paths are compared exactly, or case-insensitively where
+// foldOutputCase is set.
+func findCollisions(plan []plannedOutput) []string {
+	type claim struct {
+		path string
+		ins  []string
+	}
+	byOut := make(map[string]*claim)
+	var keys []string
+	for _, p := range plan {
+		for _, file := range p.files {
+			key := filepath.Clean(file)
+			if foldOutputCase {
+				key = strings.ToLower(key)
+			}
+			c, seen := byOut[key]
+			if !seen {
+				c = &claim{path: file}
+				byOut[key] = c
+				keys = append(keys, key)
+			}
+			c.ins = append(c.ins, p.in)
+		}
+	}
+	sort.Strings(keys)
+
+	var clashes []string
+	for _, key := range keys {
+		if c := byOut[key]; len(c.ins) > 1 {
+			clashes = append(clashes, fmt.Sprintf("  %s <- %s", c.path, strings.Join(c.ins, ", ")))
+		}
+	}
+	return clashes
+}
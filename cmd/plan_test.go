@@ -0,0 +1,167 @@
+package cmd
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/romanitalian/osheet2xlsx/v3/internal/osheet"
+)
+
+func TestPlanOutputs_FlatCollides(t *testing.T) {
+	inputs := []string{
+		filepath.Join("share", "2023", "report.osheet"),
+		filepath.Join("share", "2024", "report.osheet"),
+	}
+	_, err := planOutputs(inputs, "share", &convertOptions{outDir: "out"})
+	if !errors.Is(err, ErrOutputCollision) {
+		t.Fatalf("expected collision error, got %v", err)
+	}
+	msg := err.Error()
+	for _, want := range []string{"report.xlsx", "2023", "2024"} {
+		if !strings.Contains(msg, want) {
+			t.Errorf("collision report missing %q:\n%s", want, msg)
+		}
+	}
+}
+
+func TestPlanOutputs_CaseOnlyCollides(t *testing.T) {
+	defer func(fold bool) { foldOutputCase = fold }(foldOutputCase)
+	inputs := []string{"A.osheet", "a.osheet"}
+
+	// Case-sensitive filesystems keep both outputs
+	foldOutputCase = false
+	if _, err := planOutputs(inputs, ".", &convertOptions{outDir: "out"}); err != nil {
+		t.Errorf("case-sensitive plan: %v", err)
+	}
+	foldOutputCase = true
+	_, err := planOutputs(inputs, ".", &convertOptions{outDir: "out"})
+	if !errors.Is(err, ErrOutputCollision) {
+		t.Fatalf("expected collision error, got %v", err)
+	}
+	if msg := err.Error(); !strings.Contains(msg, "A.osheet, a.osheet") {
+		t.Errorf("collision report:\n%s", msg)
+	}
+}
+
+func TestPlanOutputs_PreserveDirs(t *testing.T) {
+	inputs := []string{
+		filepath.Join("share", "2023", "report.osheet"),
+		filepath.Join("share", "2024", "report.osheet"),
+		filepath.Join("share", "top.osheet"),
+	}
+	plan, err := planOutputs(inputs, "share", &convertOptions{outDir: "out", preserveDirs: true})
+	if err != nil {
+		t.Fatalf("plan: %v", err)
+	}
+	want := []string{
+		filepath.Join("out", "2023", "report.xlsx"),
+		filepath.Join("out", "2024", "report.xlsx"),
+		filepath.Join("out", "top.xlsx"),
+	}
+	for i, p := range plan {
+		if p.out != want[i] {
+			t.Errorf("plan[%d] = %q, want %q", i, p.out, want[i])
+		}
+	}
+}
+
+func TestPlanOutputs_SingleOutForManyInputs(t *testing.T) {
+	_, err := planOutputs([]string{"a.osheet", "b.osheet"}, ".", &convertOptions{out: "x.xlsx"})
+	if !errors.Is(err, ErrOutputCollision) {
+		t.Fatalf("expected collision error, got %v", err)
+	}
+}
+
+func TestPlanOutputs_PerSheetCollides(t *testing.T) {
+	tmp := t.TempDir()
+	// report.osheet writes report_Summary.csv, which report_Summary.osheet also claims
+	book := &osheet.Book{Sheets: []osheet.Sheet{{Name: "Summary"}, {Name: "Data"}}}
+	if err := osheet.WriteBook(book, filepath.Join(tmp, "report.osheet")); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	makeOsheet(t, filepath.Join(tmp, "report_Summary.osheet"))
+	inputs := []string{filepath.Join(tmp, "report.osheet"), filepath.Join(tmp, "report_Summary.osheet")}
+
+	_, err := planOutputs(inputs, tmp, &convertOptions{outDir: "out", format: "csv"})
+	if !errors.Is(err, ErrOutputCollision) {
+		t.Fatalf("expected collision error, got %v", err)
+	}
+	if msg := err.Error(); !strings.Contains(msg, "report_Summary.csv <- ") || strings.Contains(msg, "report_Data.csv") {
+		t.Errorf("collision report:\n%s", msg)
+	}
+	// Only the selected sheet is written, so nothing clashes
+	if _, err := planOutputs(inputs, tmp, &convertOptions{outDir: "out", format: "csv", sheet: "Data"}); err != nil {
+		t.Errorf("plan with --sheet: %v", err)
+	}
+}
+
+func TestPlanOutputs_NameTemplate(t *testing.T) {
+	tmp := t.TempDir()
+	in := filepath.Join(tmp, "2023", "q1", "report.osheet")
+	if err := os.MkdirAll(filepath.Dir(in), 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	makeOsheet(t, in)
+
+	opts := &convertOptions{outDir: "out", nameTemplate: "{reldir}/{name}_{date}-{dir}-{title}-{hash8}"}
+	plan, err := planOutputs([]string{in}, tmp, opts)
+	if err != nil {
+		t.Fatalf("plan: %v", err)
+	}
+	sum, err := fileHash(in)
+	if err != nil {
+		t.Fatalf("hash: %v", err)
+	}
+	date := time.Now().Format(nameTemplateDateStyle)
+	want := filepath.Join("out", "2023", "q1", "report_"+date+"-q1-report-"+sum[:8]+".xlsx")
+	if plan[0].out != want {
+		t.Fatalf("out = %q, want %q", plan[0].out, want)
+	}
+}
+
+func TestPlanOutputs_NameTemplateSheet(t *testing.T) {
+	tmp := t.TempDir()
+	in := filepath.Join(tmp, "report.osheet")
+	book := &osheet.Book{Sheets: []osheet.Sheet{{Name: "Summary"}, {Name: "a/b"}}}
+	if err := osheet.WriteBook(book, in); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+
+	opts := &convertOptions{outDir: "out", format: "csv", nameTemplate: "{sheet}/{name}"}
+	plan, err := planOutputs([]string{in}, tmp, opts)
+	if err != nil {
+		t.Fatalf("plan: %v", err)
+	}
+	want := []string{filepath.Join("out", "Summary", "report.csv"), filepath.Join("out", "a_b", "report.csv")}
+	if !reflect.DeepEqual(plan[0].files, want) {
+		t.Errorf("files = %q, want %q", plan[0].files, want)
+	}
+	opts.sheet = "a/b"
+	if plan, err = planOutputs([]string{in}, tmp, opts); err != nil {
+		t.Fatalf("plan with --sheet: %v", err)
+	}
+	if !reflect.DeepEqual(plan[0].files, want[1:]) {
+		t.Errorf("files with --sheet = %q", plan[0].files)
+	}
+}
+
+func TestPlanOutputs_NameTemplateRejected(t *testing.T) {
+	cases := map[string]string{
+		"unknown":   "{name}_{nope}.xlsx",
+		"traversal": "../{name}.xlsx",
+		"empty":     "{reldir}/",
+		// xlsx writes every sheet to one file
+		"sheet": "{name}_{sheet}",
+	}
+	for label, tmpl := range cases {
+		_, err := planOutputs([]string{"a.osheet"}, ".", &convertOptions{outDir: "out", nameTemplate: tmpl})
+		if err == nil {
+			t.Errorf("%s: expected error for %q", label, tmpl)
+		}
+	}
+}
@@ -0,0 +1,60 @@
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	appcfg "github.com/romanitalian/osheet2xlsx/v3/internal/config"
+	appconvert "github.com/romanitalian/osheet2xlsx/v3/internal/convert"
+	applog "github.com/romanitalian/osheet2xlsx/v3/internal/log"
+)
+
+func newReverseCmd() *cobra.Command {
+	var (
+		out       string
+		overwrite bool
+		binary    bool
+	)
+	cmd := &cobra.Command{
+		Use:     "reverse <file.xlsx>",
+		Aliases: []string{"import"},
+		Short:   "Convert an .xlsx workbook back to .osheet",
+		Args:    cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			in := args[0]
+			if !strings.EqualFold(filepath.Ext(in), ".xlsx") {
+				return fmt.Errorf("invalid input argument: expected .xlsx file, got %s", in)
+			}
+			if !cmd.Flags().Changed("overwrite") {
+				if cfg, err := appcfg.Load(); err == nil && cfg.Convert.Overwrite {
+					overwrite = true
+				}
+			}
+			logger := applog.Get()
+			logger.Info(fmt.Sprintf("reverse: input=%q out=%q overwrite=%t binary=%t", in, out, overwrite, binary))
+
+			outPath, err := appconvert.Reverse(in, out, appconvert.ReverseOptions{Overwrite: overwrite, Binary: binary})
+			if err != nil {
+				if jsonLog {
+					fmt.Fprintf(getOutputWriter(), `{"event":"reverse_error","input":"%s","error":"%v"}`+"\n", in, err)
+				} else {
+					logger.Error(fmt.Sprintf("reverse failed for %s: %v", in, err))
+				}
+				return err
+			}
+			if jsonLog {
+				fmt.Fprintf(getOutputWriter(), `{"event":"reverse_ok","input":"%s","output":"%s"}`+"\n", in, outPath)
+			} else {
+				fmt.Fprintf(getOutputWriter(), "OK: %s -> %s\n", in, outPath)
+			}
+			return nil
+		},
+	}
+	cmd.Flags().StringVar(&out, "out", "", "output file path (default <name>.osheet)")
+	cmd.Flags().BoolVar(&overwrite, "overwrite", false, "overwrite existing output file")
+	cmd.Flags().BoolVar(&binary, "binary", false, "write the Synology binary .osheet container instead of ZIP")
+	return cmd
+}
@@ -71,8 +71,9 @@ func init() {
 	rootCmd.PersistentFlags().BoolVar(&jsonLog, "json", false, "enable JSON logs")
 
 	// Add conversion flags for direct file input
-	rootCmd.Flags().String("out", "", "output .xlsx file path")
+	rootCmd.Flags().String("out", "", "output file path (default <name>.<format>)")
 	rootCmd.Flags().Bool("overwrite", false, "overwrite existing output files")
+	rootCmd.Flags().String("format", "", "output format: "+strings.Join(appconvert.FormatNames(), ", ")+" (default xlsx)")
 }
 
 // Execute runs the root command.
@@ -80,6 +81,7 @@ func Execute() error {
 	rootCmd.AddCommand(newConvertCmd())
 	rootCmd.AddCommand(newInspectCmd())
 	rootCmd.AddCommand(newValidateCmd())
+	rootCmd.AddCommand(newReverseCmd())
 	rootCmd.AddCommand(newVersionCmd())
 	rootCmd.AddCommand(newCompletionCmd())
 	// Helper used by convert for safe outDir joins
@@ -98,6 +100,10 @@ func runConvert(cmd *cobra.Command, inputPath string) error {
 	if err != nil {
 		return fmt.Errorf("failed to get overwrite flag: %w", err)
 	}
+	formatFlag, err := cmd.Flags().GetString("format")
+	if err != nil {
+		return fmt.Errorf("failed to get format flag: %w", err)
+	}
 
 	// Create default options for single file conversion
 	opts := &convertOptions{
@@ -106,6 +112,7 @@ func runConvert(cmd *cobra.Command, inputPath string) error {
 		parallel:  1,
 		out:       outFlag,
 		overwrite: overwriteFlag,
+		format:    formatFlag,
 	}
 
 	// Load config for defaults
@@ -118,6 +125,18 @@ func runConvert(cmd *cobra.Command, inputPath string) error {
 	if !overwriteFlag && cfg.Convert.Overwrite {
 		opts.overwrite = true
 	}
+	opts.streamThreshold = cfg.Convert.StreamThreshold
+	opts.stream = cfg.Convert.Stream
+	opts.nameTemplate = cfg.Convert.NameTemplate
+	if formatFlag == "" {
+		opts.format = cfg.Convert.Format
+	}
+	opts.formulas = cfg.Convert.Formulas
+	opts.numberLocale = cfg.Convert.NumberLocale
+	opts.asTable = cfg.Convert.AsTable
+	opts.stripProtection = cfg.Convert.StripProtection
+	opts.props.author = cfg.Convert.Author
+	opts.provenance = cfg.Convert.Provenance
 
 	logger := applog.Get()
 	logger.Info(fmt.Sprintf("convert: input=%q out=%q outDir=%q pattern=%q recursive=%t overwrite=%t parallel=%d dryRun=%t progress=%t failFast=%t",
@@ -126,20 +145,24 @@ func runConvert(cmd *cobra.Command, inputPath string) error {
 
 	// Single file conversion
 
+	convOpts, err := opts.convertOptions()
+	if err != nil {
+		return err
+	}
+	convOpts.Warn = warnFor(inputPath)
+
 	// Generate output path
-	outPath := opts.out
-	if outPath == "" {
-		base := filepath.Base(inputPath)
-		ext := filepath.Ext(base)
-		name := base[:len(base)-len(ext)]
-		outPath = name + ".xlsx"
+	plan, err := planOutputs([]string{inputPath}, filepath.Dir(inputPath), opts)
+	if err != nil {
+		return err
 	}
+	outPath := plan[0].out
 
 	if jsonLog {
 		fmt.Fprintf(getOutputWriter(), `{"event":"convert_start","input":"%s","output":"%s"}`+"\n", inputPath, outPath)
 	}
 
-	produced, err := appconvert.ConvertSingle(inputPath, outPath, opts.overwrite)
+	produced, err := appconvert.Convert(inputPath, outPath, convOpts)
 	if err != nil {
 		if jsonLog {
 			fmt.Fprintf(getOutputWriter(), `{"event":"convert_error","input":"%s","error":"%v"}`+"\n", inputPath, err)
@@ -149,10 +172,12 @@ func runConvert(cmd *cobra.Command, inputPath string) error {
 		return err
 	}
 
-	if jsonLog {
-		fmt.Fprintf(getOutputWriter(), `{"event":"convert_ok","input":"%s","output":"%s"}`+"\n", inputPath, produced)
-	} else {
-		fmt.Fprintf(getOutputWriter(), "OK: %s -> %s\n", inputPath, produced)
+	for _, out := range produced {
+		if jsonLog {
+			fmt.Fprintf(getOutputWriter(), `{"event":"convert_ok","input":"%s","output":"%s"}`+"\n", inputPath, out)
+		} else {
+			fmt.Fprintf(getOutputWriter(), "OK: %s -> %s\n", inputPath, out)
+		}
 	}
 
 	return nil
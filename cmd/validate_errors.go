@@ -4,3 +4,6 @@ import "errors"
 
 // ErrValidateStructure signals structural validation failure for exit-code mapping.
 var ErrValidateStructure = errors.New("validate: structure invalid")
+
+// ErrOutputCollision signals that several inputs of a batch map to the same output file.
+var ErrOutputCollision = errors.New("convert: output collision")
@@ -27,6 +27,45 @@ type ConvertConfig struct {
 	DryRun    bool   `json:"dryRun"`
 	Progress  bool   `json:"progress"`
 	FailFast  bool   `json:"failFast"`
+	// StreamThreshold is the per-sheet cell count that switches to streaming XLSX writes.
+	StreamThreshold int `json:"streamThreshold"`
+	// Stream reads and writes row by row instead of loading whole books.
+	Stream bool `json:"stream"`
+	// PreserveDirs mirrors each input's directory (relative to the scan root) under OutDir.
+	PreserveDirs bool `json:"preserveDirs"`
+	// NameTemplate renders output paths, e.g. "{reldir}/{name}_{date}.xlsx".
+	NameTemplate string `json:"nameTemplate"`
+	// Format selects the output format: xlsx (default), csv or tsv.
+	Format string `json:"format"`
+	// Sheet converts only the named sheet.
+	Sheet string    `json:"sheet"`
+	CSV   CSVConfig `json:"csv"`
+	// NDJSONHeader emits ndjson rows as objects keyed by the first row.
+	NDJSONHeader bool `json:"ndjsonHeader"`
+	// Formulas is keep (default), cache or values for xlsx output.
+	Formulas string `json:"formulas"`
+	// NumberLocale is "en" to read "1,200" as a thousands-separated number.
+	NumberLocale string `json:"numberLocale"`
+	// AsTable turns xlsx sheet data under a detected header row into a table.
+	AsTable bool `json:"asTable"`
+	// StripProtection writes xlsx output without sheet and workbook protection.
+	StripProtection bool `json:"stripProtection"`
+	// Author overrides the author document property of every output.
+	Author string `json:"author"`
+	// Provenance stamps the source file, conversion time and tool version
+	// into every output as custom document properties.
+	Provenance bool `json:"provenance"`
+}
+
+// CSVConfig holds the csv/tsv dialect defaults.
+type CSVConfig struct {
+	Delimiter  string `json:"delimiter"`
+	Quote      string `json:"quote"`
+	LineEnding string `json:"lineEnding"`
+	BOM        bool   `json:"bom"`
+	Dates      string `json:"dates"`
+	True       string `json:"true"`
+	False      string `json:"false"`
 }
 
 var loaded *Config
@@ -88,6 +127,66 @@ func Load() (*Config, error) {
 	if v := os.Getenv("OS2X_CONVERT_FAIL_FAST"); v != "" {
 		cfg.Convert.FailFast = parseBool(v)
 	}
+	if v := os.Getenv("OS2X_CONVERT_STREAM_THRESHOLD"); v != "" {
+		cfg.Convert.StreamThreshold = parseInt(v)
+	}
+	if v := os.Getenv("OS2X_CONVERT_STREAM"); v != "" {
+		cfg.Convert.Stream = parseBool(v)
+	}
+	if v := os.Getenv("OS2X_CONVERT_PRESERVE_DIRS"); v != "" {
+		cfg.Convert.PreserveDirs = parseBool(v)
+	}
+	if v := os.Getenv("OS2X_CONVERT_NAME_TEMPLATE"); v != "" {
+		cfg.Convert.NameTemplate = v
+	}
+	if v := os.Getenv("OS2X_CONVERT_FORMAT"); v != "" {
+		cfg.Convert.Format = v
+	}
+	if v := os.Getenv("OS2X_CONVERT_SHEET"); v != "" {
+		cfg.Convert.Sheet = v
+	}
+	if v := os.Getenv("OS2X_CONVERT_NDJSON_HEADER"); v != "" {
+		cfg.Convert.NDJSONHeader = parseBool(v)
+	}
+	if v := os.Getenv("OS2X_CONVERT_FORMULAS"); v != "" {
+		cfg.Convert.Formulas = v
+	}
+	if v := os.Getenv("OS2X_CONVERT_NUMBER_LOCALE"); v != "" {
+		cfg.Convert.NumberLocale = v
+	}
+	if v := os.Getenv("OS2X_CONVERT_AS_TABLE"); v != "" {
+		cfg.Convert.AsTable = parseBool(v)
+	}
+	if v := os.Getenv("OS2X_CONVERT_STRIP_PROTECTION"); v != "" {
+		cfg.Convert.StripProtection = parseBool(v)
+	}
+	if v := os.Getenv("OS2X_CONVERT_AUTHOR"); v != "" {
+		cfg.Convert.Author = v
+	}
+	if v := os.Getenv("OS2X_CONVERT_PROVENANCE"); v != "" {
+		cfg.Convert.Provenance = parseBool(v)
+	}
+	if v := os.Getenv("OS2X_CSV_DELIMITER"); v != "" {
+		cfg.Convert.CSV.Delimiter = v
+	}
+	if v := os.Getenv("OS2X_CSV_QUOTE"); v != "" {
+		cfg.Convert.CSV.Quote = v
+	}
+	if v := os.Getenv("OS2X_CSV_LINE_ENDING"); v != "" {
+		cfg.Convert.CSV.LineEnding = v
+	}
+	if v := os.Getenv("OS2X_CSV_BOM"); v != "" {
+		cfg.Convert.CSV.BOM = parseBool(v)
+	}
+	if v := os.Getenv("OS2X_CSV_DATES"); v != "" {
+		cfg.Convert.CSV.Dates = v
+	}
+	if v := os.Getenv("OS2X_CSV_TRUE"); v != "" {
+		cfg.Convert.CSV.True = v
+	}
+	if v := os.Getenv("OS2X_CSV_FALSE"); v != "" {
+		cfg.Convert.CSV.False = v
+	}
 
 	loaded = cfg
 	return cfg, nil
@@ -145,6 +244,56 @@ func merge(dst, src *Config) {
 	dst.Convert.DryRun = dst.Convert.DryRun || src.Convert.DryRun
 	dst.Convert.Progress = dst.Convert.Progress || src.Convert.Progress
 	dst.Convert.FailFast = dst.Convert.FailFast || src.Convert.FailFast
+	if src.Convert.StreamThreshold != 0 {
+		dst.Convert.StreamThreshold = src.Convert.StreamThreshold
+	}
+	dst.Convert.Stream = dst.Convert.Stream || src.Convert.Stream
+	dst.Convert.PreserveDirs = dst.Convert.PreserveDirs || src.Convert.PreserveDirs
+	if src.Convert.NameTemplate != "" {
+		dst.Convert.NameTemplate = src.Convert.NameTemplate
+	}
+	if src.Convert.Format != "" {
+		dst.Convert.Format = src.Convert.Format
+	}
+	if src.Convert.Sheet != "" {
+		dst.Convert.Sheet = src.Convert.Sheet
+	}
+	mergeCSV(&dst.Convert.CSV, src.Convert.CSV)
+	dst.Convert.NDJSONHeader = dst.Convert.NDJSONHeader || src.Convert.NDJSONHeader
+	if src.Convert.Formulas != "" {
+		dst.Convert.Formulas = src.Convert.Formulas
+	}
+	if src.Convert.NumberLocale != "" {
+		dst.Convert.NumberLocale = src.Convert.NumberLocale
+	}
+	dst.Convert.AsTable = dst.Convert.AsTable || src.Convert.AsTable
+	dst.Convert.StripProtection = dst.Convert.StripProtection || src.Convert.StripProtection
+	if src.Convert.Author != "" {
+		dst.Convert.Author = src.Convert.Author
+	}
+	dst.Convert.Provenance = dst.Convert.Provenance || src.Convert.Provenance
+}
+
+func mergeCSV(dst *CSVConfig, src CSVConfig) {
+	if src.Delimiter != "" {
+		dst.Delimiter = src.Delimiter
+	}
+	if src.Quote != "" {
+		dst.Quote = src.Quote
+	}
+	if src.LineEnding != "" {
+		dst.LineEnding = src.LineEnding
+	}
+	dst.BOM = dst.BOM || src.BOM
+	if src.Dates != "" {
+		dst.Dates = src.Dates
+	}
+	if src.True != "" {
+		dst.True = src.True
+	}
+	if src.False != "" {
+		dst.False = src.False
+	}
 }
 
 func parseBool(s string) bool {
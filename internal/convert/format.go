@@ -0,0 +1,126 @@
+package convert
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	appcsv "github.com/romanitalian/osheet2xlsx/v3/internal/csv"
+	appjson "github.com/romanitalian/osheet2xlsx/v3/internal/json"
+	"github.com/romanitalian/osheet2xlsx/v3/internal/ods"
+	"github.com/romanitalian/osheet2xlsx/v3/internal/osheet"
+	"github.com/romanitalian/osheet2xlsx/v3/internal/xlsx"
+)
+
+// DefaultFormat is used when Options.Format is empty.
+const DefaultFormat = "xlsx"
+
+// Format writes a parsed book in one output format.
+type Format struct {
+	// Ext is the file extension (without the dot) used for default output names.
+	Ext string
+	// Outputs lists the files Write will create for book at out, for overwrite checks.
+	Outputs func(book *osheet.Book, out string) []string
+	// PerSheet is set when Outputs depends on the sheets of the book.
+	PerSheet bool
+	// Write writes book and returns the files produced.
+	Write func(book *osheet.Book, out string, opts Options) ([]string, error)
+}
+
+var formats = map[string]Format{}
+
+// RegisterFormat makes a format selectable by name (case-insensitive).
+func RegisterFormat(name string, f Format) {
+	formats[strings.ToLower(name)] = f
+}
+
+// LookupFormat returns the named format; an empty name selects DefaultFormat.
+func LookupFormat(name string) (Format, error) {
+	if name == "" {
+		name = DefaultFormat
+	}
+	f, ok := formats[strings.ToLower(name)]
+	if !ok {
+		return Format{}, fmt.Errorf("invalid format argument %q (supported: %s)", name, strings.Join(FormatNames(), ", "))
+	}
+	return f, nil
+}
+
+// FormatNames lists the registered formats in sorted order.
+func FormatNames() []string {
+	names := make([]string, 0, len(formats))
+	for name := range formats {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func singleOutput(_ *osheet.Book, out string) []string { return []string{out} }
+
+func init() {
+	RegisterFormat("xlsx", Format{
+		Ext:     "xlsx",
+		Outputs: singleOutput,
+		Write: func(book *osheet.Book, out string, opts Options) ([]string, error) {
+			if err := xlsx.WriteBookWithOptions(book, out, xlsx.Options{
+				StreamThreshold: opts.StreamThreshold,
+				Formulas:        opts.Formulas,
+				AsTable:         opts.AsTable,
+				StripProtection: opts.StripProtection,
+				Warn:            opts.Warn,
+			}); err != nil {
+				return nil, err
+			}
+			return []string{out}, nil
+		},
+	})
+	RegisterFormat("csv", Format{
+		Ext:      "csv",
+		Outputs:  appcsv.SheetPaths,
+		PerSheet: true,
+		Write: func(book *osheet.Book, out string, opts Options) ([]string, error) {
+			return appcsv.WriteBook(book, out, opts.CSV)
+		},
+	})
+	RegisterFormat("tsv", Format{
+		Ext:      "tsv",
+		Outputs:  appcsv.SheetPaths,
+		PerSheet: true,
+		Write: func(book *osheet.Book, out string, opts Options) ([]string, error) {
+			csvOpts := opts.CSV
+			csvOpts.Delimiter = '\t'
+			return appcsv.WriteBook(book, out, csvOpts)
+		},
+	})
+	RegisterFormat("json", Format{
+		Ext:     "json",
+		Outputs: singleOutput,
+		Write: func(book *osheet.Book, out string, opts Options) ([]string, error) {
+			if err := appjson.WriteBook(book, out, false, opts.JSON); err != nil {
+				return nil, err
+			}
+			return []string{out}, nil
+		},
+	})
+	RegisterFormat("ndjson", Format{
+		Ext:     "ndjson",
+		Outputs: singleOutput,
+		Write: func(book *osheet.Book, out string, opts Options) ([]string, error) {
+			if err := appjson.WriteBook(book, out, true, opts.JSON); err != nil {
+				return nil, err
+			}
+			return []string{out}, nil
+		},
+	})
+	RegisterFormat("ods", Format{
+		Ext:     "ods",
+		Outputs: singleOutput,
+		Write: func(book *osheet.Book, out string, opts Options) ([]string, error) {
+			if err := ods.WriteBookWithOptions(book, out, ods.Options{Warn: opts.Warn}); err != nil {
+				return nil, err
+			}
+			return []string{out}, nil
+		},
+	})
+}
@@ -2,54 +2,224 @@ package convert
 
 import (
 	"errors"
+	"fmt"
 	"path/filepath"
 	"strings"
 
+	appcsv "github.com/romanitalian/osheet2xlsx/v3/internal/csv"
 	appfs "github.com/romanitalian/osheet2xlsx/v3/internal/fs"
+	appjson "github.com/romanitalian/osheet2xlsx/v3/internal/json"
 	"github.com/romanitalian/osheet2xlsx/v3/internal/osheet"
 	"github.com/romanitalian/osheet2xlsx/v3/internal/xlsx"
 )
 
-// ConvertSingle is a placeholder that writes an empty XLSX next to the input file.
-func ConvertSingle(inputPath string, outputPath string, overwrite bool) (string, error) {
+// Options controls a single conversion.
+type Options struct {
+	Overwrite bool
+	// StreamThreshold is the per-sheet cell count above which the streaming
+	// XLSX writer is used (0 = xlsx.DefaultStreamThreshold, negative = never).
+	StreamThreshold int
+	// Stream pipes rows from the source straight into the streaming writer
+	// instead of parsing the whole book first.
+	Stream bool
+	// Format names a registered output format (empty = DefaultFormat).
+	Format string
+	// Sheet, when set, converts only the sheet with this name.
+	Sheet string
+	// CSV controls the csv and tsv formats.
+	CSV appcsv.Options
+	// JSON controls the ndjson format.
+	JSON appjson.Options
+	// Formulas selects how xlsx output stores formula results (xlsx.FormulasKeep,
+	// FormulasCache or FormulasValues; empty means keep).
+	Formulas string
+	// NumberLocale selects how numeric text in the input is read
+	// (osheet.NumberLocaleDefault or NumberLocaleEN).
+	NumberLocale string
+	// AsTable turns xlsx sheet data under a detected header row into an
+	// Excel table (see xlsx.Options.AsTable).
+	AsTable bool
+	// StripProtection writes xlsx output without sheet and workbook
+	// protection (see xlsx.Options.StripProtection).
+	StripProtection bool
+	// Title and Properties override the document's own; empty fields keep
+	// them and custom properties replace those of the same name.
+	Title      string
+	Properties osheet.DocProperties
+	// Provenance names the converting tool and version; when set, the
+	// PropSourceFile, PropConvertedAt and PropConvertedBy custom properties
+	// are stamped into the output.
+	Provenance string
+	// Warn receives non-fatal conversion warnings, such as formulas using
+	// functions Excel does not know; nil discards them.
+	Warn func(string)
+}
+
+// ConvertSingle converts one input and returns the first file produced.
+// Use Convert for formats that write one file per sheet.
+func ConvertSingle(inputPath string, outputPath string, opts Options) (string, error) {
+	produced, err := Convert(inputPath, outputPath, opts)
+	if err != nil {
+		return "", err
+	}
+	return produced[0], nil
+}
+
+// Convert converts one input into the selected format, by default next to the
+// working directory, and returns every file produced.
+func Convert(inputPath string, outputPath string, opts Options) ([]string, error) {
+	format, err := LookupFormat(opts.Format)
+	if err != nil {
+		return nil, err
+	}
 	out := outputPath
 	if out == "" {
 		base := filepath.Base(inputPath)
 		ext := filepath.Ext(base)
 		name := base[:len(base)-len(ext)]
-		out = name + ".xlsx"
+		out = name + "." + format.Ext
 	}
 
 	if err := appfs.EnsureParentDir(out); err != nil {
-		return "", err
+		return nil, err
 	}
 
 	// Path traversal protection when output directory is set by caller
 	// Note: outputPath == "" && outputPath != out is always false, so this check is redundant
-	// The logic is handled above where we set out = name + ".xlsx" when outputPath is empty
+	// The logic is handled above where we set out = name + "." + ext when outputPath is empty
 
 	// batch mode may set outDir upstream; ensure that when caller provides absolute out in opts, it is intended.
 	// No additional checks needed here as the logic is handled in the calling code
 	// If caller provided an out path under a directory, ensure that when using outDir externally, they validate.
 	// Additionally, guard against attempts like name with path separators (should be stripped by Base())
 	if strings.ContainsAny(filepath.Base(out), string([]rune{filepath.Separator})) {
-		return "", errors.New("invalid output file name")
+		return nil, errors.New("invalid output file name")
 	}
 
-	if !overwrite {
-		if ok, err := appfs.FileExists(out); err != nil {
-			return "", err
+	// Row streaming only covers whole-book XLSX output without formula evaluation
+	if opts.Stream && strings.EqualFold(orDefault(opts.Format), "xlsx") && opts.Sheet == "" && !evaluatesFormulas(opts) {
+		if err := checkOverwrite([]string{out}, opts.Overwrite); err != nil {
+			return nil, err
+		}
+		err := convertStreaming(inputPath, out, opts)
+		if err == nil {
+			return []string{out}, nil
+		}
+		// Sources with unordered rows cannot be streamed; parse them in memory instead
+		if !errors.Is(err, osheet.ErrRowOrder) {
+			return nil, err
+		}
+	}
+
+	book, err := osheet.ReadBookUniversalWithOptions(inputPath, osheet.ReadOptions{NumberLocale: opts.NumberLocale})
+	if err != nil {
+		return nil, err
+	}
+	if opts.Sheet != "" {
+		if book, err = selectSheet(book, opts.Sheet); err != nil {
+			return nil, err
+		}
+	}
+	applyProperties(book, inputPath, opts)
+	if err := checkOverwrite(format.Outputs(book, out), opts.Overwrite); err != nil {
+		return nil, err
+	}
+	return format.Write(book, out, opts)
+}
+
+// Outputs lists the files Convert will create at out for an input whose
+// metadata (osheet.BookReader.Book) is book. Formats writing one file per
+// sheet need the sheet names; when the input could not be read (nil book)
+// the conversion reports the error, so out alone is listed.
+func Outputs(book *osheet.Book, out string, opts Options) ([]string, error) {
+	format, err := LookupFormat(opts.Format)
+	if err != nil {
+		return nil, err
+	}
+	if !format.PerSheet {
+		return format.Outputs(nil, out), nil
+	}
+	if book == nil {
+		return []string{out}, nil
+	}
+	if opts.Sheet != "" {
+		if book, err = selectSheet(book, opts.Sheet); err != nil {
+			return []string{out}, nil
+		}
+	}
+	return format.Outputs(book, out), nil
+}
+
+// evaluatesFormulas reports whether formula results must be computed, which
+// needs the whole book in memory.
+func evaluatesFormulas(opts Options) bool {
+	return opts.Formulas != "" && opts.Formulas != xlsx.FormulasKeep
+}
+
+func orDefault(format string) string {
+	if format == "" {
+		return DefaultFormat
+	}
+	return format
+}
+
+// checkOverwrite refuses to replace existing outputs unless overwrite is set.
+func checkOverwrite(paths []string, overwrite bool) error {
+	if overwrite {
+		return nil
+	}
+	for _, p := range paths {
+		if ok, err := appfs.FileExists(p); err != nil {
+			return err
 		} else if ok {
-			return "", errors.New("output exists; use --overwrite to replace")
+			return fmt.Errorf("output exists (%s); use --overwrite to replace", p)
 		}
 	}
+	return nil
+}
 
-	book, err := osheet.ReadBookUniversal(inputPath)
+// selectSheet returns a copy of book holding only the named sheet, with the
+// workbook's protection and the defined names visible from that sheet.
+func selectSheet(book *osheet.Book, name string) (*osheet.Book, error) {
+	for i := range book.Sheets {
+		if book.Sheets[i].Name == name {
+			out := &osheet.Book{
+				Title:      book.Title,
+				Sheets:     []osheet.Sheet{book.Sheets[i]},
+				Protection: book.Protection,
+				Properties: book.Properties,
+			}
+			for _, dn := range book.DefinedNames {
+				if dn.Scope == "" || dn.Scope == name {
+					out.DefinedNames = append(out.DefinedNames, dn)
+				}
+			}
+			return out, nil
+		}
+	}
+	names := make([]string, len(book.Sheets))
+	for i := range book.Sheets {
+		names[i] = book.Sheets[i].Name
+	}
+	return nil, fmt.Errorf("sheet %q not found (have: %s)", name, strings.Join(names, ", "))
+}
+
+// convertStreaming converts row by row from the source reader to the XLSX
+// stream writer. Warnings are held back until the stream completes, so a
+// fallback to the in-memory path does not report them twice.
+func convertStreaming(inputPath string, out string, opts Options) error {
+	br, err := osheet.OpenBookReaderWithOptions(inputPath, osheet.ReadOptions{NumberLocale: opts.NumberLocale})
 	if err != nil {
-		return "", err
+		return err
 	}
-	if err := xlsx.WriteBook(book, out); err != nil {
-		return "", err
+	defer func() { _ = br.Close() }()
+	applyProperties(br.Book(), inputPath, opts)
+	var warnings []string
+	err = xlsx.WriteBookReaderWithOptions(br, out, xlsx.Options{AsTable: opts.AsTable, StripProtection: opts.StripProtection, Warn: func(w string) { warnings = append(warnings, w) }})
+	if err == nil && opts.Warn != nil {
+		for _, w := range warnings {
+			opts.Warn(w)
+		}
 	}
-	return out, nil
+	return err
 }
@@ -0,0 +1,61 @@
+package convert
+
+import (
+	"path/filepath"
+	"time"
+
+	"github.com/romanitalian/osheet2xlsx/v3/internal/osheet"
+)
+
+// Provenance custom properties stamped by Options.Provenance.
+const (
+	PropSourceFile  = "SourceFile"
+	PropConvertedAt = "ConvertedAt"
+	PropConvertedBy = "ConvertedBy"
+)
+
+// applyProperties overrides the document properties of book with those set
+// in opts and stamps the provenance of the conversion.
+func applyProperties(book *osheet.Book, inputPath string, opts Options) {
+	if opts.Title != "" {
+		book.Title = opts.Title
+	}
+	p, set := &book.Properties, opts.Properties
+	if set.Author != "" {
+		p.Author = set.Author
+	}
+	if set.Description != "" {
+		p.Description = set.Description
+	}
+	if set.Keywords != "" {
+		p.Keywords = set.Keywords
+	}
+	if !set.Created.IsZero() {
+		p.Created = set.Created
+	}
+	if !set.Modified.IsZero() {
+		p.Modified = set.Modified
+	}
+	custom := set.Custom
+	if opts.Provenance != "" {
+		custom = append(custom[:len(custom):len(custom)],
+			osheet.CustomProperty{Name: PropSourceFile, Value: filepath.Base(inputPath)},
+			osheet.CustomProperty{Name: PropConvertedAt, Value: time.Now().UTC().Truncate(time.Second)},
+			osheet.CustomProperty{Name: PropConvertedBy, Value: opts.Provenance},
+		)
+	}
+	for _, c := range custom {
+		setCustomProperty(p, c)
+	}
+}
+
+// setCustomProperty replaces the property named c.Name or appends c.
+func setCustomProperty(p *osheet.DocProperties, c osheet.CustomProperty) {
+	for i := range p.Custom {
+		if p.Custom[i].Name == c.Name {
+			p.Custom[i] = c
+			return
+		}
+	}
+	p.Custom = append(p.Custom, c)
+}
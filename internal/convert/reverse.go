@@ -0,0 +1,41 @@
+package convert
+
+import (
+	"path/filepath"
+	"strings"
+
+	"github.com/romanitalian/osheet2xlsx/v3/internal/osheet"
+	"github.com/romanitalian/osheet2xlsx/v3/internal/xlsx"
+)
+
+// ReverseOptions controls .xlsx to .osheet conversion.
+type ReverseOptions struct {
+	Overwrite bool
+	// Binary writes the Synology binary container instead of a ZIP with document.json.
+	Binary bool
+}
+
+// Reverse converts an .xlsx workbook into an .osheet. An empty outputPath
+// writes <name>.osheet in the current directory. It returns the written path.
+func Reverse(inputPath string, outputPath string, opts ReverseOptions) (string, error) {
+	out := outputPath
+	if out == "" {
+		base := filepath.Base(inputPath)
+		out = strings.TrimSuffix(base, filepath.Ext(base)) + ".osheet"
+	}
+	if err := checkOverwrite([]string{out}, opts.Overwrite); err != nil {
+		return "", err
+	}
+	book, err := xlsx.ReadBook(inputPath)
+	if err != nil {
+		return "", err
+	}
+	write := osheet.WriteBook
+	if opts.Binary {
+		write = osheet.WriteBinaryBook
+	}
+	if err := write(book, out); err != nil {
+		return "", err
+	}
+	return out, nil
+}
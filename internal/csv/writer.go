@@ -0,0 +1,257 @@
+package csv
+
+import (
+	"bufio"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	appfs "github.com/romanitalian/osheet2xlsx/v3/internal/fs"
+	"github.com/romanitalian/osheet2xlsx/v3/internal/osheet"
+)
+
+// Quoting modes.
+const (
+	QuoteMinimal    = "minimal"    // quote only fields that need it
+	QuoteAll        = "all"        // quote every field
+	QuoteNonNumeric = "nonnumeric" // quote every field except numbers
+)
+
+// Date encodings.
+const (
+	DatesISO    = "iso"    // ISO 8601: 2006-01-02 or 2006-01-02T15:04:05
+	DatesSerial = "serial" // Excel serial number, as stored in the model
+)
+
+// Options controls the CSV dialect. Zero values select the defaults noted per field.
+type Options struct {
+	// Delimiter separates fields (default ',').
+	Delimiter rune
+	// Quote is QuoteMinimal (default), QuoteAll or QuoteNonNumeric.
+	Quote string
+	// CRLF ends records with "\r\n" instead of "\n".
+	CRLF bool
+	// BOM prefixes each file with a UTF-8 byte order mark (for Excel on Windows).
+	BOM bool
+	// Dates is DatesISO (default) or DatesSerial.
+	Dates string
+	// True and False spell booleans (default "TRUE" and "FALSE").
+	True  string
+	False string
+}
+
+// Validate reports unknown option values.
+func (o Options) Validate() error {
+	switch o.Quote {
+	case "", QuoteMinimal, QuoteAll, QuoteNonNumeric:
+	default:
+		return fmt.Errorf("invalid csv quote mode %q (want minimal, all or nonnumeric)", o.Quote)
+	}
+	switch o.Dates {
+	case "", DatesISO, DatesSerial:
+	default:
+		return fmt.Errorf("invalid csv date encoding %q (want iso or serial)", o.Dates)
+	}
+	if o.Delimiter == '"' || o.Delimiter == '\r' || o.Delimiter == '\n' {
+		return fmt.Errorf("invalid csv delimiter %q", o.Delimiter)
+	}
+	return nil
+}
+
+func (o Options) withDefaults() Options {
+	if o.Delimiter == 0 {
+		o.Delimiter = ','
+	}
+	if o.Quote == "" {
+		o.Quote = QuoteMinimal
+	}
+	if o.Dates == "" {
+		o.Dates = DatesISO
+	}
+	if o.True == "" {
+		o.True = "TRUE"
+	}
+	if o.False == "" {
+		o.False = "FALSE"
+	}
+	return o
+}
+
+var unsafeFileChars = regexp.MustCompile(`[/\\:*?"<>|\x00-\x1f]`)
+
+// SheetPlaceholder in an output path stands for the sheet name.
+const SheetPlaceholder = "{sheet}"
+
+// SheetPaths returns the file each sheet is written to. A SheetPlaceholder in
+// outPath is replaced by the sanitized sheet name; otherwise a single-sheet
+// book uses outPath as is and other books get the name appended to the base
+// name, e.g. report_Summary.csv.
+func SheetPaths(book *osheet.Book, outPath string) []string {
+	if book == nil || len(book.Sheets) == 0 {
+		return nil
+	}
+	expand := strings.Contains(outPath, SheetPlaceholder)
+	if len(book.Sheets) == 1 && !expand {
+		return []string{outPath}
+	}
+	ext := filepath.Ext(outPath)
+	stem := strings.TrimSuffix(outPath, ext)
+	sheetPath := func(name string) string {
+		if expand {
+			return strings.ReplaceAll(outPath, SheetPlaceholder, name)
+		}
+		return stem + "_" + name + ext
+	}
+	paths := make([]string, len(book.Sheets))
+	seen := make(map[string]bool, len(book.Sheets))
+	for i, s := range book.Sheets {
+		name := unsafeFileChars.ReplaceAllString(s.Name, "_")
+		if name == "" {
+			name = fmt.Sprintf("Sheet%d", i+1)
+		}
+		p := sheetPath(name)
+		// Sheet names differing only by sanitized characters must not overwrite each other
+		for n := 2; seen[strings.ToLower(p)]; n++ {
+			p = sheetPath(fmt.Sprintf("%s_%d", name, n))
+		}
+		seen[strings.ToLower(p)] = true
+		paths[i] = p
+	}
+	return paths
+}
+
+// WriteBook writes every sheet of book to its own file and returns the paths written.
+func WriteBook(book *osheet.Book, outPath string, opts Options) ([]string, error) {
+	if book == nil {
+		return nil, errors.New("nil book")
+	}
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+	opts = opts.withDefaults()
+	paths := SheetPaths(book, outPath)
+	if len(paths) == 0 {
+		return nil, errors.New("book has no sheets")
+	}
+	for i := range book.Sheets {
+		if err := writeSheetFile(&book.Sheets[i], paths[i], opts); err != nil {
+			return nil, err
+		}
+	}
+	return paths, nil
+}
+
+func writeSheetFile(s *osheet.Sheet, path string, opts Options) error {
+	if err := appfs.EnsureParentDir(path); err != nil {
+		return err
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriter(f)
+	if err := WriteSheet(w, s, opts); err != nil {
+		_ = f.Close()
+		return fmt.Errorf("write %s: %w", path, err)
+	}
+	if err := w.Flush(); err != nil {
+		_ = f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// WriteSheet writes one sheet as delimited text. Merged ranges keep their value
+// in the top-left cell only; trailing empty cells of a row are kept so every
+// record has the sheet's width. QuoteMinimal leaves quoting to encoding/csv;
+// the other modes quote fields themselves.
+func WriteSheet(w io.Writer, s *osheet.Sheet, opts Options) error {
+	opts = opts.withDefaults()
+	if opts.BOM {
+		if _, err := io.WriteString(w, "\uFEFF"); err != nil {
+			return err
+		}
+	}
+	if opts.Quote == QuoteMinimal {
+		cw := csv.NewWriter(w)
+		cw.Comma = opts.Delimiter
+		cw.UseCRLF = opts.CRLF
+		record := make([]string, s.Width)
+		for _, row := range s.Cells {
+			for c := range record {
+				record[c], _ = cellText(rowCell(row, c), opts)
+			}
+			if err := cw.Write(record); err != nil {
+				return err
+			}
+		}
+		cw.Flush()
+		return cw.Error()
+	}
+
+	eol := "\n"
+	if opts.CRLF {
+		eol = "\r\n"
+	}
+	delim := string(opts.Delimiter)
+	var line strings.Builder
+	for _, row := range s.Cells {
+		line.Reset()
+		for c := 0; c < s.Width; c++ {
+			if c > 0 {
+				line.WriteString(delim)
+			}
+			text, numeric := cellText(rowCell(row, c), opts)
+			if opts.Quote == QuoteNonNumeric && numeric {
+				line.WriteString(text)
+			} else {
+				line.WriteString(`"` + strings.ReplaceAll(text, `"`, `""`) + `"`)
+			}
+		}
+		line.WriteString(eol)
+		if _, err := io.WriteString(w, line.String()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// rowCell returns cell c of row, empty past its end.
+func rowCell(row []osheet.Cell, c int) osheet.Cell {
+	if c < len(row) {
+		return row[c]
+	}
+	return osheet.Cell{}
+}
+
+// cellText renders a cell and reports whether it is a number. Formula cells
+// are written as their cached value; without one they are empty, never
+// "=FORMULA", which spreadsheet apps would run on import.
+func cellText(c osheet.Cell, opts Options) (string, bool) {
+	switch c.Type {
+	case osheet.ValueNumber:
+		return strconv.FormatFloat(c.NumberValue, 'f', -1, 64), true
+	case osheet.ValueBool:
+		if c.BoolValue {
+			return opts.True, false
+		}
+		return opts.False, false
+	case osheet.ValueDateTime:
+		if opts.Dates == DatesSerial {
+			return strconv.FormatFloat(c.DateEpoch, 'f', -1, 64), true
+		}
+		return osheet.FormatSerialISO(c.DateEpoch), false
+	case osheet.ValueString:
+		return c.StringValue, false
+	}
+	if c.Formula != "" {
+		return "", false
+	}
+	return c.StringValue, false
+}
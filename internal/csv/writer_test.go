@@ -0,0 +1,107 @@
+package csv
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/romanitalian/osheet2xlsx/v3/internal/osheet"
+)
+
+func sampleSheet() *osheet.Sheet {
+	return &osheet.Sheet{
+		Name:   "Data",
+		Width:  4,
+		Height: 2,
+		Cells: [][]osheet.Cell{
+			{
+				{Type: osheet.ValueString, StringValue: "name, first"},
+				{Type: osheet.ValueString, StringValue: `say "hi"`},
+				{Type: osheet.ValueNumber, NumberValue: 1.5},
+				{Type: osheet.ValueBool, BoolValue: true},
+			},
+			{
+				{Type: osheet.ValueDateTime, DateEpoch: 45293},   // 2024-01-02
+				{Type: osheet.ValueDateTime, DateEpoch: 45293.5}, // 2024-01-02 12:00
+				{Type: osheet.ValueEmpty, Formula: "SUM(C1:C1)"},
+				{Type: osheet.ValueNumber, NumberValue: 3, Formula: "C1*2"},
+			},
+		},
+	}
+}
+
+func TestWriteSheet_Dialects(t *testing.T) {
+	cases := []struct {
+		name string
+		opts Options
+		want string
+	}{
+		{
+			// Formula cells are written as their cached value or left empty
+			name: "defaults",
+			want: "\"name, first\",\"say \"\"hi\"\"\",1.5,TRUE\n" +
+				"2024-01-02,2024-01-02T12:00:00,,3\n",
+		},
+		{
+			name: "tab crlf",
+			opts: Options{Delimiter: '\t', CRLF: true},
+			want: "name, first\t\"say \"\"hi\"\"\"\t1.5\tTRUE\r\n" +
+				"2024-01-02\t2024-01-02T12:00:00\t\t3\r\n",
+		},
+		{
+			name: "semicolon all crlf serial",
+			opts: Options{Delimiter: ';', Quote: QuoteAll, CRLF: true, Dates: DatesSerial, True: "1", False: "0"},
+			want: "\"name, first\";\"say \"\"hi\"\"\";\"1.5\";\"1\"\r\n" +
+				"\"45293\";\"45293.5\";\"\";\"3\"\r\n",
+		},
+		{
+			name: "tab nonnumeric bom",
+			opts: Options{Delimiter: '\t', Quote: QuoteNonNumeric, BOM: true},
+			want: "\uFEFF\"name, first\"\t\"say \"\"hi\"\"\"\t1.5\t\"TRUE\"\n" +
+				"\"2024-01-02\"\t\"2024-01-02T12:00:00\"\t\"\"\t3\n",
+		},
+	}
+	for _, tc := range cases {
+		var b strings.Builder
+		if err := WriteSheet(&b, sampleSheet(), tc.opts); err != nil {
+			t.Fatalf("%s: %v", tc.name, err)
+		}
+		if b.String() != tc.want {
+			t.Errorf("%s:\n got %q\nwant %q", tc.name, b.String(), tc.want)
+		}
+	}
+}
+
+func TestWriteBook_FilePerSheet(t *testing.T) {
+	dir := t.TempDir()
+	book := &osheet.Book{Sheets: []osheet.Sheet{*sampleSheet(), *sampleSheet(), {Name: "a/b", Width: 1, Cells: [][]osheet.Cell{{{Type: osheet.ValueString, StringValue: "x"}}}}}}
+	paths, err := WriteBook(book, filepath.Join(dir, "out.csv"), Options{})
+	if err != nil {
+		t.Fatalf("WriteBook: %v", err)
+	}
+	want := []string{"out_Data.csv", "out_Data_2.csv", "out_a_b.csv"}
+	if len(paths) != len(want) {
+		t.Fatalf("paths = %v", paths)
+	}
+	for i, p := range paths {
+		if filepath.Base(p) != want[i] {
+			t.Errorf("path[%d] = %s, want %s", i, filepath.Base(p), want[i])
+		}
+		if _, err := os.Stat(p); err != nil {
+			t.Errorf("missing %s: %v", p, err)
+		}
+	}
+	data, err := os.ReadFile(paths[2])
+	if err != nil || string(data) != "x\n" {
+		t.Fatalf("sheet a/b = %q, %v", data, err)
+	}
+}
+
+func TestOptions_Validate(t *testing.T) {
+	for _, o := range []Options{{Quote: "some"}, {Dates: "unix"}, {Delimiter: '"'}} {
+		if o.Validate() == nil {
+			t.Errorf("expected error for %+v", o)
+		}
+	}
+}
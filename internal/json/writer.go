@@ -0,0 +1,297 @@
+package json
+
+import (
+	"bufio"
+	stdjson "encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+
+	"github.com/xuri/excelize/v2"
+
+	appfs "github.com/romanitalian/osheet2xlsx/v3/internal/fs"
+	"github.com/romanitalian/osheet2xlsx/v3/internal/osheet"
+)
+
+// SchemaVersion is bumped on any incompatible change to the JSON or NDJSON
+// layout; additive fields keep the version.
+const SchemaVersion = 1
+
+// Book is the --format json document (schema version 1).
+type Book struct {
+	SchemaVersion int     `json:"schemaVersion"`
+	Title         string  `json:"title,omitempty"`
+	Sheets        []Sheet `json:"sheets"`
+}
+
+// Sheet holds one sheet; Width and Height are the used range size.
+type Sheet struct {
+	Name   string  `json:"name"`
+	Width  int     `json:"width"`
+	Height int     `json:"height"`
+	Merges []Merge `json:"merges,omitempty"`
+	Cols   []Col   `json:"cols,omitempty"`
+	Rows   []Row   `json:"rows,omitempty"`
+	Cells  []Cell  `json:"cells"`
+}
+
+// Merge is an inclusive range such as "A1:B2".
+type Merge struct {
+	Ref string `json:"ref"`
+}
+
+// Col is an explicit column width or a hidden column; Index is 1-based.
+type Col struct {
+	Index  int     `json:"index"`
+	Width  float64 `json:"width"`
+	Hidden bool    `json:"hidden,omitempty"`
+}
+
+// Row is an explicit row height or a hidden row; Index is 1-based.
+type Row struct {
+	Index  int     `json:"index"`
+	Height float64 `json:"height"`
+	Hidden bool    `json:"hidden,omitempty"`
+}
+
+// Cell is a non-empty cell. Type is string, number, bool, date or formula
+// (formula without a value, Value is null). Dates carry Value as ISO 8601 and Serial as the
+// Excel serial number.
+type Cell struct {
+	Ref     string      `json:"ref"`
+	Row     int         `json:"row"`
+	Col     int         `json:"col"`
+	Type    string      `json:"type"`
+	Value   interface{} `json:"value"`
+	Serial  *float64    `json:"serial,omitempty"`
+	Formula string      `json:"formula,omitempty"`
+	NumFmt  string      `json:"numFmt,omitempty"`
+}
+
+// Options controls NDJSON output.
+type Options struct {
+	// Header treats each sheet's first row as field names and emits rows as
+	// objects under "record" instead of arrays under "values".
+	Header bool
+}
+
+// FromBook converts the parsed model into the versioned JSON document.
+func FromBook(book *osheet.Book) Book {
+	out := Book{SchemaVersion: SchemaVersion, Title: book.Title, Sheets: make([]Sheet, 0, len(book.Sheets))}
+	for i := range book.Sheets {
+		out.Sheets = append(out.Sheets, fromSheet(&book.Sheets[i]))
+	}
+	return out
+}
+
+func fromSheet(s *osheet.Sheet) Sheet {
+	out := Sheet{Name: s.Name, Width: s.Width, Height: s.Height, Cells: []Cell{}}
+	for _, m := range s.Merges {
+		out.Merges = append(out.Merges, Merge{Ref: cellRef(m.StartRow, m.StartCol) + ":" + cellRef(m.EndRow, m.EndCol)})
+	}
+	for _, c := range s.Cols {
+		out.Cols = append(out.Cols, Col{Index: c.Index, Width: c.Width, Hidden: c.Hidden})
+	}
+	for _, r := range s.Rows {
+		out.Rows = append(out.Rows, Row{Index: r.Index, Height: r.Height, Hidden: r.Hidden})
+	}
+	for r, row := range s.Cells {
+		for c, cell := range row {
+			if cell.Type == osheet.ValueEmpty && cell.Formula == "" {
+				continue
+			}
+			jc := Cell{
+				Ref:     cellRef(r+1, c+1),
+				Row:     r + 1,
+				Col:     c + 1,
+				Type:    typeName(cell.Type),
+				Value:   cellValue(cell),
+				Formula: cell.Formula,
+				NumFmt:  cell.NumFmt,
+			}
+			if cell.Type == osheet.ValueDateTime {
+				serial := cell.DateEpoch
+				jc.Serial = &serial
+			}
+			out.Cells = append(out.Cells, jc)
+		}
+	}
+	return out
+}
+
+// typeName names a value type; only formula cells reach the default case.
+func typeName(t osheet.ValueType) string {
+	switch t {
+	case osheet.ValueString:
+		return "string"
+	case osheet.ValueNumber:
+		return "number"
+	case osheet.ValueBool:
+		return "bool"
+	case osheet.ValueDateTime:
+		return "date"
+	default:
+		return "formula"
+	}
+}
+
+// cellValue returns the typed JSON value; dates become ISO 8601 strings.
+func cellValue(c osheet.Cell) interface{} {
+	switch c.Type {
+	case osheet.ValueString:
+		return c.StringValue
+	case osheet.ValueNumber:
+		return c.NumberValue
+	case osheet.ValueBool:
+		return c.BoolValue
+	case osheet.ValueDateTime:
+		return osheet.FormatSerialISO(c.DateEpoch)
+	default:
+		return nil
+	}
+}
+
+// FormulaValue is an NDJSON formula cell: the formula and its cached value,
+// null when there is none.
+type FormulaValue struct {
+	Formula string      `json:"formula"`
+	Value   interface{} `json:"value"`
+}
+
+// rowValue returns the NDJSON value of a cell: cellValue, or a
+// FormulaValue for formula cells.
+func rowValue(c osheet.Cell) interface{} {
+	if c.Formula != "" {
+		return FormulaValue{Formula: c.Formula, Value: cellValue(c)}
+	}
+	return cellValue(c)
+}
+
+// Encode writes book as one indented JSON document.
+func Encode(w io.Writer, book *osheet.Book) error {
+	enc := stdjson.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(FromBook(book))
+}
+
+// rowRecord is one NDJSON line. Exactly one of Values and Record is set.
+type rowRecord struct {
+	SchemaVersion int                    `json:"schemaVersion"`
+	Sheet         string                 `json:"sheet"`
+	Row           int                    `json:"row"`
+	Values        []interface{}          `json:"values,omitempty"`
+	Record        map[string]interface{} `json:"record,omitempty"`
+}
+
+// EncodeNDJSON writes one JSON object per row of every sheet. Empty rows are
+// skipped; in header mode the first non-empty row supplies the field names.
+func EncodeNDJSON(w io.Writer, book *osheet.Book, opts Options) error {
+	enc := stdjson.NewEncoder(w)
+	for i := range book.Sheets {
+		s := &book.Sheets[i]
+		var header []string
+		for r, row := range s.Cells {
+			if rowEmpty(row) {
+				continue
+			}
+			if opts.Header && header == nil {
+				header = headerNames(row, s.Width)
+				continue
+			}
+			rec := rowRecord{SchemaVersion: SchemaVersion, Sheet: s.Name, Row: r + 1}
+			if opts.Header {
+				rec.Record = make(map[string]interface{}, len(header))
+				for c, name := range header {
+					var v interface{}
+					if c < len(row) {
+						v = rowValue(row[c])
+					}
+					rec.Record[name] = v
+				}
+			} else {
+				rec.Values = make([]interface{}, s.Width)
+				for c := 0; c < s.Width && c < len(row); c++ {
+					rec.Values[c] = rowValue(row[c])
+				}
+			}
+			if err := enc.Encode(rec); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// headerNames derives unique field names from a header row; blank headers
+// fall back to the column letter and repeats get a numeric suffix.
+func headerNames(row []osheet.Cell, width int) []string {
+	names := make([]string, width)
+	seen := make(map[string]int, width)
+	for c := 0; c < width; c++ {
+		name := ""
+		if c < len(row) {
+			switch v := cellValue(row[c]).(type) {
+			case string:
+				name = v
+			case float64:
+				name = strconv.FormatFloat(v, 'f', -1, 64)
+			case bool:
+				name = strconv.FormatBool(v)
+			}
+		}
+		if name == "" {
+			name, _ = excelize.ColumnNumberToName(c + 1)
+		}
+		seen[name]++
+		if n := seen[name]; n > 1 {
+			name = name + "_" + strconv.Itoa(n)
+		}
+		names[c] = name
+	}
+	return names
+}
+
+func rowEmpty(row []osheet.Cell) bool {
+	for _, c := range row {
+		if c.Type != osheet.ValueEmpty || c.Formula != "" {
+			return false
+		}
+	}
+	return true
+}
+
+// WriteBook writes book to outPath as JSON, or as NDJSON when ndjson is set.
+func WriteBook(book *osheet.Book, outPath string, ndjson bool, opts Options) error {
+	if book == nil {
+		return errors.New("nil book")
+	}
+	if err := appfs.EnsureParentDir(outPath); err != nil {
+		return err
+	}
+	f, err := os.Create(outPath)
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriter(f)
+	if ndjson {
+		err = EncodeNDJSON(w, book, opts)
+	} else {
+		err = Encode(w, book)
+	}
+	if err == nil {
+		err = w.Flush()
+	}
+	if err != nil {
+		_ = f.Close()
+		return fmt.Errorf("write %s: %w", outPath, err)
+	}
+	return f.Close()
+}
+
+// cellRef returns the A1 reference of a 1-based row and column.
+func cellRef(row, col int) string {
+	ref, _ := excelize.CoordinatesToCellName(col, row)
+	return ref
+}
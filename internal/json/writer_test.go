@@ -0,0 +1,101 @@
+package json
+
+import (
+	"bytes"
+	stdjson "encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/romanitalian/osheet2xlsx/v3/internal/osheet"
+)
+
+func sampleBook() *osheet.Book {
+	return &osheet.Book{
+		Title: "Report",
+		Sheets: []osheet.Sheet{{
+			Name:   "S",
+			Width:  3,
+			Height: 3,
+			Merges: []osheet.Merge{{StartRow: 1, StartCol: 1, EndRow: 1, EndCol: 2}},
+			Cols:   []osheet.ColSpec{{Index: 1, Width: 20}, {Index: 3, Hidden: true}},
+			Rows:   []osheet.RowSpec{{Index: 3, Height: 18, Hidden: true}},
+			Cells: [][]osheet.Cell{
+				{{Type: osheet.ValueString, StringValue: "name"}, {}, {Type: osheet.ValueString, StringValue: "name"}},
+				// Rich text is written as its plain text
+				{{Type: osheet.ValueString, StringValue: "a", RichText: []osheet.RichTextRun{{Text: "a", Font: &osheet.Font{Bold: true}}}}, {Type: osheet.ValueNumber, NumberValue: 2, NumFmt: "0%"}, {Type: osheet.ValueBool, BoolValue: true}},
+				{{Type: osheet.ValueDateTime, DateEpoch: 45293}, {Formula: "SUM(B2:B2)"}, {Formula: "B2*2", Type: osheet.ValueNumber, NumberValue: 4}},
+			},
+		}},
+	}
+}
+
+func TestEncode_Schema(t *testing.T) {
+	var buf bytes.Buffer
+	if err := Encode(&buf, sampleBook()); err != nil {
+		t.Fatalf("Encode: %v", err)
+	}
+	var got Book
+	if err := stdjson.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if got.SchemaVersion != SchemaVersion || got.Title != "Report" || len(got.Sheets) != 1 {
+		t.Fatalf("unexpected book: %+v", got)
+	}
+	s := got.Sheets[0]
+	if len(s.Merges) != 1 || s.Merges[0].Ref != "A1:B1" {
+		t.Errorf("merges = %+v", s.Merges)
+	}
+	if len(s.Cols) != 2 || s.Cols[0] != (Col{Index: 1, Width: 20}) || s.Cols[1] != (Col{Index: 3, Hidden: true}) {
+		t.Errorf("cols = %+v", s.Cols)
+	}
+	if len(s.Rows) != 1 || s.Rows[0] != (Row{Index: 3, Height: 18, Hidden: true}) {
+		t.Errorf("rows = %+v", s.Rows)
+	}
+	if len(s.Cells) != 8 {
+		t.Fatalf("cells = %d, want 8 (empty cells omitted)", len(s.Cells))
+	}
+	byRef := map[string]Cell{}
+	for _, c := range s.Cells {
+		byRef[c.Ref] = c
+	}
+	if c := byRef["B2"]; c.Type != "number" || c.Value != 2.0 || c.NumFmt != "0%" {
+		t.Errorf("B2 = %+v", c)
+	}
+	if c := byRef["A3"]; c.Type != "date" || c.Value != "2024-01-02" || c.Serial == nil || *c.Serial != 45293 {
+		t.Errorf("A3 = %+v", c)
+	}
+	if c := byRef["B3"]; c.Type != "formula" || c.Value != nil || c.Formula != "SUM(B2:B2)" {
+		t.Errorf("B3 = %+v", c)
+	}
+}
+
+func TestEncodeNDJSON_Modes(t *testing.T) {
+	var buf bytes.Buffer
+	if err := EncodeNDJSON(&buf, sampleBook(), Options{}); err != nil {
+		t.Fatalf("EncodeNDJSON: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("lines = %d, want 3", len(lines))
+	}
+	if want := `{"schemaVersion":1,"sheet":"S","row":2,"values":["a",2,true]}`; lines[1] != want {
+		t.Errorf("row 2 = %s, want %s", lines[1], want)
+	}
+	// Formula cells carry the formula and the cached value, if any
+	if want := `{"schemaVersion":1,"sheet":"S","row":3,"values":["2024-01-02",{"formula":"SUM(B2:B2)","value":null},{"formula":"B2*2","value":4}]}`; lines[2] != want {
+		t.Errorf("row 3 = %s, want %s", lines[2], want)
+	}
+
+	buf.Reset()
+	if err := EncodeNDJSON(&buf, sampleBook(), Options{Header: true}); err != nil {
+		t.Fatalf("EncodeNDJSON header: %v", err)
+	}
+	lines = strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("header lines = %d, want 2", len(lines))
+	}
+	// Blank header falls back to the column letter, repeats get a suffix
+	if want := `{"schemaVersion":1,"sheet":"S","row":2,"record":{"B":2,"name":"a","name_2":true}}`; lines[0] != want {
+		t.Errorf("record = %s, want %s", lines[0], want)
+	}
+}
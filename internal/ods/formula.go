@@ -0,0 +1,49 @@
+package ods
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/xuri/excelize/v2"
+
+	"github.com/romanitalian/osheet2xlsx/v3/internal/osheet"
+)
+
+// toOpenFormula translates a source formula into OpenFormula as stored in
+// table:formula ("of:=SUM([.A1:.B2])"). The source dialect (semicolons,
+// decimal commas, localized names, "Sheet.A1" references) is normalised by
+// osheet.TranslateFormula first; sheets maps source sheet names to the names
+// written to the file. Unknown functions are kept as written and reported in
+// the returned warnings.
+func toOpenFormula(formula string, sheets map[string]string) (string, []string) {
+	excel, warnings := osheet.TranslateFormula(formula, osheet.FormulaOptions{Sheets: sheets})
+	return osheet.OpenFormula(excel), warnings
+}
+
+// formulaTranslator rewrites cell formulas for the tables of one workbook,
+// reporting untranslatable functions through warn.
+type formulaTranslator struct {
+	sheets map[string]string
+	warn   func(string)
+}
+
+// formula returns the OpenFormula of the cell at row and col (1-based) of
+// sheet, or "" when it has none. As in the xlsx writer, text starting with
+// "=" counts as a formula.
+func (t *formulaTranslator) formula(sheet string, row, col int, cell osheet.Cell) string {
+	src := cell.Formula
+	if src == "" && cell.Type == osheet.ValueString && strings.HasPrefix(cell.StringValue, "=") {
+		src = cell.StringValue
+	}
+	if src == "" {
+		return ""
+	}
+	out, warnings := toOpenFormula(src, t.sheets)
+	if t.warn != nil && len(warnings) > 0 {
+		axis, _ := excelize.CoordinatesToCellName(col, row)
+		for _, w := range warnings {
+			t.warn(fmt.Sprintf("%s!%s: %s", sheet, axis, w))
+		}
+	}
+	return out
+}
@@ -0,0 +1,31 @@
+package ods
+
+import "testing"
+
+func TestToOpenFormula(t *testing.T) {
+	sheets := map[string]string{"Q1/Q2": "Q1_Q2", "Data": "Data", "My Sheet": "My Sheet"}
+	cases := map[string]string{
+		"SUM(A1:B1)":                "of:=SUM([.A1:.B1])",
+		"=A1*$B$2":                  "of:=[.A1]*[.$B$2]",
+		"IF(A1>0,\"a,b\",C3)":       `of:=IF([.A1]>0;"a,b";[.C3])`,
+		"Data!A1+'My Sheet'!B2:C3":  "of:=[Data.A1]+['My Sheet'.B2:.C3]",
+		"'Q1/Q2'!A1":                "of:=[Q1_Q2.A1]",
+		"SUM(A:A)":                  "of:=SUM([.A:.A])",
+		"LOG10(100)+ATAN2(1,2)":     "of:=LOG10(100)+ATAN2(1;2)",
+		"SUM({1,2;3,4})":            "of:=SUM({1;2|3;4})",
+		"\"say \"\"A1\"\"\"&A1":     `of:="say ""A1"""&[.A1]`,
+		"VLOOKUP(x,Table1,2,FALSE)": "of:=VLOOKUP(x;Table1;2;FALSE)",
+		"ROUND(1.5E3,0)":            "of:=ROUND(1.5E3;0)",
+		"'It''s'!A1":                "of:=['It''s'.A1]",
+		// Synology dialect is normalised first
+		"=ROUND(1,5;0)":       "of:=ROUND(1.5;0)",
+		"SUMME(A1;2)":         "of:=SUM([.A1];2)",
+		"Data.A1+[Data.B2]":   "of:=[Data.A1]+[Data.B2]",
+		"'My Sheet'.A1:.B2*2": "of:=['My Sheet'.A1:.B2]*2",
+	}
+	for in, want := range cases {
+		if got, _ := toOpenFormula(in, sheets); got != want {
+			t.Errorf("toOpenFormula(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
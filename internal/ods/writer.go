@@ -0,0 +1,468 @@
+package ods
+
+import (
+	"archive/zip"
+	"bufio"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	appfs "github.com/romanitalian/osheet2xlsx/v3/internal/fs"
+	"github.com/romanitalian/osheet2xlsx/v3/internal/osheet"
+)
+
+// xmlWriter remembers the first write error so XML can be emitted without
+// checking every call; callers check err once at the end.
+type xmlWriter struct {
+	w   io.Writer
+	err error
+}
+
+func (x *xmlWriter) str(s string) {
+	if x.err == nil {
+		_, x.err = io.WriteString(x.w, s)
+	}
+}
+
+func (x *xmlWriter) printf(format string, args ...interface{}) {
+	x.str(fmt.Sprintf(format, args...))
+}
+
+// MimeType is the media type stored uncompressed as the first archive entry.
+const MimeType = "application/vnd.oasis.opendocument.spreadsheet"
+
+const (
+	nsOffice = "urn:oasis:names:tc:opendocument:xmlns:office:1.0"
+	nsStyle  = "urn:oasis:names:tc:opendocument:xmlns:style:1.0"
+	nsText   = "urn:oasis:names:tc:opendocument:xmlns:text:1.0"
+	nsTable  = "urn:oasis:names:tc:opendocument:xmlns:table:1.0"
+	nsFO     = "urn:oasis:names:tc:opendocument:xmlns:xsl-fo-compatible:1.0"
+	nsNumber = "urn:oasis:names:tc:opendocument:xmlns:datastyle:1.0"
+	nsOF     = "urn:oasis:names:tc:opendocument:xmlns:of:1.2"
+	nsMeta   = "urn:oasis:names:tc:opendocument:xmlns:meta:1.0"
+	nsDC     = "http://purl.org/dc/elements/1.1/"
+)
+
+// Cell styles for dates; the number styles they reference are in automaticStyles.
+const (
+	dateCellStyle     = "ceDate"
+	dateTimeCellStyle = "ceDateTime"
+)
+
+var invalidTableChars = regexp.MustCompile(`[\[\]*?:/\\]`)
+
+// Options controls how a book is written.
+type Options struct {
+	// Warn receives conversion warnings such as formulas using functions
+	// Excel does not know; nil discards them.
+	Warn func(string)
+}
+
+// WriteBook writes book to outPath as an OpenDocument spreadsheet with
+// default options.
+func WriteBook(book *osheet.Book, outPath string) error {
+	return WriteBookWithOptions(book, outPath, Options{})
+}
+
+// WriteBookWithOptions writes book to outPath as an OpenDocument spreadsheet.
+func WriteBookWithOptions(book *osheet.Book, outPath string, opts Options) error {
+	if book == nil {
+		return errors.New("nil book")
+	}
+	if err := appfs.EnsureParentDir(outPath); err != nil {
+		return err
+	}
+	f, err := os.Create(outPath)
+	if err != nil {
+		return err
+	}
+	if err := Write(f, book, opts); err != nil {
+		_ = f.Close()
+		return fmt.Errorf("write %s: %w", outPath, err)
+	}
+	return f.Close()
+}
+
+// Write encodes book as an ODS package.
+func Write(w io.Writer, book *osheet.Book, opts Options) error {
+	zw := zip.NewWriter(w)
+	now := time.Now()
+	// The mimetype entry must come first and be stored uncompressed
+	mw, err := zw.CreateHeader(&zip.FileHeader{Name: "mimetype", Method: zip.Store, Modified: now})
+	if err != nil {
+		return err
+	}
+	if _, err := io.WriteString(mw, MimeType); err != nil {
+		return err
+	}
+	entries := []struct {
+		name  string
+		write func(*xmlWriter)
+	}{
+		{"META-INF/manifest.xml", writeManifest},
+		{"meta.xml", func(x *xmlWriter) { writeMeta(x, book) }},
+		{"styles.xml", writeStyles},
+		{"content.xml", func(x *xmlWriter) { writeContent(x, book, opts) }},
+	}
+	for _, e := range entries {
+		ew, err := zw.CreateHeader(&zip.FileHeader{Name: e.name, Method: zip.Deflate, Modified: now})
+		if err != nil {
+			return err
+		}
+		bw := bufio.NewWriter(ew)
+		x := &xmlWriter{w: bw}
+		e.write(x)
+		if x.err != nil {
+			return x.err
+		}
+		if err := bw.Flush(); err != nil {
+			return err
+		}
+	}
+	return zw.Close()
+}
+
+func writeManifest(w *xmlWriter) {
+	w.str(xml.Header +
+		`<manifest:manifest xmlns:manifest="urn:oasis:names:tc:opendocument:xmlns:manifest:1.0" manifest:version="1.2">` +
+		`<manifest:file-entry manifest:full-path="/" manifest:version="1.2" manifest:media-type="` + MimeType + `"/>` +
+		`<manifest:file-entry manifest:full-path="meta.xml" manifest:media-type="text/xml"/>` +
+		`<manifest:file-entry manifest:full-path="styles.xml" manifest:media-type="text/xml"/>` +
+		`<manifest:file-entry manifest:full-path="content.xml" manifest:media-type="text/xml"/>` +
+		`</manifest:manifest>`)
+}
+
+// writeMeta writes the title and document properties of book, with custom
+// properties as user-defined fields.
+func writeMeta(w *xmlWriter, book *osheet.Book) {
+	w.str(xml.Header +
+		`<office:document-meta xmlns:office="` + nsOffice + `" xmlns:meta="` + nsMeta + `" xmlns:dc="` + nsDC + `" office:version="1.2">` +
+		`<office:meta><meta:generator>osheet2xlsx</meta:generator>`)
+	p := book.Properties
+	element := func(name, text string) {
+		if text != "" {
+			w.str(`<` + name + `>` + textEscaper.Replace(text) + `</` + name + `>`)
+		}
+	}
+	element("dc:title", book.Title)
+	element("dc:description", p.Description)
+	element("meta:keyword", p.Keywords)
+	element("meta:initial-creator", p.Author)
+	if !p.Created.IsZero() {
+		element("meta:creation-date", p.Created.UTC().Format(time.RFC3339))
+	}
+	if !p.Modified.IsZero() {
+		element("dc:date", p.Modified.UTC().Format(time.RFC3339))
+	}
+	for _, c := range p.Custom {
+		var typ, text string
+		switch v := c.Value.(type) {
+		case string:
+			typ, text = "string", v
+		case float64:
+			typ, text = "float", strconv.FormatFloat(v, 'f', -1, 64)
+		case bool:
+			typ, text = "boolean", strconv.FormatBool(v)
+		case time.Time:
+			typ, text = "date", v.UTC().Format(time.RFC3339)
+		default:
+			continue
+		}
+		w.str(`<meta:user-defined meta:name="` + escapeAttr(c.Name) + `" meta:value-type="` + typ + `">` + textEscaper.Replace(text) + `</meta:user-defined>`)
+	}
+	w.str(`</office:meta></office:document-meta>`)
+}
+
+func writeStyles(w *xmlWriter) {
+	w.str(xml.Header +
+		`<office:document-styles xmlns:office="` + nsOffice + `" xmlns:style="` + nsStyle + `" office:version="1.2">` +
+		`<office:styles><style:default-style style:family="table-cell"/></office:styles>` +
+		`</office:document-styles>`)
+}
+
+// sheetNames sanitizes and de-duplicates table names, keyed by source name.
+func sheetNames(book *osheet.Book) ([]string, map[string]string) {
+	names := make([]string, len(book.Sheets))
+	bySource := make(map[string]string, len(book.Sheets))
+	used := make(map[string]bool, len(book.Sheets))
+	for i, s := range book.Sheets {
+		name := strings.Trim(invalidTableChars.ReplaceAllString(s.Name, "_"), "'")
+		if name == "" {
+			name = fmt.Sprintf("Sheet%d", i+1)
+		}
+		base := name
+		for n := 2; used[strings.ToLower(name)]; n++ {
+			name = fmt.Sprintf("%s_%d", base, n)
+		}
+		used[strings.ToLower(name)] = true
+		names[i] = name
+		if _, ok := bySource[s.Name]; !ok {
+			bySource[s.Name] = name
+		}
+	}
+	return names, bySource
+}
+
+func writeContent(w *xmlWriter, book *osheet.Book, opts Options) {
+	names, bySource := sheetNames(book)
+	formulas := &formulaTranslator{sheets: bySource, warn: opts.Warn}
+	colStyles, rowStyles := lengthStyles(book)
+
+	w.str(xml.Header)
+	w.str(`<office:document-content xmlns:office="` + nsOffice + `" xmlns:style="` + nsStyle +
+		`" xmlns:text="` + nsText + `" xmlns:table="` + nsTable + `" xmlns:fo="` + nsFO +
+		`" xmlns:number="` + nsNumber + `" xmlns:of="` + nsOF + `" office:version="1.2">`)
+	writeAutomaticStyles(w, colStyles, rowStyles)
+	w.str(`<office:body><office:spreadsheet>`)
+	for i := range book.Sheets {
+		writeTable(w, names[i], &book.Sheets[i], colStyles, rowStyles, formulas)
+	}
+	w.str(`</office:spreadsheet></office:body></office:document-content>`)
+}
+
+// lengthStyles assigns one automatic style per distinct column width and row height.
+func lengthStyles(book *osheet.Book) (map[float64]string, map[float64]string) {
+	cols := map[float64]string{}
+	rows := map[float64]string{}
+	for _, s := range book.Sheets {
+		for _, c := range s.Cols {
+			if _, ok := cols[c.Width]; !ok && c.Width > 0 {
+				cols[c.Width] = "co" + strconv.Itoa(len(cols)+1)
+			}
+		}
+		for _, r := range s.Rows {
+			if _, ok := rows[r.Height]; !ok && r.Height > 0 {
+				rows[r.Height] = "ro" + strconv.Itoa(len(rows)+1)
+			}
+		}
+	}
+	return cols, rows
+}
+
+func writeAutomaticStyles(w *xmlWriter, colStyles, rowStyles map[float64]string) {
+	w.str(`<office:automatic-styles>`)
+	for _, width := range sortedKeys(colStyles) {
+		// Excel widths are in characters of the default font: 7px each plus 5px padding at 96dpi
+		inches := (width*7 + 5) / 96
+		w.printf(`<style:style style:name="%s" style:family="table-column"><style:table-column-properties style:column-width="%sin"/></style:style>`,
+			colStyles[width], strconv.FormatFloat(inches, 'f', 4, 64))
+	}
+	for _, height := range sortedKeys(rowStyles) {
+		w.printf(`<style:style style:name="%s" style:family="table-row"><style:table-row-properties style:row-height="%spt" style:use-optimal-row-height="false"/></style:style>`,
+			rowStyles[height], strconv.FormatFloat(height, 'f', -1, 64))
+	}
+	w.str(`<number:date-style style:name="N1"><number:year number:style="long"/><number:text>-</number:text>` +
+		`<number:month number:style="long"/><number:text>-</number:text><number:day number:style="long"/></number:date-style>`)
+	w.str(`<number:date-style style:name="N2"><number:year number:style="long"/><number:text>-</number:text>` +
+		`<number:month number:style="long"/><number:text>-</number:text><number:day number:style="long"/><number:text> </number:text>` +
+		`<number:hours number:style="long"/><number:text>:</number:text><number:minutes number:style="long"/>` +
+		`<number:text>:</number:text><number:seconds number:style="long"/></number:date-style>`)
+	w.str(`<style:style style:name="` + dateCellStyle + `" style:family="table-cell" style:data-style-name="N1"/>`)
+	w.str(`<style:style style:name="` + dateTimeCellStyle + `" style:family="table-cell" style:data-style-name="N2"/>`)
+	w.str(`</office:automatic-styles>`)
+}
+
+func sortedKeys(m map[float64]string) []float64 {
+	keys := make([]float64, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Float64s(keys)
+	return keys
+}
+
+// span is the extent of a merge anchored at its top-left cell.
+type span struct{ rows, cols int }
+
+func writeTable(w *xmlWriter, name string, s *osheet.Sheet, colStyles, rowStyles map[float64]string, formulas *formulaTranslator) {
+	anchors := map[[2]int]span{}
+	covered := map[[2]int]bool{}
+	for _, m := range s.Merges {
+		if m.StartRow <= 0 || m.StartCol <= 0 || m.EndRow < m.StartRow || m.EndCol < m.StartCol {
+			continue
+		}
+		if m.EndRow == m.StartRow && m.EndCol == m.StartCol {
+			continue
+		}
+		anchors[[2]int{m.StartRow, m.StartCol}] = span{rows: m.EndRow - m.StartRow + 1, cols: m.EndCol - m.StartCol + 1}
+		for r := m.StartRow; r <= m.EndRow; r++ {
+			for c := m.StartCol; c <= m.EndCol; c++ {
+				if r != m.StartRow || c != m.StartCol {
+					covered[[2]int{r, c}] = true
+				}
+			}
+		}
+	}
+
+	width, height := s.Width, len(s.Cells)
+	colWidths := map[int]float64{}
+	for _, c := range s.Cols {
+		if c.Index > 0 && c.Width > 0 {
+			colWidths[c.Index] = c.Width
+			if c.Index > width {
+				width = c.Index
+			}
+		}
+	}
+	rowHeights := map[int]float64{}
+	for _, r := range s.Rows {
+		if r.Index > 0 && r.Height > 0 {
+			rowHeights[r.Index] = r.Height
+			if r.Index > height {
+				height = r.Index
+			}
+		}
+	}
+	for k, sp := range anchors {
+		if end := k[0] + sp.rows - 1; end > height {
+			height = end
+		}
+		if end := k[1] + sp.cols - 1; end > width {
+			width = end
+		}
+	}
+	if width < 1 {
+		width = 1
+	}
+	if height < 1 {
+		// A table needs at least one row
+		height = 1
+	}
+
+	w.str(`<table:table table:name="` + escapeAttr(name) + `">`)
+	for c := 1; c <= width; c++ {
+		if st, ok := colStyles[colWidths[c]]; ok {
+			w.str(`<table:table-column table:style-name="` + st + `"/>`)
+		} else {
+			w.str(`<table:table-column/>`)
+		}
+	}
+	for r := 1; r <= height; r++ {
+		if st, ok := rowStyles[rowHeights[r]]; ok {
+			w.str(`<table:table-row table:style-name="` + st + `">`)
+		} else {
+			w.str(`<table:table-row>`)
+		}
+		var row []osheet.Cell
+		if r-1 < len(s.Cells) {
+			row = s.Cells[r-1]
+		}
+		for c := 1; c <= width; c++ {
+			key := [2]int{r, c}
+			if covered[key] {
+				w.str(`<table:covered-table-cell/>`)
+				continue
+			}
+			var cell osheet.Cell
+			if c-1 < len(row) {
+				cell = row[c-1]
+			}
+			writeCell(w, cell, anchors[key], formulas.formula(name, r, c, cell))
+		}
+		w.str(`</table:table-row>`)
+	}
+	w.str(`</table:table>`)
+}
+
+// writeCell writes one table cell with its formula, already in OpenFormula,
+// if any.
+func writeCell(w *xmlWriter, cell osheet.Cell, sp span, formula string) {
+	var attrs strings.Builder
+	if sp.rows > 0 {
+		fmt.Fprintf(&attrs, ` table:number-rows-spanned="%d" table:number-columns-spanned="%d"`, sp.rows, sp.cols)
+	}
+	if formula != "" {
+		attrs.WriteString(` table:formula="` + escapeAttr(formula) + `"`)
+		if cell.Formula == "" {
+			// The text was the formula itself, not its result
+			cell = osheet.Cell{}
+		}
+	}
+	text := ""
+	switch cell.Type {
+	case osheet.ValueString:
+		attrs.WriteString(` office:value-type="string"`)
+		text = cell.StringValue
+	case osheet.ValueNumber:
+		v := strconv.FormatFloat(cell.NumberValue, 'f', -1, 64)
+		attrs.WriteString(` office:value-type="float" office:value="` + v + `"`)
+		text = v
+	case osheet.ValueBool:
+		v := strconv.FormatBool(cell.BoolValue)
+		attrs.WriteString(` office:value-type="boolean" office:boolean-value="` + v + `"`)
+		text = strings.ToUpper(v)
+	case osheet.ValueDateTime:
+		iso := osheet.FormatSerialISO(cell.DateEpoch)
+		style := dateCellStyle
+		if strings.Contains(iso, "T") {
+			style = dateTimeCellStyle
+			text = strings.Replace(iso, "T", " ", 1)
+		} else {
+			text = iso
+		}
+		attrs.WriteString(` table:style-name="` + style + `" office:value-type="date" office:date-value="` + iso + `"`)
+	}
+	if attrs.Len() == 0 {
+		w.str(`<table:table-cell/>`)
+		return
+	}
+	w.str(`<table:table-cell` + attrs.String() + `>`)
+	if text != "" {
+		for _, line := range strings.Split(text, "\n") {
+			w.str(`<text:p>` + escapeText(line) + `</text:p>`)
+		}
+	}
+	w.str(`</table:table-cell>`)
+}
+
+var (
+	attrEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;", `"`, "&quot;", "'", "&apos;",
+		"\t", "&#x9;", "\n", "&#xA;", "\r", "&#xD;")
+	textEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+)
+
+func escapeAttr(s string) string {
+	return attrEscaper.Replace(s)
+}
+
+// escapeText escapes XML and keeps runs of spaces and tabs, which ODF collapses
+// otherwise (including a single leading space).
+func escapeText(s string) string {
+	var b strings.Builder
+	spaces := 0
+	flush := func() {
+		if spaces == 0 {
+			return
+		}
+		if b.Len() > 0 {
+			b.WriteByte(' ')
+			spaces--
+		}
+		if spaces > 0 {
+			fmt.Fprintf(&b, `<text:s text:c="%d"/>`, spaces)
+		}
+		spaces = 0
+	}
+	for _, r := range s {
+		switch r {
+		case ' ':
+			spaces++
+			continue
+		case '\t':
+			flush()
+			b.WriteString(`<text:tab/>`)
+			continue
+		}
+		flush()
+		b.WriteString(textEscaper.Replace(string(r)))
+	}
+	flush()
+	return b.String()
+}
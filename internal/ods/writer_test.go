@@ -0,0 +1,327 @@
+package ods
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"io"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/romanitalian/osheet2xlsx/v3/internal/osheet"
+)
+
+// odsCell is what readBack recovers for one table cell.
+type odsCell struct {
+	typ, value, formula string
+	rows, cols          int
+	covered             bool
+}
+
+type odsTable struct {
+	name    string
+	columns []string // style names, one per column
+	rows    []string // style names, one per row
+	cells   [][]odsCell
+	styles  map[string]string // automatic style name -> width or height
+}
+
+// readBack parses content.xml of an ODS package into tables.
+func readBack(t *testing.T, path string) []odsTable {
+	t.Helper()
+	zr, err := zip.OpenReader(path)
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	defer zr.Close()
+	if zr.File[0].Name != "mimetype" || zr.File[0].Method != zip.Store {
+		t.Fatalf("first entry must be stored mimetype, got %s", zr.File[0].Name)
+	}
+	var content io.ReadCloser
+	for _, f := range zr.File {
+		if f.Name == "content.xml" {
+			if content, err = f.Open(); err != nil {
+				t.Fatalf("open content: %v", err)
+			}
+		}
+	}
+	if content == nil {
+		t.Fatal("content.xml missing")
+	}
+	defer content.Close()
+
+	var tables []odsTable
+	styles := map[string]string{}
+	var cur *odsTable
+	var style string
+	dec := xml.NewDecoder(content)
+	attr := func(se xml.StartElement, local string) string {
+		for _, a := range se.Attr {
+			if a.Name.Local == local {
+				return a.Value
+			}
+		}
+		return ""
+	}
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("content.xml is not well-formed: %v", err)
+		}
+		switch el := tok.(type) {
+		case xml.StartElement:
+			switch el.Name.Local {
+			case "style":
+				style = attr(el, "name")
+			case "table-column-properties":
+				styles[style] = attr(el, "column-width")
+			case "table-row-properties":
+				styles[style] = attr(el, "row-height")
+			case "table":
+				tables = append(tables, odsTable{name: attr(el, "name"), styles: styles})
+				cur = &tables[len(tables)-1]
+			case "table-column":
+				cur.columns = append(cur.columns, attr(el, "style-name"))
+			case "table-row":
+				cur.rows = append(cur.rows, attr(el, "style-name"))
+				cur.cells = append(cur.cells, nil)
+			case "table-cell", "covered-table-cell":
+				c := odsCell{typ: attr(el, "value-type"), formula: attr(el, "formula"), covered: el.Name.Local == "covered-table-cell"}
+				c.value = attr(el, "value") + attr(el, "date-value") + attr(el, "boolean-value")
+				c.rows, _ = strconv.Atoi(attr(el, "number-rows-spanned"))
+				c.cols, _ = strconv.Atoi(attr(el, "number-columns-spanned"))
+				r := len(cur.cells) - 1
+				cur.cells[r] = append(cur.cells[r], c)
+			case "p":
+				text := readParagraph(t, dec)
+				r := len(cur.cells) - 1
+				c := &cur.cells[r][len(cur.cells[r])-1]
+				if c.typ == "string" {
+					c.value += text
+				}
+			}
+		}
+	}
+	return tables
+}
+
+// readParagraph collects a text:p body, expanding text:s and text:tab.
+func readParagraph(t *testing.T, dec *xml.Decoder) string {
+	t.Helper()
+	var b strings.Builder
+	for depth := 1; depth > 0; {
+		tok, err := dec.Token()
+		if err != nil {
+			t.Fatalf("paragraph: %v", err)
+		}
+		switch el := tok.(type) {
+		case xml.CharData:
+			b.Write(el)
+		case xml.StartElement:
+			depth++
+			switch el.Name.Local {
+			case "s":
+				n := 1
+				for _, a := range el.Attr {
+					if a.Name.Local == "c" {
+						n, _ = strconv.Atoi(a.Value)
+					}
+				}
+				b.WriteString(strings.Repeat(" ", n))
+			case "tab":
+				b.WriteByte('\t')
+			}
+		case xml.EndElement:
+			depth--
+		}
+	}
+	return b.String()
+}
+
+func TestWriteBook_RoundTripExamples(t *testing.T) {
+	for _, name := range []string{"sample.osheet", "typed.osheet"} {
+		t.Run(name, func(t *testing.T) {
+			book, err := osheet.ReadBookUniversal(filepath.Join("..", "..", "examples", name))
+			if err != nil {
+				t.Fatalf("read example: %v", err)
+			}
+			out := filepath.Join(t.TempDir(), strings.TrimSuffix(name, ".osheet")+".ods")
+			if err := WriteBook(book, out); err != nil {
+				t.Fatalf("WriteBook: %v", err)
+			}
+			tables := readBack(t, out)
+			if len(tables) != len(book.Sheets) {
+				t.Fatalf("tables = %d, want %d", len(tables), len(book.Sheets))
+			}
+			_, sheets := sheetNames(book)
+			for i := range book.Sheets {
+				compareSheet(t, &book.Sheets[i], tables[i], sheets)
+			}
+		})
+	}
+}
+
+// compareSheet checks that every source cell comes back with the same type, value and formula.
+func compareSheet(t *testing.T, s *osheet.Sheet, tb odsTable, sheets map[string]string) {
+	t.Helper()
+	if tb.name != s.Name {
+		t.Errorf("name = %q, want %q", tb.name, s.Name)
+	}
+	for r, row := range s.Cells {
+		for c, cell := range row {
+			got := tb.cells[r][c]
+			if want, _ := toOpenFormula(cell.Formula, sheets); cell.Formula != "" && got.formula != want {
+				t.Errorf("%d,%d formula = %q", r, c, got.formula)
+			}
+			switch cell.Type {
+			case osheet.ValueNumber:
+				if got.typ != "float" || got.value != strconv.FormatFloat(cell.NumberValue, 'f', -1, 64) {
+					t.Errorf("%d,%d = %+v, want number %v", r, c, got, cell.NumberValue)
+				}
+			case osheet.ValueDateTime:
+				if got.typ != "date" || got.value != osheet.FormatSerialISO(cell.DateEpoch) {
+					t.Errorf("%d,%d = %+v, want date %v", r, c, got, cell.DateEpoch)
+				}
+			case osheet.ValueString:
+				// Paragraphs are split on line breaks
+				if got.typ != "string" || got.value != strings.ReplaceAll(cell.StringValue, "\n", "") {
+					t.Errorf("%d,%d = %+v, want string %q", r, c, got, cell.StringValue)
+				}
+			}
+		}
+	}
+}
+
+func TestWriteBookWithOptions_Formulas(t *testing.T) {
+	book := &osheet.Book{Sheets: []osheet.Sheet{{
+		Name:  "Calc",
+		Width: 3,
+		Cells: [][]osheet.Cell{{
+			{Type: osheet.ValueNumber, NumberValue: 2},
+			{Formula: "SYNO_FN(A1)"},
+			{Type: osheet.ValueString, StringValue: "=SUMME(A1;1)"},
+		}},
+	}}}
+	var warnings []string
+	out := filepath.Join(t.TempDir(), "calc.ods")
+	if err := WriteBookWithOptions(book, out, Options{Warn: func(w string) { warnings = append(warnings, w) }}); err != nil {
+		t.Fatalf("WriteBookWithOptions: %v", err)
+	}
+	cells := readBack(t, out)[0].cells[0]
+	if cells[1].formula != "of:=SYNO_FN([.A1])" {
+		t.Errorf("B1 formula = %q", cells[1].formula)
+	}
+	// Text starting with "=" is a formula, as in xlsx output
+	if c := cells[2]; c.formula != "of:=SUM([.A1];1)" || c.typ != "" || c.value != "" {
+		t.Errorf("C1 = %+v", c)
+	}
+	if len(warnings) != 1 || warnings[0] != "Calc!B1: unknown function SYNO_FN" {
+		t.Errorf("warnings = %q", warnings)
+	}
+}
+
+func TestWriteBook_LayoutRoundTrip(t *testing.T) {
+	book := &osheet.Book{Sheets: []osheet.Sheet{
+		{
+			Name:   "Main",
+			Width:  3,
+			Height: 2,
+			Cells: [][]osheet.Cell{
+				{{Type: osheet.ValueString, StringValue: "a  b <c>"}, {}, {Type: osheet.ValueBool, BoolValue: true}},
+				{{Type: osheet.ValueDateTime, DateEpoch: 45293.5}, {Formula: "'Q1/Q2'!A1*2"}, {}},
+			},
+			Merges: []osheet.Merge{{StartRow: 1, StartCol: 1, EndRow: 1, EndCol: 2}},
+			Cols:   []osheet.ColSpec{{Index: 2, Width: 20}},
+			Rows:   []osheet.RowSpec{{Index: 4, Height: 30}},
+		},
+		{Name: "Q1/Q2", Width: 1, Height: 1, Cells: [][]osheet.Cell{{{Type: osheet.ValueNumber, NumberValue: 7}}}},
+	}}
+	out := filepath.Join(t.TempDir(), "layout.ods")
+	if err := WriteBook(book, out); err != nil {
+		t.Fatalf("WriteBook: %v", err)
+	}
+	tables := readBack(t, out)
+	if len(tables) != 2 || tables[1].name != "Q1_Q2" {
+		t.Fatalf("tables = %+v", tables)
+	}
+	tb := tables[0]
+	if a := tb.cells[0][0]; a.rows != 1 || a.cols != 2 || a.value != "a  b <c>" {
+		t.Errorf("merge anchor = %+v", a)
+	}
+	if !tb.cells[0][1].covered {
+		t.Errorf("B1 should be covered: %+v", tb.cells[0][1])
+	}
+	if c := tb.cells[0][2]; c.typ != "boolean" || c.value != "true" {
+		t.Errorf("C1 = %+v", c)
+	}
+	if c := tb.cells[1][0]; c.typ != "date" || c.value != "2024-01-02T12:00:00" {
+		t.Errorf("A2 = %+v", c)
+	}
+	if c := tb.cells[1][1]; c.formula != "of:=[Q1_Q2.A1]*2" {
+		t.Errorf("B2 formula = %q", c.formula)
+	}
+	// Column 2 is 20 chars wide; row 4 exists only for its height
+	if len(tb.columns) != 3 || tb.columns[0] != "" || !strings.HasSuffix(tb.styles[tb.columns[1]], "in") {
+		t.Errorf("columns = %v styles = %v", tb.columns, tb.styles)
+	}
+	if len(tb.rows) != 4 || tb.styles[tb.rows[3]] != "30pt" {
+		t.Errorf("rows = %v styles = %v", tb.rows, tb.styles)
+	}
+}
+
+func TestWriteBook_Meta(t *testing.T) {
+	book := &osheet.Book{
+		Title: "Q3 <Sales>",
+		Properties: osheet.DocProperties{
+			Author:  "Ann",
+			Created: time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC),
+			Custom: []osheet.CustomProperty{
+				{Name: "Pages", Value: 3.0},
+				{Name: "Final", Value: true},
+				{Name: "Reviewed", Value: time.Date(2024, 3, 2, 0, 0, 0, 0, time.UTC)},
+			},
+		},
+		Sheets: []osheet.Sheet{{Name: "S", Cells: [][]osheet.Cell{{{Type: osheet.ValueString, StringValue: "x"}}}}},
+	}
+	out := filepath.Join(t.TempDir(), "meta.ods")
+	if err := WriteBook(book, out); err != nil {
+		t.Fatalf("WriteBook: %v", err)
+	}
+	zr, err := zip.OpenReader(out)
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	defer zr.Close()
+	var meta string
+	for _, f := range zr.File {
+		if f.Name == "meta.xml" {
+			rc, err := f.Open()
+			if err != nil {
+				t.Fatalf("open meta: %v", err)
+			}
+			data, _ := io.ReadAll(rc)
+			_ = rc.Close()
+			meta = string(data)
+		}
+	}
+	for _, want := range []string{
+		`<dc:title>Q3 &lt;Sales&gt;</dc:title>`,
+		`<meta:initial-creator>Ann</meta:initial-creator>`,
+		`<meta:creation-date>2024-03-01T09:00:00Z</meta:creation-date>`,
+		`<meta:user-defined meta:name="Pages" meta:value-type="float">3</meta:user-defined>`,
+		`<meta:user-defined meta:name="Final" meta:value-type="boolean">true</meta:user-defined>`,
+		`<meta:user-defined meta:name="Reviewed" meta:value-type="date">2024-03-02T00:00:00Z</meta:user-defined>`,
+	} {
+		if !strings.Contains(meta, want) {
+			t.Errorf("meta.xml has no %s:\n%s", want, meta)
+		}
+	}
+	if err := xml.Unmarshal([]byte(meta), new(struct{})); err != nil {
+		t.Errorf("meta.xml is not well-formed: %v", err)
+	}
+}
@@ -1,13 +1,18 @@
 package osheet
 
 import (
-	"encoding/json"
 	"fmt"
+	"sort"
 	"strconv"
 )
 
 // ConvertBinaryToSheet converts a BinarySheet to our standard Sheet format
 func ConvertBinaryToSheet(binary *BinarySheet) (*Sheet, error) {
+	return convertBinarySheet(binary, ReadOptions{})
+}
+
+// convertBinarySheet is ConvertBinaryToSheet with options.
+func convertBinarySheet(binary *BinarySheet, opts ReadOptions) (*Sheet, error) {
 	if binary == nil {
 		return nil, fmt.Errorf("binary sheet is nil")
 	}
@@ -45,6 +50,8 @@ func ConvertBinaryToSheet(binary *BinarySheet) (*Sheet, error) {
 		cells[i] = make([]Cell, width)
 	}
 
+	styles := binaryStyleEntries(binary.Styles)
+
 	// Fill cells from binary format
 	for rowKey, rowData := range binary.Cells {
 		rowIndex, err := strconv.Atoi(rowKey)
@@ -59,100 +66,98 @@ func ConvertBinaryToSheet(binary *BinarySheet) (*Sheet, error) {
 			}
 
 			// Convert cell data to our format
-			cell := inferCell(cellData.Value)
-			cells[rowIndex][colIndex] = cell
+			cells[rowIndex][colIndex] = binaryCell(cellData, styles, opts)
 		}
 	}
 
-	// Convert column specifications
+	sheet := binarySheetMeta(binary)
+	sheet.Width = width
+	sheet.Height = height
+	sheet.Cells = cells
+	return &sheet, nil
+}
+
+// binarySheetMeta converts everything but the cells of a BinarySheet. Binary
+// row and column keys are 0-based; the model is 1-based.
+func binarySheetMeta(binary *BinarySheet) Sheet {
 	cols := make([]ColSpec, 0, len(binary.Cols))
 	for colKey, colData := range binary.Cols {
 		colIndex, err := strconv.Atoi(colKey)
-		if err != nil {
+		if err != nil || colIndex < 0 {
 			continue
 		}
-		cols = append(cols, ColSpec{
-			Index: colIndex,
-			Width: colData.Width,
-		})
+		cols = append(cols, ColSpec{Index: colIndex + 1, Width: colData.Width, Hidden: colData.Hidden})
 	}
+	sort.Slice(cols, func(i, j int) bool { return cols[i].Index < cols[j].Index })
 
-	return &Sheet{
-		Name:   binary.Title,
-		Width:  width,
-		Height: height,
-		Cells:  cells,
-		Cols:   cols,
-		Merges: nil, // Not supported in binary format for MVP
-		Rows:   nil, // Not supported in binary format for MVP
-	}, nil
-}
-
-// GenerateDocumentJSON creates a document.json in our expected format
-func GenerateDocumentJSON(sheet *Sheet) ([]byte, error) {
-	// Convert cells to our v3 format with short keys
-	cells := make([][]interface{}, len(sheet.Cells))
-	for i, row := range sheet.Cells {
-		cells[i] = make([]interface{}, len(row))
-		for j, cell := range row {
-			cellData := make(map[string]interface{})
-
-			// Set value based on cell type
-			switch cell.Type {
-			case ValueString:
-				cellData["t"] = "s"
-				cellData["v"] = cell.StringValue
-			case ValueNumber:
-				cellData["t"] = "n"
-				cellData["v"] = cell.NumberValue
-			case ValueBool:
-				cellData["t"] = "b"
-				cellData["v"] = cell.BoolValue
-			case ValueDateTime:
-				cellData["t"] = "d"
-				cellData["v"] = cell.DateEpoch
-			default:
-				cellData["v"] = cell.StringValue
-			}
-
-			// Add formula if present
-			if cell.Formula != "" {
-				cellData["f"] = cell.Formula
-			}
-
-			cells[i][j] = cellData
+	var rows []RowSpec
+	for rowKey, rowData := range binary.Rows {
+		rowIndex, err := strconv.Atoi(rowKey)
+		if err != nil || rowIndex < 0 {
+			continue
 		}
+		rows = append(rows, RowSpec{Index: rowIndex + 1, Height: rowData.Height, Hidden: rowData.Hidden})
+	}
+	sort.Slice(rows, func(i, j int) bool { return rows[i].Index < rows[j].Index })
+
+	var merges []Merge
+	for _, m := range binary.Merges {
+		merges = append(merges, Merge{
+			StartRow: m.Row + 1,
+			StartCol: m.Col + 1,
+			EndRow:   m.Row + m.RowSpan,
+			EndCol:   m.Col + m.ColSpan,
+		})
 	}
 
-	// Create document structure
-	document := map[string]interface{}{
-		"sheets": []interface{}{
-			map[string]interface{}{
-				"name":  sheet.Name,
-				"cells": cells,
-			},
-		},
+	return Sheet{
+		Name:               binary.Title,
+		Cols:               cols,
+		Rows:               rows,
+		Merges:             merges,
+		DefaultColWidth:    binary.DefaultColWidth,
+		DefaultRowHeight:   binary.DefaultRowHeight,
+		View:               binary.View,
+		Validations:        binary.Validations,
+		ConditionalFormats: binary.ConditionalFormats,
+		Images:             binary.Images,
+		AutoFilter:         binary.AutoFilter,
+		Tables:             binary.Tables,
+		Protection:         binary.Protection,
 	}
+}
 
-	// Add column specifications if present
-	if len(sheet.Cols) > 0 {
-		cols := make([]map[string]interface{}, 0, len(sheet.Cols))
-		for _, col := range sheet.Cols {
-			cols = append(cols, map[string]interface{}{
-				"index": col.Index,
-				"width": col.Width,
-			})
+// binaryStyleEntries resolves style ids once so cells sharing a style share the pointer
+func binaryStyleEntries(binaryStyles map[string]StyleData) map[int]styleEntry {
+	styles := make(map[int]styleEntry, len(binaryStyles))
+	for id, data := range binaryStyles {
+		styleID, err := strconv.Atoi(id)
+		if err != nil {
+			continue
 		}
-		if sheets, ok := document["sheets"].([]interface{}); ok && len(sheets) > 0 {
-			if firstSheet, ok := sheets[0].(map[string]interface{}); ok {
-				firstSheet["cols"] = cols
-			}
+		entry := styleEntry{numFmt: data.NumFmt}
+		if data.Style != (Style{}) {
+			st := data.Style
+			entry.style = &st
 		}
+		styles[styleID] = entry
 	}
+	return styles
+}
 
-	result, err := json.MarshalIndent(document, "", "  ")
-	if err != nil {
-		return nil, fmt.Errorf("failed to marshal document: %w", err)
+// binaryCell converts a single binary cell into the standard Cell model
+func binaryCell(data CellData, styles map[int]styleEntry, opts ReadOptions) Cell {
+	cell := inferCell(data.Value, opts)
+	if data.Runs != nil && data.Formula == "" {
+		cell = richTextCell(data.Runs)
 	}
-	return result, nil
+	cell.Formula = data.Formula
+	cell.Link, cell.Comment = data.Link, data.Comment
+	applyStyleEntry(&cell, styles[data.Style])
+	return cell
+}
+
+// GenerateDocumentJSON creates a document.json in our expected format for a single sheet
+func GenerateDocumentJSON(sheet *Sheet) ([]byte, error) {
+	return GenerateBookDocumentJSON(&Book{Sheets: []Sheet{*sheet}})
 }
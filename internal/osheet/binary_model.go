@@ -1,25 +1,68 @@
 package osheet
 
-// BinarySheet represents a parsed binary .osheet file structure
+// BinaryBook represents all sheets of a parsed binary .osheet file in tab order
+type BinaryBook struct {
+	Title        string
+	Sheets       []BinarySheet
+	DefinedNames []DefinedName
+	Protection   *WorkbookProtection
+	Properties   DocProperties
+}
+
+// BinarySheet represents a parsed binary .osheet file structure. Row and
+// column keys are 0-based, as in Cells.
 type BinarySheet struct {
-	Title  string
-	Cells  map[string]map[string]CellData
-	Cols   map[string]ColData
-	Styles map[string]StyleData
+	Title              string
+	Cells              map[string]map[string]CellData
+	Cols               map[string]ColData
+	Rows               map[string]RowData
+	Merges             []MergeData
+	DefaultColWidth    float64
+	DefaultRowHeight   float64
+	View               SheetView
+	Validations        []DataValidation
+	ConditionalFormats []ConditionalFormat
+	Images             []Image
+	AutoFilter         string
+	Tables             []Table
+	Protection         *SheetProtection
+	Styles             map[string]StyleData
 }
 
 // CellData represents a single cell in binary format
 type CellData struct {
-	Value string `json:"v"`
-	Style int    `json:"s,omitempty"`
+	Value   string     `json:"v"`
+	Style   int        `json:"s,omitempty"`
+	Formula string     `json:"f,omitempty"`
+	Link    *Hyperlink `json:"link,omitempty"`
+	Comment *Comment   `json:"comment,omitempty"`
+	// Runs is the value as rich text; Value keeps the plain text.
+	Runs []RichTextRun `json:"runs,omitempty"`
 }
 
 // ColData represents column metadata in binary format
 type ColData struct {
-	Width float64 `json:"w"`
+	Width  float64 `json:"w,omitempty"`
+	Hidden bool    `json:"hidden,omitempty"`
+}
+
+// RowData represents row metadata in binary format
+type RowData struct {
+	Height float64 `json:"h,omitempty"`
+	Hidden bool    `json:"hidden,omitempty"`
+}
+
+// MergeData represents a merged range in binary format: the 0-based top-left
+// cell and the number of rows and columns it spans
+type MergeData struct {
+	Row     int `json:"r"`
+	Col     int `json:"c"`
+	RowSpan int `json:"rs"`
+	ColSpan int `json:"cs"`
 }
 
-// StyleData represents style information (simplified for MVP)
+// StyleData represents a parsed entry of the binary styles table
 type StyleData struct {
-	// For future expansion - currently not used in conversion
+	Style  Style
+	NumFmt string
 }
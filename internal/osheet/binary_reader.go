@@ -4,8 +4,11 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"math"
 	"os"
 	"regexp"
+	"sort"
+	"strconv"
 	"strings"
 )
 
@@ -41,8 +44,18 @@ func IsBinaryOsheet(path string) bool {
 	return cellsPattern.MatchString(text)
 }
 
-// ParseBinaryOsheet parses a binary .osheet file and extracts sheet data
+// ParseBinaryOsheet parses a binary .osheet file and returns its first sheet.
+// Use ParseBinaryBook to get every sheet of the workbook.
 func ParseBinaryOsheet(path string) (*BinarySheet, error) {
+	book, err := ParseBinaryBook(path)
+	if err != nil {
+		return nil, err
+	}
+	return &book.Sheets[0], nil
+}
+
+// ParseBinaryBook parses a binary .osheet file and extracts all sheets in tab order
+func ParseBinaryBook(path string) (*BinaryBook, error) {
 	file, err := os.Open(path)
 	if err != nil {
 		return nil, fmt.Errorf("failed to open file: %w", err)
@@ -79,104 +92,280 @@ func ParseBinaryOsheet(path string) (*BinarySheet, error) {
 
 	// Extract sheet information
 	sheets, ok := jsonData["sheets"].(map[string]interface{})
-	if !ok {
+	if !ok || len(sheets) == 0 {
 		return nil, fmt.Errorf("no sheets found in JSON")
 	}
 
-	// Find the sheet data in the text/sh_1 section
-	// The actual sheet data is in a separate JSON object
-	sheetDataStart := strings.Index(text, `text/sh_1`)
-	if sheetDataStart == -1 {
-		return nil, fmt.Errorf("no sheet data section found")
+	// Styles may be shared across sheets in the header and overridden per sheet
+	headerStyles := parseStyleTable(jsonData["styles"])
+
+	book := &BinaryBook{
+		DefinedNames: parseDefinedNames(firstPresent(jsonData, "definedNames", "names")),
+		Protection:   parseWorkbookProtection(jsonData["protection"]),
 	}
+	book.Title, book.Properties = parseDocProperties(toString(jsonData["title"]), jsonData["properties"])
+	found := 0
+	for _, entry := range orderBinarySheets(sheets) {
+		// The actual sheet data is in a separate JSON object after text/<id>
+		sheet := BinarySheet{
+			Title:  entry.title,
+			Cells:  make(map[string]map[string]CellData),
+			Cols:   make(map[string]ColData),
+			Styles: binaryStyles(headerStyles),
+		}
+		sheetDataStart := findSection(text, "text/"+entry.id)
+		if sheetDataStart == -1 {
+			// Keep the tab even when its payload is missing
+			book.Sheets = append(book.Sheets, sheet)
+			continue
+		}
+
+		// Find the JSON object after text/<id>
+		sheetJSONStart := strings.Index(text[sheetDataStart:], "{")
+		if sheetJSONStart == -1 {
+			return nil, fmt.Errorf("no sheet JSON found for %s", entry.id)
+		}
+
+		sheetJSONContent, err := extractCompleteJSON(text[sheetDataStart+sheetJSONStart:])
+		if err != nil {
+			return nil, fmt.Errorf("failed to extract sheet JSON for %s: %w", entry.id, err)
+		}
+
+		// Parse the sheet JSON
+		var sheetJSON map[string]interface{}
+		if jsonErr := json.Unmarshal([]byte(sheetJSONContent), &sheetJSON); jsonErr != nil {
+			return nil, fmt.Errorf("failed to parse sheet JSON for %s: %w", entry.id, jsonErr)
+		}
 
-	// Find the JSON object after text/sh_1
-	sheetJSONStart := strings.Index(text[sheetDataStart:], "{")
-	if sheetJSONStart == -1 {
-		return nil, fmt.Errorf("no sheet JSON found")
+		applyBinarySheetJSON(&sheet, sheetJSON, headerStyles)
+		book.Sheets = append(book.Sheets, sheet)
+		found++
 	}
 
-	sheetJSONContent, err := extractCompleteJSON(text[sheetDataStart+sheetJSONStart:])
-	if err != nil {
-		return nil, fmt.Errorf("failed to extract sheet JSON: %w", err)
+	if found == 0 {
+		return nil, fmt.Errorf("no sheet data section found")
 	}
 
-	// Parse the sheet JSON
-	var sheetJSON map[string]interface{}
-	if jsonErr := json.Unmarshal([]byte(sheetJSONContent), &sheetJSON); jsonErr != nil {
-		return nil, fmt.Errorf("failed to parse sheet JSON: %w", jsonErr)
+	return book, nil
+}
+
+// applyBinarySheetJSON fills a BinarySheet from its decoded text/sh_N payload.
+// A payload without "cells" only contributes metadata.
+func applyBinarySheetJSON(sheet *BinarySheet, sheetJSON map[string]interface{}, headerStyles styleTable) {
+	if cells, ok := sheetJSON["cells"].(map[string]interface{}); ok {
+		sheet.Cells = parseBinaryCells(cells)
 	}
+	sheet.Cols = parseBinaryCols(sheetJSON["cols"])
+	sheet.Rows = parseBinaryRows(sheetJSON["rows"])
+	sheet.Merges = parseBinaryMerges(sheetJSON["merges"])
+	sheet.DefaultColWidth = toFloat(sheetJSON["defaultColWidth"])
+	sheet.DefaultRowHeight = toFloat(sheetJSON["defaultRowHeight"])
+	sheet.View = newSheetView(int(toFloat(sheetJSON["frozenRows"])), int(toFloat(sheetJSON["frozenCols"])),
+		toString(sheetJSON["tabColor"]), toString(sheetJSON["visibility"]), toFloat(sheetJSON["zoom"]))
+	sheet.Validations = parseValidations(sheetJSON["validations"])
+	sheet.ConditionalFormats = parseConditionalFormats(sheetJSON["conditionalFormats"])
+	sheet.Images = parseImages(sheetJSON["images"])
+	sheet.AutoFilter = parseAutoFilter(sheetJSON["autoFilter"])
+	sheet.Tables = parseTables(sheetJSON["tables"])
+	sheet.Protection = parseSheetProtection(sheetJSON["protection"])
+	sheet.Styles = binaryStyles(headerStyles.merge(parseStyleTable(sheetJSON["styles"])))
+}
 
-	// Extract title from the first sheet info
-	title := "Sheet"
-	for _, data := range sheets {
-		if sheet, sheetOk := data.(map[string]interface{}); sheetOk {
-			if titleVal, titleOk := sheet["title"].(string); titleOk {
-				title = titleVal
-				break
+// binarySheetEntry is a single entry of the "sheets" map in the gcVer header
+type binarySheetEntry struct {
+	id    string
+	title string
+	order int
+}
+
+// orderBinarySheets returns the header sheet entries in tab order: by their
+// "order" field, or by the numeric suffix of the id (sh_N) without one.
+func orderBinarySheets(sheets map[string]interface{}) []binarySheetEntry {
+	entries := make([]binarySheetEntry, 0, len(sheets))
+	for id, data := range sheets {
+		entry := binarySheetEntry{id: id, title: "Sheet", order: sheetIDNumber(id)}
+		if sheet, ok := data.(map[string]interface{}); ok {
+			if titleVal, titleOk := sheet["title"].(string); titleOk && titleVal != "" {
+				entry.title = titleVal
 			}
+			if v, ok := sheet["order"].(float64); ok {
+				entry.order = int(v)
+			}
+		}
+		if !strings.HasPrefix(entry.id, "sh_") {
+			entry.id = "sh_" + entry.id
 		}
+		entries = append(entries, entry)
 	}
+	sort.SliceStable(entries, func(i, j int) bool {
+		if entries[i].order != entries[j].order {
+			return entries[i].order < entries[j].order
+		}
+		return entries[i].id < entries[j].id
+	})
+	return entries
+}
 
-	// Extract cells data from sheetJSON
-	cells, ok := sheetJSON["cells"].(map[string]interface{})
-	if !ok {
-		return nil, fmt.Errorf("no cells data found")
+// sheetIDNumber extracts N from ids like "sh_N" or "N"; unknown ids sort last
+func sheetIDNumber(id string) int {
+	n, err := strconv.Atoi(strings.TrimPrefix(id, "sh_"))
+	if err != nil {
+		return math.MaxInt32
+	}
+	return n
+}
+
+// findSection returns the index of name in text, ignoring matches that are a prefix
+// of a longer section name (e.g. text/sh_1 inside text/sh_10)
+func findSection(text, name string) int {
+	offset := 0
+	for {
+		idx := strings.Index(text[offset:], name)
+		if idx == -1 {
+			return -1
+		}
+		end := offset + idx + len(name)
+		if end >= len(text) || !isSectionNameChar(text[end]) {
+			return offset + idx
+		}
+		offset = end
 	}
+}
+
+func isSectionNameChar(ch byte) bool {
+	return ch == '_' || (ch >= '0' && ch <= '9') || (ch >= 'a' && ch <= 'z') || (ch >= 'A' && ch <= 'Z')
+}
 
-	// Parse cells structure
+// parseBinaryCells converts the raw "cells" map into typed cell data
+func parseBinaryCells(cells map[string]interface{}) map[string]map[string]CellData {
 	parsedCells := make(map[string]map[string]CellData)
 	for rowKey, rowData := range cells {
 		if rowMap, ok := rowData.(map[string]interface{}); ok {
-			parsedCells[rowKey] = make(map[string]CellData)
-			for colKey, cellData := range rowMap {
-				if cellMap, ok := cellData.(map[string]interface{}); ok {
-					cell := CellData{}
-					if val, ok := cellMap["v"].(string); ok {
-						cell.Value = val
-					}
-					if style, ok := cellMap["s"].(float64); ok {
-						cell.Style = int(style)
-					}
-					parsedCells[rowKey][colKey] = cell
-				}
+			parsedCells[rowKey] = parseBinaryRow(rowMap)
+		}
+	}
+	return parsedCells
+}
+
+// parseBinaryRow converts a single raw row map (column key -> cell object)
+func parseBinaryRow(rowMap map[string]interface{}) map[string]CellData {
+	row := make(map[string]CellData, len(rowMap))
+	for colKey, cellData := range rowMap {
+		if cellMap, ok := cellData.(map[string]interface{}); ok {
+			cell := CellData{}
+			if val, ok := cellMap["v"].(string); ok {
+				cell.Value = val
+			}
+			if style, ok := cellMap["s"].(float64); ok {
+				cell.Style = int(style)
+			}
+			if formula, ok := cellMap["f"].(string); ok {
+				cell.Formula = formula
 			}
+			cell.Link = parseLink(firstPresent(cellMap, "link", "hyperlink"))
+			cell.Comment = parseComment(firstPresent(cellMap, "comment", "note"))
+			cell.Runs = parseRichText(firstPresent(cellMap, "runs", "richText"))
+			row[colKey] = cell
 		}
 	}
+	return row
+}
 
-	// Extract columns data
+// binaryStyles converts a parsed style table into binary style data keyed by id
+func binaryStyles(table styleTable) map[string]StyleData {
+	styles := make(map[string]StyleData, len(table))
+	for id, entry := range table {
+		data := StyleData{NumFmt: entry.numFmt}
+		if entry.style != nil {
+			data.Style = *entry.style
+		}
+		styles[id] = data
+	}
+	return styles
+}
+
+// parseBinaryCols converts the raw "cols" map into column metadata
+func parseBinaryCols(raw interface{}) map[string]ColData {
 	cols := make(map[string]ColData)
-	if colsData, ok := sheetJSON["cols"].(map[string]interface{}); ok {
+	if colsData, ok := raw.(map[string]interface{}); ok {
 		for colKey, colData := range colsData {
 			if colMap, ok := colData.(map[string]interface{}); ok {
-				if width, ok := colMap["w"].(float64); ok {
-					cols[colKey] = ColData{Width: width}
+				col := ColData{Width: toFloat(colMap["w"]), Hidden: colMap["hidden"] == true}
+				if col != (ColData{}) {
+					cols[colKey] = col
 				}
 			}
 		}
 	}
+	return cols
+}
 
-	return &BinarySheet{
-		Title:  title,
-		Cells:  parsedCells,
-		Cols:   cols,
-		Styles: make(map[string]StyleData), // Simplified for MVP
-	}, nil
+// parseBinaryRows converts the raw "rows" map into row metadata
+func parseBinaryRows(raw interface{}) map[string]RowData {
+	rows := make(map[string]RowData)
+	if rowsData, ok := raw.(map[string]interface{}); ok {
+		for rowKey, rowData := range rowsData {
+			if rowMap, ok := rowData.(map[string]interface{}); ok {
+				row := RowData{Height: toFloat(rowMap["h"]), Hidden: rowMap["hidden"] == true}
+				if row != (RowData{}) {
+					rows[rowKey] = row
+				}
+			}
+		}
+	}
+	return rows
 }
 
-// extractCompleteJSON finds the complete JSON object from the given text
+// parseBinaryMerges converts the raw "merges" array; spans default to one cell
+func parseBinaryMerges(raw interface{}) []MergeData {
+	items, ok := raw.([]interface{})
+	if !ok {
+		return nil
+	}
+	var merges []MergeData
+	for _, item := range items {
+		m, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		merge := MergeData{Row: toInt(m["r"]), Col: toInt(m["c"]), RowSpan: max(toInt(m["rs"]), 1), ColSpan: max(toInt(m["cs"]), 1)}
+		if merge.Row < 0 || merge.Col < 0 || (merge.RowSpan == 1 && merge.ColSpan == 1) {
+			continue
+		}
+		merges = append(merges, merge)
+	}
+	return merges
+}
+
+// extractCompleteJSON finds the complete JSON object from the given text.
+// Braces inside string literals are ignored.
 func extractCompleteJSON(text string) (string, error) {
 	braceCount := 0
 	endIndex := -1
+	inString := false
+	escaped := false
 
-	for i, char := range text {
-		if char == '{' {
+	for i := 0; i < len(text) && endIndex == -1; i++ {
+		char := text[i]
+		if inString {
+			if escaped {
+				escaped = false
+			} else if char == '\\' {
+				escaped = true
+			} else if char == '"' {
+				inString = false
+			}
+			continue
+		}
+		switch char {
+		case '"':
+			inString = true
+		case '{':
 			braceCount++
-		} else if char == '}' {
+		case '}':
 			braceCount--
 			if braceCount == 0 {
 				endIndex = i + 1
-				break
 			}
 		}
 	}
@@ -3,6 +3,7 @@ package osheet
 import (
 	"os"
 	"path/filepath"
+	"reflect"
 	"testing"
 )
 
@@ -162,3 +163,203 @@ func contains(s, substr string) bool {
 				return false
 			}()))
 }
+
+// writeBinaryFixture builds a minimal Synology-like binary container from a header and sheet sections
+func writeBinaryFixture(t *testing.T, header string, sections map[string]string, order []string) string {
+	t.Helper()
+	var b []byte
+	b = append(b, 0x00, 0x02)
+	b = append(b, []byte("schema\x00enc\x00id\x00ver\x00")...)
+	b = append(b, []byte(header)...)
+	for _, name := range order {
+		b = append(b, 0x00, 0x01)
+		b = append(b, []byte("text/"+name)...)
+		b = append(b, 0x00)
+		b = append(b, []byte(sections[name])...)
+	}
+	path := filepath.Join(t.TempDir(), "book.osheet")
+	if err := os.WriteFile(path, b, 0600); err != nil {
+		t.Fatalf("write fixture: %v", err)
+	}
+	return path
+}
+
+func TestParseBinaryBook_MultipleSheets(t *testing.T) {
+	header := `{"gcVer":1,"sheets":{"sh_10":{"title":"Tenth"},"sh_2":{"title":"Second"},"sh_1":{"title":"First"}}}`
+	sections := map[string]string{
+		"sh_1":  `{"cells":{"0":{"0":{"v":"a{b}"}}}}`,
+		"sh_2":  `{"cells":{"0":{"0":{"v":"2"}},"1":{"1":{"v":"x"}}},"cols":{"1":{"w":42}}}`,
+		"sh_10": `{"cells":{"0":{"0":{"v":"10"}}}}`,
+	}
+	// Section order in the file must not matter
+	path := writeBinaryFixture(t, header, sections, []string{"sh_10", "sh_2", "sh_1"})
+
+	if format, err := DetectFormat(path); err != nil || format != FormatBinary {
+		t.Fatalf("DetectFormat = %v, %v; want Binary", format, err)
+	}
+
+	book, err := ReadBinaryBook(path)
+	if err != nil {
+		t.Fatalf("ReadBinaryBook failed: %v", err)
+	}
+	want := []string{"First", "Second", "Tenth"}
+	if len(book.Sheets) != len(want) {
+		t.Fatalf("Expected %d sheets, got %d", len(want), len(book.Sheets))
+	}
+	for i, name := range want {
+		if book.Sheets[i].Name != name {
+			t.Errorf("sheet %d name = %q, want %q", i, book.Sheets[i].Name, name)
+		}
+	}
+	if got := book.Sheets[0].Cells[0][0].StringValue; got != "a{b}" {
+		t.Errorf("First!A1 = %q, want %q", got, "a{b}")
+	}
+	if got := book.Sheets[1].Cells[1][1].StringValue; got != "x" {
+		t.Errorf("Second!B2 = %q, want %q", got, "x")
+	}
+	if len(book.Sheets[1].Cols) != 1 || book.Sheets[1].Cols[0].Width != 42 {
+		t.Errorf("Second cols = %+v", book.Sheets[1].Cols)
+	}
+	if got := book.Sheets[2].Cells[0][0].NumberValue; got != 10 {
+		t.Errorf("Tenth!A1 = %v, want 10", got)
+	}
+}
+
+func TestReadBinaryBook_Layout(t *testing.T) {
+	header := `{"gcVer":1,"sheets":{"sh_1":{"title":"L"}}}`
+	sections := map[string]string{
+		"sh_1": `{"cells":{"0":{"0":{"v":"a"}}},` +
+			`"cols":{"2":{"w":30,"hidden":true},"0":{"w":12}},` +
+			`"rows":{"4":{"hidden":true},"1":{"h":28}},` +
+			`"merges":[{"r":0,"c":0,"rs":2,"cs":3},{"r":5,"c":1,"cs":2},{"r":9,"c":9}],` +
+			`"defaultColWidth":10,"defaultRowHeight":16}`,
+	}
+	path := writeBinaryFixture(t, header, sections, []string{"sh_1"})
+	book, err := ReadBinaryBook(path)
+	if err != nil {
+		t.Fatalf("ReadBinaryBook: %v", err)
+	}
+	br, err := OpenBookReader(path)
+	if err != nil {
+		t.Fatalf("OpenBookReader: %v", err)
+	}
+	defer br.Close()
+
+	wantCols := []ColSpec{{Index: 1, Width: 12}, {Index: 3, Width: 30, Hidden: true}}
+	wantRows := []RowSpec{{Index: 2, Height: 28}, {Index: 5, Hidden: true}}
+	// Single-cell spans are not merges
+	wantMerges := []Merge{{StartRow: 1, StartCol: 1, EndRow: 2, EndCol: 3}, {StartRow: 6, StartCol: 2, EndRow: 6, EndCol: 3}}
+	for name, s := range map[string]Sheet{"ReadBinaryBook": book.Sheets[0], "OpenBookReader": br.Book().Sheets[0]} {
+		if !reflect.DeepEqual(s.Cols, wantCols) {
+			t.Errorf("%s: cols = %+v, want %+v", name, s.Cols, wantCols)
+		}
+		if !reflect.DeepEqual(s.Rows, wantRows) {
+			t.Errorf("%s: rows = %+v, want %+v", name, s.Rows, wantRows)
+		}
+		if !reflect.DeepEqual(s.Merges, wantMerges) {
+			t.Errorf("%s: merges = %+v, want %+v", name, s.Merges, wantMerges)
+		}
+		if s.DefaultColWidth != 10 || s.DefaultRowHeight != 16 {
+			t.Errorf("%s: defaults = %v x %v", name, s.DefaultColWidth, s.DefaultRowHeight)
+		}
+	}
+}
+
+func TestParseBinaryBook_ExplicitOrder(t *testing.T) {
+	// Only "order" places a tab; other fields are not sort keys
+	header := `{"gcVer":1,"sheets":{"sh_1":{"title":"Last","order":1,"index":0},"sh_2":{"title":"Front","order":0,"index":1}}}`
+	sections := map[string]string{
+		"sh_1": `{"cells":{"0":{"0":{"v":"1"}}}}`,
+		"sh_2": `{"cells":{"0":{"0":{"v":"2"}}}}`,
+	}
+	path := writeBinaryFixture(t, header, sections, []string{"sh_1", "sh_2"})
+
+	book, err := ParseBinaryBook(path)
+	if err != nil {
+		t.Fatalf("ParseBinaryBook failed: %v", err)
+	}
+	if len(book.Sheets) != 2 || book.Sheets[0].Title != "Front" || book.Sheets[1].Title != "Last" {
+		t.Fatalf("unexpected sheet order: %+v", book.Sheets)
+	}
+}
+
+func TestParseBinaryBook_SheetWithoutCells(t *testing.T) {
+	header := `{"gcVer":1,"sheets":{"sh_1":{"title":"Data","order":0},"sh_2":{"title":"Blank","order":1}}}`
+	sections := map[string]string{
+		"sh_1": `{"cells":{"0":{"0":{"v":"1"}}}}`,
+		"sh_2": `{"defaultColWidth":12,"cols":{"0":{"w":30}}}`,
+	}
+	path := writeBinaryFixture(t, header, sections, []string{"sh_1", "sh_2"})
+
+	book, err := ParseBinaryBook(path)
+	if err != nil {
+		t.Fatalf("ParseBinaryBook failed: %v", err)
+	}
+	if len(book.Sheets) != 2 {
+		t.Fatalf("sheets = %+v", book.Sheets)
+	}
+	blank := book.Sheets[1]
+	if blank.Title != "Blank" || len(blank.Cells) != 0 || blank.DefaultColWidth != 12 || blank.Cols["0"].Width != 30 {
+		t.Errorf("sheet without cells = %+v", blank)
+	}
+}
+
+func TestParseBinaryBook_Styles(t *testing.T) {
+	header := `{"gcVer":1,"styles":{"1":{"font":{"bold":true}}},"sheets":{"sh_1":{"title":"S"}}}`
+	sections := map[string]string{
+		"sh_1": `{"styles":{"2":{"fill":"rgb(0,128,255)","font":{"italic":true,"size":14,"family":"Arial"}}},` +
+			`"cells":{"0":{"0":{"v":"a","s":1},"1":{"v":"b","s":2},"2":{"v":"c"}}}}`,
+	}
+	path := writeBinaryFixture(t, header, sections, []string{"sh_1"})
+
+	book, err := ReadBinaryBook(path)
+	if err != nil {
+		t.Fatalf("ReadBinaryBook failed: %v", err)
+	}
+	row := book.Sheets[0].Cells[0]
+	if row[0].Style == nil || !row[0].Style.Font.Bold {
+		t.Errorf("header style not applied: %+v", row[0].Style)
+	}
+	want := Style{Font: Font{Family: "Arial", Size: 14, Italic: true}, Fill: "0080FF"}
+	if row[1].Style == nil || *row[1].Style != want {
+		t.Errorf("sheet style = %+v, want %+v", row[1].Style, want)
+	}
+	if row[2].Style != nil {
+		t.Errorf("unstyled cell got style %+v", row[2].Style)
+	}
+}
+
+func TestParseBinaryBook_StyleKeys(t *testing.T) {
+	thin := BorderSide{Style: "thin"}
+	cases := []struct {
+		style string
+		want  *Style
+	}{
+		{`{"font":{"family":"Arial","size":11,"bold":true,"italic":true,"underline":true,"color":"#FF0000"}}`,
+			&Style{Font: Font{Family: "Arial", Size: 11, Bold: true, Italic: true, Underline: true, Color: "FF0000"}}},
+		{`{"fill":"#FFFF00"}`, &Style{Fill: "FFFF00"}},
+		{`{"fill":{"color":"rgb(0,128,255)"}}`, &Style{Fill: "0080FF"}},
+		{`{"border":"thin"}`, &Style{Border: Border{Left: thin, Right: thin, Top: thin, Bottom: thin}}},
+		{`{"border":{"left":"thin","bottom":{"style":"double","color":"#000000"}}}`,
+			&Style{Border: Border{Left: thin, Bottom: BorderSide{Style: "double", Color: "000000"}}}},
+		{`{"align":{"horizontal":"center","vertical":"middle","wrap":true,"indent":2}}`,
+			&Style{Alignment: Alignment{Horizontal: "center", Vertical: "center", Wrap: true, Indent: 2}}},
+		{`{"locked":false,"hidden":true}`, &Style{Protection: CellProtection{Unlocked: true, HideFormula: true}}},
+		{`{"protection":{"locked":false}}`, &Style{Protection: CellProtection{Unlocked: true}}},
+		// Keys Synology Office does not write are ignored
+		{`{"b":true,"i":true,"u":true,"ff":"Arial","fs":11,"fc":"#FF0000","bgColor":"#FFFF00","bd":"thin",` +
+			`"a":{"h":"center"},"v":"middle","w":true,"ind":2,"f":{"bold":true}}`, nil},
+	}
+	for _, tc := range cases {
+		header := `{"gcVer":1,"styles":{"1":` + tc.style + `},"sheets":{"sh_1":{"title":"S"}}}`
+		sections := map[string]string{"sh_1": `{"cells":{"0":{"0":{"v":"a","s":1}}}}`}
+		book, err := ReadBinaryBook(writeBinaryFixture(t, header, sections, []string{"sh_1"}))
+		if err != nil {
+			t.Fatalf("%s: %v", tc.style, err)
+		}
+		got := book.Sheets[0].Cells[0][0].Style
+		if (got == nil) != (tc.want == nil) || got != nil && *got != *tc.want {
+			t.Errorf("%s: style = %+v, want %+v", tc.style, got, tc.want)
+		}
+	}
+}
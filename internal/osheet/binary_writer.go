@@ -0,0 +1,267 @@
+package osheet
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	appfs "github.com/romanitalian/osheet2xlsx/v3/internal/fs"
+)
+
+// binaryPrelude is the record header preceding the gcVer JSON, and
+// binarySectionMark the one preceding each text/sh_N section name.
+var (
+	binaryPrelude     = []byte("\x00\x02schema\x00enc\x00id\x00ver\x00")
+	binarySectionMark = []byte{0x00, 0x01}
+)
+
+// binaryGCVersion is the gcVer written to the header.
+const binaryGCVersion = 1
+
+// binaryHeader is the gcVer JSON; gcVer must be the first key, since readers
+// locate the header by its `{"gcVer"` prefix.
+type binaryHeader struct {
+	GCVer  int                               `json:"gcVer"`
+	Title  string                            `json:"title,omitempty"`
+	Styles map[string]map[string]interface{} `json:"styles,omitempty"`
+	Sheets map[string]binaryHeaderSheet      `json:"sheets"`
+	// DefinedNames uses the document.json list form.
+	DefinedNames []DefinedName       `json:"definedNames,omitempty"`
+	Protection   *WorkbookProtection `json:"protection,omitempty"`
+	Properties   *propertiesOut      `json:"properties,omitempty"`
+}
+
+type binaryHeaderSheet struct {
+	Title string `json:"title"`
+	Order int    `json:"order"`
+}
+
+// binarySectionJSON is the text/sh_N payload of one sheet.
+type binarySectionJSON struct {
+	Cells              binaryCells            `json:"cells"`
+	Cols               map[string]ColData     `json:"cols,omitempty"`
+	Rows               map[string]RowData     `json:"rows,omitempty"`
+	Merges             []MergeData            `json:"merges,omitempty"`
+	DefaultColWidth    float64                `json:"defaultColWidth,omitempty"`
+	DefaultRowHeight   float64                `json:"defaultRowHeight,omitempty"`
+	Validations        []DataValidation       `json:"validations,omitempty"`
+	ConditionalFormats []conditionalFormatOut `json:"conditionalFormats,omitempty"`
+	Images             []imageOut             `json:"images,omitempty"`
+	AutoFilter         string                 `json:"autoFilter,omitempty"`
+	Tables             []Table                `json:"tables,omitempty"`
+	Protection         *SheetProtection       `json:"protection,omitempty"`
+	sheetViewJSON
+}
+
+// binaryCells holds the cells of a sheet by 0-based row index. Rows are
+// written in numeric order, which the streaming reader requires; plain map
+// keys would be sorted as text ("0", "1", "10", "2").
+type binaryCells map[int]map[string]CellData
+
+func (c binaryCells) MarshalJSON() ([]byte, error) {
+	rows := make([]int, 0, len(c))
+	for r := range c {
+		rows = append(rows, r)
+	}
+	sort.Ints(rows)
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, r := range rows {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		buf.WriteString(`"` + strconv.Itoa(r) + `":`)
+		data, err := json.Marshal(c[r])
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(data)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// binaryStyleKey identifies one entry of the shared styles table.
+type binaryStyleKey struct {
+	style  Style
+	numFmt string
+}
+
+// WriteBinaryBook writes book to outPath as a binary (Synology) .osheet.
+func WriteBinaryBook(book *Book, outPath string) error {
+	if book == nil {
+		return errors.New("nil book")
+	}
+	if err := appfs.EnsureParentDir(outPath); err != nil {
+		return err
+	}
+	f, err := os.Create(outPath)
+	if err != nil {
+		return err
+	}
+	if err := WriteBinary(f, book); err != nil {
+		_ = f.Close()
+		return fmt.Errorf("write %s: %w", outPath, err)
+	}
+	return f.Close()
+}
+
+// WriteBinary encodes book in the layout ParseBinaryBook reads: the gcVer
+// header listing sheets sh_1..sh_N in tab order, followed by one text/sh_N
+// section per sheet. Cells are stored as text (types are re-inferred on read)
+// with formulas under "f"; styles and number formats share one header table.
+func WriteBinary(w io.Writer, book *Book) error {
+	header := binaryHeader{
+		GCVer:        binaryGCVersion,
+		Title:        book.Title,
+		Sheets:       make(map[string]binaryHeaderSheet, len(book.Sheets)),
+		DefinedNames: book.DefinedNames,
+		Protection:   book.Protection,
+		Properties:   toPropertiesOut(book.Properties),
+	}
+	styleIDs := map[binaryStyleKey]int{}
+	sections := make([]binarySectionJSON, len(book.Sheets))
+	for i := range book.Sheets {
+		s := &book.Sheets[i]
+		header.Sheets[binarySheetID(i)] = binaryHeaderSheet{Title: s.Name, Order: i}
+		sections[i] = encodeBinarySheet(s, func(c Cell) int {
+			if c.Style == nil && c.NumFmt == "" {
+				return 0
+			}
+			key := binaryStyleKey{numFmt: c.NumFmt}
+			if c.Style != nil {
+				key.style = *c.Style
+			}
+			id, ok := styleIDs[key]
+			if !ok {
+				id = len(styleIDs) + 1
+				styleIDs[key] = id
+				if header.Styles == nil {
+					header.Styles = make(map[string]map[string]interface{})
+				}
+				header.Styles[strconv.Itoa(id)] = styleToMap(c.Style, c.NumFmt)
+			}
+			return id
+		})
+	}
+
+	var buf bytes.Buffer
+	buf.Write(binaryPrelude)
+	data, err := json.Marshal(header)
+	if err != nil {
+		return fmt.Errorf("failed to marshal header: %w", err)
+	}
+	buf.Write(data)
+	for i := range sections {
+		buf.Write(binarySectionMark)
+		buf.WriteString("text/" + binarySheetID(i))
+		buf.WriteByte(0x00)
+		data, err := json.Marshal(sections[i])
+		if err != nil {
+			return fmt.Errorf("failed to marshal sheet %q: %w", book.Sheets[i].Name, err)
+		}
+		buf.Write(data)
+	}
+	_, err = w.Write(buf.Bytes())
+	return err
+}
+
+func binarySheetID(i int) string { return "sh_" + strconv.Itoa(i+1) }
+
+// encodeBinarySheet converts a sheet into 0-based row and column keys,
+// omitting cells that carry neither a value, a formula, a style nor an
+// annotation.
+func encodeBinarySheet(s *Sheet, styleID func(Cell) int) binarySectionJSON {
+	out := binarySectionJSON{
+		Cells:              make(binaryCells),
+		DefaultColWidth:    s.DefaultColWidth,
+		DefaultRowHeight:   s.DefaultRowHeight,
+		Validations:        s.Validations,
+		ConditionalFormats: conditionalFormatsOut(s.ConditionalFormats),
+		AutoFilter:         s.AutoFilter,
+		Tables:             s.Tables,
+		Protection:         s.Protection,
+		sheetViewJSON:      viewJSON(s.View),
+	}
+	for r, row := range s.Cells {
+		var cells map[string]CellData
+		for c, cell := range row {
+			data := CellData{Value: binaryCellText(cell), Formula: cell.Formula, Style: styleID(cell), Link: cell.Link, Comment: cell.Comment}
+			if data.Value == "" && data.Formula == "" && data.Style == 0 && data.Link == nil && data.Comment == nil {
+				continue
+			}
+			data.Runs = cell.RichText
+			if cells == nil {
+				cells = make(map[string]CellData)
+				out.Cells[r] = cells
+			}
+			cells[strconv.Itoa(c)] = data
+		}
+	}
+	for _, col := range s.Cols {
+		if col.Index <= 0 {
+			continue
+		}
+		if out.Cols == nil {
+			out.Cols = make(map[string]ColData)
+		}
+		out.Cols[strconv.Itoa(col.Index-1)] = ColData{Width: col.Width, Hidden: col.Hidden}
+	}
+	for _, row := range s.Rows {
+		if row.Index <= 0 {
+			continue
+		}
+		if out.Rows == nil {
+			out.Rows = make(map[string]RowData)
+		}
+		out.Rows[strconv.Itoa(row.Index-1)] = RowData{Height: row.Height, Hidden: row.Hidden}
+	}
+	for _, m := range s.Merges {
+		if m.StartRow <= 0 || m.StartCol <= 0 || m.EndRow < m.StartRow || m.EndCol < m.StartCol {
+			continue
+		}
+		out.Merges = append(out.Merges, MergeData{
+			Row:     m.StartRow - 1,
+			Col:     m.StartCol - 1,
+			RowSpan: m.EndRow - m.StartRow + 1,
+			ColSpan: m.EndCol - m.StartCol + 1,
+		})
+	}
+	// The binary container has no room for resources, so images are inline
+	forEachImage(s, 0, func(img *Image, _ string) {
+		out.Images = append(out.Images, imageOut{Cell: img.Cell, Data: base64.StdEncoding.EncodeToString(img.Data), Width: img.Width, Height: img.Height, Alt: img.AltText})
+	})
+	return out
+}
+
+// binaryCellText renders a value as text that inferCell maps back to the
+// same type and value.
+func binaryCellText(c Cell) string {
+	switch c.Type {
+	case ValueString:
+		return c.StringValue
+	case ValueNumber:
+		s := strconv.FormatFloat(c.NumberValue, 'f', -1, 64)
+		if !strings.Contains(s, ".") && len(strings.TrimPrefix(s, "-")) >= 10 {
+			// Long integers would otherwise be read as Unix timestamps
+			s += ".0"
+		}
+		return s
+	case ValueBool:
+		if c.BoolValue {
+			return "TRUE"
+		}
+		return "FALSE"
+	case ValueDateTime:
+		return FormatSerialISO(c.DateEpoch)
+	default:
+		return ""
+	}
+}
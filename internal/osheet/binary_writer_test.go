@@ -0,0 +1,158 @@
+package osheet
+
+import (
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestWriteBinaryBook_RoundTrip(t *testing.T) {
+	bold := &Style{Font: Font{Bold: true}, Fill: "FFFF00"}
+	input := &Style{Protection: CellProtection{Unlocked: true}}
+	in := &Book{Title: "Ledger", DefinedNames: []DefinedName{{Name: "Items", RefersTo: "Main.$A$1:$A$4", Scope: "Main"}}, Protection: &WorkbookProtection{Structure: true}, Sheets: []Sheet{
+		{
+			Name: "Main",
+			Cells: [][]Cell{
+				{
+					{Type: ValueString, StringValue: "Item {a}", Style: bold}, {Type: ValueNumber, NumberValue: 0.25, NumFmt: "0%"},
+					{Type: ValueString, StringValue: "12 pcs", RichText: []RichTextRun{{Text: "12", Font: &Font{Bold: true, Color: "FF0000"}}, {Text: " pcs"}}},
+				},
+				{{Type: ValueNumber, NumberValue: 1234567890, Style: input}, {Type: ValueBool, BoolValue: true}},
+				{{Type: ValueDateTime, DateEpoch: 45293.5}, {Formula: "SUM(A2:B2)", Style: bold}},
+				{{Link: &Hyperlink{Location: "S2!A1"}, Comment: &Comment{Author: "Ann", Text: "see {S2}"}}, {Type: ValueString, StringValue: "x", Link: &Hyperlink{URL: "https://example.com"}}},
+			},
+			Cols:             []ColSpec{{Index: 1, Hidden: true}, {Index: 2, Width: 18}},
+			Rows:             []RowSpec{{Index: 2, Height: 30}, {Index: 3, Hidden: true}},
+			Merges:           []Merge{{StartRow: 4, StartCol: 1, EndRow: 5, EndCol: 2}},
+			DefaultColWidth:  12,
+			DefaultRowHeight: 18,
+			View:             SheetView{FrozenRows: 1, TabColor: "00AA00", Visibility: SheetHidden, Zoom: 75},
+			Validations: []DataValidation{
+				{Range: "A2:A9 C2", Kind: ValidationList, Values: []string{"yes", "no"}, ErrorMessage: "pick one"},
+				{Range: "B2:B9", Kind: ValidationDecimal, Operator: "between", Formula1: "0", Formula2: "1", AllowBlank: true, ErrorStyle: "warning"},
+			},
+			Images: []Image{{Cell: "C3", Data: testPNG(t, 2, 2), Width: 20, Height: 10, AltText: "logo"}},
+			ConditionalFormats: []ConditionalFormat{
+				{Range: "B2:B9", Kind: ConditionalCellValue, Operator: "lessThan", Formula1: "0", Style: bold},
+				{Range: "A2:A9", Kind: ConditionalColorScale, Colors: []string{"FFFFFF", "FF0000"}},
+			},
+			AutoFilter: "A1:B3",
+			Tables:     []Table{{Name: "Stock", Range: "D1:E9", HeaderRow: true, Style: "TableStyleLight1"}, {Range: "G2:G4"}},
+			Protection: &SheetProtection{PasswordHash: "CBEB", Allow: []string{AllowSelectUnlockedCells, AllowSort}},
+		},
+	}}
+	in.Properties = DocProperties{Author: "Ann", Keywords: "q3", Modified: time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC), Custom: []CustomProperty{
+		{Name: "Reviewed", Value: time.Date(2024, 3, 2, 0, 0, 0, 0, time.UTC)}, {Name: "Pages", Value: 3.0},
+	}}
+	// sh_10 must not be confused with sh_1
+	for i := 2; i <= 10; i++ {
+		in.Sheets = append(in.Sheets, Sheet{Name: "S" + strconv.Itoa(i), Cells: [][]Cell{{{Type: ValueNumber, NumberValue: float64(i)}}}})
+	}
+	path := filepath.Join(t.TempDir(), "book.osheet")
+	if err := WriteBinaryBook(in, path); err != nil {
+		t.Fatalf("WriteBinaryBook: %v", err)
+	}
+	if format, err := DetectFormat(path); err != nil || format != FormatBinary {
+		t.Fatalf("DetectFormat = %v, %v; want Binary", format, err)
+	}
+	book, err := ReadBinaryBook(path)
+	if err != nil {
+		t.Fatalf("ReadBinaryBook: %v", err)
+	}
+	if book.Title != "Ledger" || len(book.Sheets) != len(in.Sheets) {
+		t.Fatalf("book = %q with %d sheets", book.Title, len(book.Sheets))
+	}
+	if !reflect.DeepEqual(book.DefinedNames, in.DefinedNames) {
+		t.Errorf("names = %+v, want %+v", book.DefinedNames, in.DefinedNames)
+	}
+	if !reflect.DeepEqual(book.Protection, in.Protection) {
+		t.Errorf("book protection = %+v, want %+v", book.Protection, in.Protection)
+	}
+	if !reflect.DeepEqual(book.Properties, in.Properties) {
+		t.Errorf("properties = %+v, want %+v", book.Properties, in.Properties)
+	}
+	for i := range in.Sheets {
+		if book.Sheets[i].Name != in.Sheets[i].Name {
+			t.Errorf("sheet %d = %q, want %q", i, book.Sheets[i].Name, in.Sheets[i].Name)
+		}
+	}
+	if got := book.Sheets[9].Cells[0][0].NumberValue; got != 10 {
+		t.Errorf("tenth sheet A1 = %v", got)
+	}
+	s := book.Sheets[0]
+	for r, row := range in.Sheets[0].Cells {
+		for c, want := range row {
+			got := s.Cells[r][c]
+			if got.Type != want.Type || got.NumberValue != want.NumberValue || got.BoolValue != want.BoolValue ||
+				got.DateEpoch != want.DateEpoch || got.Formula != want.Formula || got.NumFmt != want.NumFmt ||
+				(want.Type == ValueString && got.StringValue != want.StringValue) {
+				t.Errorf("R%dC%d = %+v, want %+v", r+1, c+1, got, want)
+			}
+			if !reflect.DeepEqual(got.RichText, want.RichText) {
+				t.Errorf("R%dC%d rich text = %+v, want %+v", r+1, c+1, got.RichText, want.RichText)
+			}
+			if !reflect.DeepEqual(got.Link, want.Link) || !reflect.DeepEqual(got.Comment, want.Comment) {
+				t.Errorf("R%dC%d annotations = %+v %+v, want %+v %+v", r+1, c+1, got.Link, got.Comment, want.Link, want.Comment)
+			}
+			if (want.Style == nil) != (got.Style == nil) || want.Style != nil && *got.Style != *want.Style {
+				t.Errorf("R%dC%d style = %+v, want %+v", r+1, c+1, got.Style, want.Style)
+			}
+		}
+	}
+	if !reflect.DeepEqual(s.Cols, in.Sheets[0].Cols) || !reflect.DeepEqual(s.Rows, in.Sheets[0].Rows) || !reflect.DeepEqual(s.Merges, in.Sheets[0].Merges) {
+		t.Errorf("cols = %+v, rows = %+v, merges = %+v", s.Cols, s.Rows, s.Merges)
+	}
+	if s.DefaultColWidth != 12 || s.DefaultRowHeight != 18 {
+		t.Errorf("defaults = %v x %v", s.DefaultColWidth, s.DefaultRowHeight)
+	}
+	if s.View != in.Sheets[0].View {
+		t.Errorf("view = %+v, want %+v", s.View, in.Sheets[0].View)
+	}
+	if !reflect.DeepEqual(s.Validations, in.Sheets[0].Validations) {
+		t.Errorf("validations = %+v, want %+v", s.Validations, in.Sheets[0].Validations)
+	}
+	if !reflect.DeepEqual(s.Images, in.Sheets[0].Images) {
+		t.Errorf("images = %+v, want %+v", s.Images, in.Sheets[0].Images)
+	}
+	if !reflect.DeepEqual(s.ConditionalFormats, in.Sheets[0].ConditionalFormats) {
+		t.Errorf("conditional formats = %+v, want %+v", s.ConditionalFormats, in.Sheets[0].ConditionalFormats)
+	}
+	if s.AutoFilter != in.Sheets[0].AutoFilter || !reflect.DeepEqual(s.Tables, in.Sheets[0].Tables) {
+		t.Errorf("autofilter = %q, tables = %+v", s.AutoFilter, s.Tables)
+	}
+	if !reflect.DeepEqual(s.Protection, in.Sheets[0].Protection) {
+		t.Errorf("protection = %+v, want %+v", s.Protection, in.Sheets[0].Protection)
+	}
+
+	// The streaming reader sees the same rows
+	br, err := OpenBookReader(path)
+	if err != nil {
+		t.Fatalf("OpenBookReader: %v", err)
+	}
+	defer br.Close()
+	if got := br.Book().DefinedNames; !reflect.DeepEqual(got, in.DefinedNames) {
+		t.Errorf("streamed names = %+v, want %+v", got, in.DefinedNames)
+	}
+	if got := br.Book().Sheets[0].Tables; !reflect.DeepEqual(got, in.Sheets[0].Tables) {
+		t.Errorf("streamed tables = %+v, want %+v", got, in.Sheets[0].Tables)
+	}
+	if got := br.Book(); !reflect.DeepEqual(got.Protection, in.Protection) || !reflect.DeepEqual(got.Sheets[0].Protection, in.Sheets[0].Protection) {
+		t.Errorf("streamed protection = %+v, %+v", got.Protection, got.Sheets[0].Protection)
+	}
+	rows, err := br.OpenSheet(0)
+	if err != nil {
+		t.Fatalf("OpenSheet: %v", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		n, cells := rows.Row()
+		if n == 3 && cells[1].Formula != "SUM(A2:B2)" {
+			t.Errorf("streamed B3 = %+v", cells[1])
+		}
+	}
+	if err := rows.Err(); err != nil {
+		t.Fatalf("stream: %v", err)
+	}
+}
@@ -0,0 +1,66 @@
+package osheet
+
+import "strings"
+
+// parseConditionalFormats reads a "conditionalFormats" array. Entries are
+// objects with a range and a type; comparisons use the same keys as
+// validations, formats an inline style and scales a list of colours:
+//
+//	{"range":"B2:B50","type":"cellValue","operator":">","value":100,"style":{"fill":"#FFC7CE"}}
+//	{"range":"C2:C50","type":"formula","formula":"=C2<TODAY()","style":{"font":{"color":"#C00000"}}}
+//	{"range":"D2:D50","type":"colorScale","colors":["#F8696B","#FFEB84","#63BE7B"]}
+//	{"range":"E2:E50","type":"dataBar","color":"#638EC6"}
+//
+// Entries without a range or type are skipped.
+func parseConditionalFormats(raw interface{}) []ConditionalFormat {
+	items, ok := raw.([]interface{})
+	if !ok {
+		return nil
+	}
+	var out []ConditionalFormat
+	for _, item := range items {
+		m, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		cf := ConditionalFormat{
+			Range:      strings.Join(strings.FieldsFunc(toString(firstPresent(m, "range", "ref", "sqref")), isRangeSeparator), " "),
+			Kind:       normalizeConditionalKind(toString(firstPresent(m, "type", "kind"))),
+			StopIfTrue: toBool(m["stopIfTrue"]),
+		}
+		if cf.Range == "" || cf.Kind == "" {
+			continue
+		}
+		cf.Operator, cf.Formula1, cf.Formula2 = parseComparison(m, cf.Kind)
+		if style, ok := parseStyle(firstPresent(m, "style", "format")); ok {
+			cf.Style = style
+		}
+		colors, _ := m["colors"].([]interface{})
+		if len(colors) == 0 {
+			colors = []interface{}{firstPresent(m, "minColor", "color"), m["midColor"], m["maxColor"]}
+		}
+		for _, c := range colors {
+			if color := normalizeColor(toString(c)); color != "" {
+				cf.Colors = append(cf.Colors, color)
+			}
+		}
+		out = append(out, cf)
+	}
+	return out
+}
+
+func normalizeConditionalKind(in string) string {
+	s := strings.TrimSpace(in)
+	switch strings.ToLower(s) {
+	case "cellvalue", "cell", "cellis", "value":
+		return ConditionalCellValue
+	case "formula", "expression", "custom":
+		return ConditionalFormula
+	case "colorscale", "color_scale", "2_color_scale", "3_color_scale", "gradient":
+		return ConditionalColorScale
+	case "databar", "data_bar", "bar":
+		return ConditionalDataBar
+	default:
+		return s
+	}
+}
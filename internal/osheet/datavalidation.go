@@ -0,0 +1,156 @@
+package osheet
+
+import (
+	"strconv"
+	"strings"
+)
+
+// parseValidations reads a "validations" array. Entries are objects with a
+// range and a type, plus either Excel-style operator/formula1/formula2 or
+// min/max bounds, list values and input/error messages:
+//
+//	{"range":"B2:B50","type":"list","values":["Open","Done"]}
+//	{"range":"C2:C50","type":"whole","min":1,"max":10,"errorMessage":"1-10"}
+//	{"range":"D2:D50","type":"date","min":"2024-01-01"}
+//	{"range":"E2:E50","type":"custom","formula":"=E2>D2"}
+//
+// Entries without a range or type are skipped.
+func parseValidations(raw interface{}) []DataValidation {
+	items, ok := raw.([]interface{})
+	if !ok {
+		return nil
+	}
+	var out []DataValidation
+	for _, item := range items {
+		m, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		v := DataValidation{
+			Range:        strings.Join(strings.FieldsFunc(toString(firstPresent(m, "range", "ref", "sqref")), isRangeSeparator), " "),
+			Kind:         normalizeValidationKind(toString(firstPresent(m, "type", "kind"))),
+			AllowBlank:   toBool(m["allowBlank"]),
+			InputTitle:   toString(m["inputTitle"]),
+			InputMessage: toString(m["inputMessage"]),
+			ErrorTitle:   toString(m["errorTitle"]),
+			ErrorMessage: toString(m["errorMessage"]),
+			ErrorStyle:   normalizeErrorStyle(toString(m["errorStyle"])),
+		}
+		if v.Range == "" || v.Kind == "" {
+			continue
+		}
+		if values, ok := m["values"].([]interface{}); ok {
+			for _, value := range values {
+				v.Values = append(v.Values, anyToString(value))
+			}
+		}
+		v.Operator, v.Formula1, v.Formula2 = parseComparison(m, v.Kind)
+		out = append(out, v)
+	}
+	return out
+}
+
+// parseComparison reads an operator with its operands, given either as
+// operator/formula1/formula2 or as min/max bounds. A second operand without
+// an operator means between.
+func parseComparison(m map[string]interface{}, kind string) (operator, formula1, formula2 string) {
+	operator = normalizeOperator(toString(m["operator"]))
+	formula1 = validationOperand(firstPresent(m, "formula1", "formula", "value", "source"), kind)
+	formula2 = validationOperand(m["formula2"], kind)
+	min, max := validationOperand(m["min"], kind), validationOperand(m["max"], kind)
+	switch {
+	case min != "" && max != "":
+		formula1, formula2 = min, max
+		if operator != "notBetween" {
+			operator = "between"
+		}
+	case min != "":
+		formula1, operator = min, "greaterThanOrEqual"
+	case max != "":
+		formula1, operator = max, "lessThanOrEqual"
+	}
+	if operator == "" && formula2 != "" {
+		operator = "between"
+	}
+	return operator, formula1, formula2
+}
+
+func isRangeSeparator(r rune) bool {
+	return r == ' ' || r == ',' || r == ';'
+}
+
+// validationOperand renders an operand as formula text; date kinds turn date
+// strings into serials.
+func validationOperand(v interface{}, kind string) string {
+	switch t := v.(type) {
+	case float64:
+		return strconv.FormatFloat(t, 'f', -1, 64)
+	case string:
+		s := strings.TrimSpace(t)
+		if kind == ValidationDate {
+			if tm, ok := parseDate(s); ok {
+				return strconv.FormatFloat(toExcelSerial(tm), 'f', -1, 64)
+			}
+		}
+		return s
+	}
+	return ""
+}
+
+func normalizeValidationKind(in string) string {
+	s := strings.TrimSpace(in)
+	switch strings.ToLower(s) {
+	case "list", "dropdown", "select":
+		return ValidationList
+	case "whole", "integer", "int":
+		return ValidationWhole
+	case "decimal", "number":
+		return ValidationDecimal
+	case "date":
+		return ValidationDate
+	case "time":
+		return ValidationTime
+	case "textlength", "length":
+		return ValidationTextLength
+	case "custom", "formula":
+		return ValidationCustom
+	default:
+		return s
+	}
+}
+
+// normalizeOperator accepts Excel comparison names in any case and the usual
+// symbols.
+func normalizeOperator(in string) string {
+	switch strings.ToLower(strings.TrimSpace(in)) {
+	case "between":
+		return "between"
+	case "notbetween":
+		return "notBetween"
+	case "equal", "=", "==":
+		return "equal"
+	case "notequal", "<>", "!=":
+		return "notEqual"
+	case "greaterthan", ">":
+		return "greaterThan"
+	case "lessthan", "<":
+		return "lessThan"
+	case "greaterthanorequal", ">=":
+		return "greaterThanOrEqual"
+	case "lessthanorequal", "<=":
+		return "lessThanOrEqual"
+	default:
+		return ""
+	}
+}
+
+func normalizeErrorStyle(in string) string {
+	switch s := strings.ToLower(strings.TrimSpace(in)); s {
+	case "warning", "information":
+		return s
+	case "info":
+		return "information"
+	default:
+		return ""
+	}
+}
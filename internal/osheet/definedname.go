@@ -0,0 +1,55 @@
+package osheet
+
+import (
+	"sort"
+	"strings"
+)
+
+// parseDefinedNames reads named ranges: a list of {name, refersTo|ref|range|formula,
+// scope|sheet, comment} or an object mapping each name to its reference or to
+// such an object. A leading "=" is dropped; entries without a name or a
+// reference are skipped.
+func parseDefinedNames(raw interface{}) []DefinedName {
+	var items []interface{}
+	switch t := raw.(type) {
+	case []interface{}:
+		items = t
+	case map[string]interface{}:
+		keys := make([]string, 0, len(t))
+		for k := range t {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			switch v := t[k].(type) {
+			case string:
+				items = append(items, map[string]interface{}{"name": k, "refersTo": v})
+			case map[string]interface{}:
+				entry := map[string]interface{}{"name": k}
+				for key, value := range v {
+					entry[key] = value
+				}
+				items = append(items, entry)
+			}
+		}
+	}
+	var out []DefinedName
+	for _, item := range items {
+		m, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		dn := DefinedName{
+			Name:     strings.TrimSpace(toString(m["name"])),
+			RefersTo: strings.TrimSpace(toString(firstPresent(m, "refersTo", "ref", "range", "formula"))),
+			Scope:    strings.TrimSpace(toString(firstPresent(m, "scope", "sheet"))),
+			Comment:  toString(m["comment"]),
+		}
+		dn.RefersTo = strings.TrimSpace(strings.TrimPrefix(dn.RefersTo, "="))
+		if dn.Name == "" || dn.RefersTo == "" {
+			continue
+		}
+		out = append(out, dn)
+	}
+	return out
+}
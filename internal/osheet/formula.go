@@ -0,0 +1,546 @@
+package osheet
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+var (
+	a1CellPattern = regexp.MustCompile(`^\$?[A-Za-z]{1,3}\$?[0-9]+$`)
+	a1ColPattern  = regexp.MustCompile(`^\$?[A-Za-z]{1,3}$`)
+	a1RowPattern  = regexp.MustCompile(`^\$?[0-9]+$`)
+	bareSheetName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_.]*$`)
+	r1c1Pattern   = regexp.MustCompile(`^[Rr][0-9]*[Cc][0-9]*$`)
+	// odfPlainSheetName matches sheet names OpenFormula needs not quote.
+	odfPlainSheetName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+)
+
+// formulaTokenKind classifies a formula token.
+type formulaTokenKind int
+
+const (
+	tokOther    formulaTokenKind = iota // operators, parentheses, braces, whitespace
+	tokString                           // "text" literal, quotes included
+	tokNumber                           // numeric literal, decimal point normalised
+	tokError                            // error literal such as #N/A or #REF!
+	tokFunc                             // function name; "(" follows as its own token
+	tokName                             // defined name, boolean or structured reference
+	tokRef                              // cell or range reference, optionally sheet-qualified
+	tokSep                              // argument separator outside arrays
+	tokArraySep                         // column or row separator inside {...}
+)
+
+// formulaToken is one lexical element. For tokRef, sheet is the unquoted
+// sheet name ("" when unqualified) and ref the A1 part without dots.
+type formulaToken struct {
+	kind  formulaTokenKind
+	text  string
+	sheet string
+	ref   string
+}
+
+// FormulaOptions configures TranslateFormula.
+type FormulaOptions struct {
+	// Sheets maps source sheet names to the names written to the output.
+	// References to listed sheets are renamed; "Sheet.A1" dotted references
+	// are only recognised for listed sheets (or with a leading "$").
+	Sheets map[string]string
+}
+
+// TranslateFormula converts a source formula in Synology or Excel syntax,
+// with or without the leading "=", into an Excel formula without "=":
+// semicolon argument separators (and decimal commas) become Excel's,
+// localized function names are mapped to Excel names, and sheet references
+// ("Sheet.A1", "$Sheet.A1", "[Sheet.A1]", "Sheet!A1") become "Sheet!A1"
+// with quoting and renames applied. Functions Excel does not know are kept
+// as written and reported in the returned warnings.
+func TranslateFormula(formula string, opts FormulaOptions) (string, []string) {
+	f := strings.TrimPrefix(strings.TrimSpace(formula), "=")
+	tokens := tokenizeFormula(f, opts.Sheets)
+	var (
+		b        strings.Builder
+		warnings []string
+		odfArray bool
+	)
+	for i, tok := range tokens {
+		switch tok.kind {
+		case tokSep:
+			b.WriteByte(',')
+		case tokArraySep:
+			// OpenFormula arrays use ';' between columns and '|' between rows
+			switch {
+			case odfArray && tok.text == ";":
+				b.WriteByte(',')
+			case tok.text == "|":
+				b.WriteByte(';')
+			default:
+				b.WriteString(tok.text)
+			}
+		case tokFunc:
+			name, ok := excelFunctionName(tok.text)
+			if !ok {
+				warnings = append(warnings, fmt.Sprintf("unknown function %s", tok.text))
+			}
+			b.WriteString(name)
+		case tokName:
+			if c, ok := localizedConstants[strings.ToUpper(tok.text)]; ok {
+				b.WriteString(c)
+			} else {
+				b.WriteString(tok.text)
+			}
+		case tokRef:
+			if tok.sheet != "" {
+				name := tok.sheet
+				if renamed, ok := opts.Sheets[name]; ok {
+					name = renamed
+				}
+				b.WriteString(excelSheetName(name))
+				b.WriteByte('!')
+			}
+			b.WriteString(tok.ref)
+		default:
+			if tok.text == "{" {
+				odfArray = arrayUsesPipes(tokens[i+1:])
+			}
+			b.WriteString(tok.text)
+		}
+	}
+	return b.String(), warnings
+}
+
+// FormulaReferences returns the sheets and the names an Excel formula, as
+// returned by TranslateFormula, refers to, each once in order of use. Names
+// include booleans and anything else the formula spells as a bare word.
+func FormulaReferences(excel string) (sheets, names []string) {
+	seen := make(map[string]bool)
+	for _, tok := range tokenizeFormula(strings.TrimPrefix(strings.TrimSpace(excel), "="), nil) {
+		switch {
+		case tok.kind == tokRef && tok.sheet != "" && !seen["!"+tok.sheet]:
+			seen["!"+tok.sheet] = true
+			sheets = append(sheets, tok.sheet)
+		case tok.kind == tokName && !seen[tok.text]:
+			seen[tok.text] = true
+			names = append(names, tok.text)
+		}
+	}
+	return sheets, names
+}
+
+// OpenFormula converts an Excel formula, as returned by TranslateFormula,
+// into OpenFormula as stored in an ODS table:formula ("of:=SUM([.A1:.B2])"):
+// references are bracketed and dot-qualified ("'My Sheet'!A1" becomes
+// "['My Sheet'.A1]"), argument separators become ';' and inline arrays use
+// ';' between columns and '|' between rows.
+func OpenFormula(excel string) string {
+	f := strings.TrimPrefix(strings.TrimSpace(excel), "=")
+	var b strings.Builder
+	b.WriteString("of:=")
+	for _, tok := range tokenizeFormula(f, nil) {
+		switch tok.kind {
+		case tokSep:
+			b.WriteByte(';')
+		case tokArraySep:
+			if tok.text == "," {
+				b.WriteByte(';')
+			} else {
+				b.WriteByte('|')
+			}
+		case tokRef:
+			b.WriteByte('[')
+			if tok.sheet != "" {
+				b.WriteString(odfSheetName(tok.sheet))
+			}
+			first, second, isRange := strings.Cut(tok.ref, ":")
+			b.WriteString("." + first)
+			if isRange {
+				b.WriteString(":." + second)
+			}
+			b.WriteByte(']')
+		default:
+			b.WriteString(tok.text)
+		}
+	}
+	return b.String()
+}
+
+// odfSheetName quotes a sheet name unless it is a plain identifier.
+func odfSheetName(name string) string {
+	if odfPlainSheetName.MatchString(name) {
+		return name
+	}
+	return "'" + strings.ReplaceAll(name, "'", "''") + "'"
+}
+
+// arrayUsesPipes reports whether the array starting at tokens uses '|' rows.
+func arrayUsesPipes(tokens []formulaToken) bool {
+	for _, tok := range tokens {
+		if tok.kind == tokOther && tok.text == "}" {
+			return false
+		}
+		if tok.kind == tokArraySep && tok.text == "|" {
+			return true
+		}
+	}
+	return false
+}
+
+// excelFunctionName maps a function name to its Excel spelling; ok is false
+// when the name is neither an Excel function nor a known localized one.
+func excelFunctionName(name string) (string, bool) {
+	upper := strings.ToUpper(name)
+	if mapped, ok := localizedFunctions[upper]; ok {
+		return mapped, true
+	}
+	if excelFunctions[upper] {
+		return upper, true
+	}
+	if strings.HasPrefix(upper, "_XLFN.") || strings.HasPrefix(upper, "_XLL.") {
+		return name, true
+	}
+	return name, false
+}
+
+// excelSheetName quotes a sheet name when Excel requires it.
+func excelSheetName(name string) string {
+	if bareSheetName.MatchString(name) && !a1CellPattern.MatchString(name) && !r1c1Pattern.MatchString(name) {
+		return name
+	}
+	return "'" + strings.ReplaceAll(name, "'", "''") + "'"
+}
+
+// usesSemicolons reports whether ';' separates arguments, i.e. appears
+// outside string literals, arrays and brackets.
+func usesSemicolons(f string) bool {
+	depth := 0
+	for i := 0; i < len(f); i++ {
+		switch f[i] {
+		case '"':
+			i = formulaStringEnd(f, i) - 1
+		case '{', '[':
+			depth++
+		case '}', ']':
+			depth--
+		case ';':
+			if depth == 0 {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// tokenizeFormula splits f (without "=") into tokens. sheets lists the known
+// source sheet names for dotted references.
+func tokenizeFormula(f string, sheets map[string]string) []formulaToken {
+	semicolons := usesSemicolons(f)
+	var (
+		tokens  []formulaToken
+		inArray bool
+	)
+	emit := func(kind formulaTokenKind, text string) {
+		tokens = append(tokens, formulaToken{kind: kind, text: text})
+	}
+	for i := 0; i < len(f); {
+		ch := f[i]
+		switch {
+		case ch == '"':
+			end := formulaStringEnd(f, i)
+			emit(tokString, f[i:end])
+			i = end
+		case ch == '#':
+			end := i + 1
+			for end < len(f) && (isFormulaWordChar(f[end]) || f[end] == '/') {
+				end++
+			}
+			if end < len(f) && (f[end] == '!' || f[end] == '?') {
+				end++
+			}
+			emit(tokError, f[i:end])
+			i = end
+		case ch == '{' || ch == '}':
+			inArray = ch == '{'
+			emit(tokOther, string(ch))
+			i++
+		case ch == ',' || ch == ';' || (ch == '|' && inArray):
+			if inArray {
+				emit(tokArraySep, string(ch))
+			} else if ch == ';' || !semicolons {
+				emit(tokSep, string(ch))
+			} else {
+				// A comma in the semicolon dialect is a union or decimal outside a number
+				emit(tokOther, string(ch))
+			}
+			i++
+		case ch == '[':
+			end := bracketEnd(f, i)
+			if tok, ok := parseODFRef(f[i+1 : end-1]); ok {
+				tokens = append(tokens, tok)
+			} else {
+				emit(tokOther, f[i:end])
+			}
+			i = end
+		case ch == '\'' || (ch == '$' && i+1 < len(f) && f[i+1] == '\''):
+			// A leading "$" marks an absolute sheet in OpenFormula; Excel has none
+			start := i
+			if ch == '$' {
+				start++
+			}
+			name, end := formulaQuotedName(f, start)
+			if end < len(f) && (f[end] == '!' || f[end] == '.') {
+				if tok, refEnd, ok := qualifiedRef(f, name, end+1, f[end] == '.', sheets); ok {
+					tokens = append(tokens, tok)
+					i = refEnd
+					continue
+				}
+			}
+			emit(tokOther, f[i:end])
+			i = end
+		case isDigit(ch) || (ch == '.' && i+1 < len(f) && isDigit(f[i+1])):
+			if ref, end, ok := scanA1(f, i); ok && strings.Contains(ref, ":") {
+				// Row range such as 1:3
+				tokens = append(tokens, formulaToken{kind: tokRef, text: f[i:end], ref: ref})
+				i = end
+				continue
+			}
+			text, end := scanNumber(f, i, semicolons)
+			emit(tokNumber, text)
+			i = end
+		case isFormulaWordChar(ch) || ch == '$':
+			end := i
+			for end < len(f) && (isFormulaWordChar(f[end]) || f[end] == '$' || f[end] == '.') {
+				end++
+			}
+			word := f[i:end]
+			switch {
+			case end < len(f) && f[end] == '(':
+				emit(tokFunc, word)
+				i = end
+			case end < len(f) && f[end] == '!':
+				if tok, refEnd, ok := qualifiedRef(f, strings.TrimPrefix(word, "$"), end+1, false, sheets); ok {
+					tokens = append(tokens, tok)
+					i = refEnd
+				} else {
+					emit(tokName, word)
+					i = end
+				}
+			case end < len(f) && f[end] == '[':
+				// Structured reference such as Table1[Column]
+				end = bracketEnd(f, end)
+				emit(tokName, f[i:end])
+				i = end
+			default:
+				if tok, refEnd, ok := plainOrDottedRef(f, i, word, sheets); ok {
+					tokens = append(tokens, tok)
+					i = refEnd
+				} else {
+					emit(tokName, word)
+					i = end
+				}
+			}
+		default:
+			emit(tokOther, string(ch))
+			i++
+		}
+	}
+	return tokens
+}
+
+// plainOrDottedRef reads "A1", "$A$1:B2", "A:C" or a dotted "Sheet.A1[:B2]" at i.
+func plainOrDottedRef(f string, i int, word string, sheets map[string]string) (formulaToken, int, bool) {
+	if dot := strings.LastIndexByte(word, '.'); dot > 0 {
+		sheet := word[:dot]
+		_, known := sheets[strings.TrimPrefix(sheet, "$")]
+		if known || strings.HasPrefix(sheet, "$") {
+			return qualifiedRef(f, strings.TrimPrefix(sheet, "$"), i+dot+1, true, sheets)
+		}
+		return formulaToken{}, i, false
+	}
+	ref, end, ok := scanA1(f, i)
+	if !ok {
+		return formulaToken{}, i, false
+	}
+	return formulaToken{kind: tokRef, text: f[i:end], ref: ref}, end, true
+}
+
+// qualifiedRef reads the A1 part of a reference to sheet starting at i. In
+// dotted (OpenFormula-like) syntax the end of a range may repeat the sheet
+// ("Sheet.A1:Sheet.B2") or start with a dot (".A1:.B2").
+func qualifiedRef(f string, sheet string, i int, dotted bool, sheets map[string]string) (formulaToken, int, bool) {
+	end := i
+	for end < len(f) && isA1Char(f[end]) {
+		end++
+	}
+	first := f[i:end]
+	if !a1CellPattern.MatchString(first) && !a1ColPattern.MatchString(first) && !a1RowPattern.MatchString(first) {
+		return formulaToken{}, i, false
+	}
+	ref := first
+	if end < len(f) && f[end] == ':' {
+		j := end + 1
+		if dotted {
+			// Skip a repeated sheet prefix on the range end
+			k := j
+			for k < len(f) && f[k] != '.' && f[k] != ':' && (isFormulaWordChar(f[k]) || f[k] == '$' || f[k] == '\'' || f[k] == ' ') {
+				k++
+			}
+			if k < len(f) && f[k] == '.' {
+				j = k + 1
+			}
+		}
+		k := j
+		for k < len(f) && isA1Char(f[k]) {
+			k++
+		}
+		if second := f[j:k]; validA1Range(first, second) {
+			ref = first + ":" + second
+			end = k
+		}
+	}
+	if a1ColPattern.MatchString(ref) || a1RowPattern.MatchString(ref) {
+		// A lone column or row is not a reference
+		return formulaToken{}, i, false
+	}
+	return formulaToken{kind: tokRef, text: f[i:end], sheet: sheet, ref: ref}, end, true
+}
+
+// parseODFRef parses the inside of an OpenFormula reference such as ".A1",
+// "Sheet.A1:.B2", "$'My Sheet'.A1" or "['Sheet'.A1:'Sheet'.B2]".
+func parseODFRef(s string) (formulaToken, bool) {
+	s = strings.TrimPrefix(s, "$")
+	sheet := ""
+	rest := s
+	switch {
+	case strings.HasPrefix(s, "'"):
+		name, end := formulaQuotedName(s, 0)
+		if end >= len(s) || s[end] != '.' {
+			return formulaToken{}, false
+		}
+		sheet, rest = name, s[end+1:]
+	case strings.HasPrefix(s, "."):
+		rest = s[1:]
+	default:
+		dot := strings.IndexByte(s, '.')
+		if dot <= 0 {
+			return formulaToken{}, false
+		}
+		sheet, rest = s[:dot], s[dot+1:]
+	}
+	first, second, isRange := strings.Cut(rest, ":")
+	if isRange {
+		// The range end may repeat the sheet: ".B2", "Sheet.B2" or "$Sheet.B2"
+		if dot := strings.LastIndexByte(second, '.'); dot >= 0 {
+			second = second[dot+1:]
+		}
+		if !validA1Range(first, second) {
+			return formulaToken{}, false
+		}
+		return formulaToken{kind: tokRef, sheet: sheet, ref: first + ":" + second}, true
+	}
+	if !a1CellPattern.MatchString(first) {
+		return formulaToken{}, false
+	}
+	return formulaToken{kind: tokRef, sheet: sheet, ref: first}, true
+}
+
+func validA1Range(first, second string) bool {
+	return (a1CellPattern.MatchString(first) && a1CellPattern.MatchString(second)) ||
+		(a1ColPattern.MatchString(first) && a1ColPattern.MatchString(second)) ||
+		(a1RowPattern.MatchString(first) && a1RowPattern.MatchString(second))
+}
+
+// scanA1 reads an unqualified reference or range at i.
+func scanA1(f string, i int) (string, int, bool) {
+	tok, end, ok := qualifiedRef(f, "", i, false, nil)
+	if !ok {
+		return "", i, false
+	}
+	// A word continuing past the reference (e.g. "A1B") is a name
+	if end < len(f) && (isFormulaWordChar(f[end]) || f[end] == '.') {
+		return "", i, false
+	}
+	return tok.ref, end, true
+}
+
+// scanNumber reads a numeric literal; in the semicolon dialect a decimal
+// comma becomes a point.
+func scanNumber(f string, i int, semicolons bool) (string, int) {
+	var b strings.Builder
+	j := i
+	for j < len(f) {
+		ch := f[j]
+		switch {
+		case isDigit(ch) || ch == '.':
+			b.WriteByte(ch)
+		case ch == ',' && semicolons && j+1 < len(f) && isDigit(f[j+1]):
+			b.WriteByte('.')
+		case (ch == 'E' || ch == 'e') && j+1 < len(f) && (isDigit(f[j+1]) || f[j+1] == '+' || f[j+1] == '-'):
+			b.WriteByte(ch)
+			b.WriteByte(f[j+1])
+			j++
+		default:
+			return b.String(), j
+		}
+		j++
+	}
+	return b.String(), j
+}
+
+// formulaStringEnd returns the index after the string literal at i ("" escapes a quote).
+func formulaStringEnd(f string, i int) int {
+	for j := i + 1; j < len(f); j++ {
+		if f[j] == '"' {
+			if j+1 < len(f) && f[j+1] == '"' {
+				j++
+				continue
+			}
+			return j + 1
+		}
+	}
+	return len(f)
+}
+
+// formulaQuotedName reads a quoted sheet name at i and returns it unescaped
+// with the index after the closing quote.
+func formulaQuotedName(f string, i int) (string, int) {
+	var b strings.Builder
+	for j := i + 1; j < len(f); j++ {
+		if f[j] == '\'' {
+			if j+1 < len(f) && f[j+1] == '\'' {
+				b.WriteByte('\'')
+				j++
+				continue
+			}
+			return b.String(), j + 1
+		}
+		b.WriteByte(f[j])
+	}
+	return b.String(), len(f)
+}
+
+// bracketEnd returns the index after the bracket opened at i, allowing nesting.
+func bracketEnd(f string, i int) int {
+	depth := 0
+	for j := i; j < len(f); j++ {
+		switch f[j] {
+		case '[':
+			depth++
+		case ']':
+			depth--
+			if depth == 0 {
+				return j + 1
+			}
+		}
+	}
+	return len(f)
+}
+
+func isFormulaWordChar(ch byte) bool {
+	return ch == '_' || isDigit(ch) || (ch >= 'A' && ch <= 'Z') || (ch >= 'a' && ch <= 'z') || ch >= 0x80
+}
+
+func isA1Char(ch byte) bool {
+	return ch == '$' || isDigit(ch) || (ch >= 'A' && ch <= 'Z') || (ch >= 'a' && ch <= 'z')
+}
+
+func isDigit(ch byte) bool { return ch >= '0' && ch <= '9' }
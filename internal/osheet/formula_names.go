@@ -0,0 +1,112 @@
+package osheet
+
+import "strings"
+
+// excelFunctions is the set of worksheet functions Excel recognises.
+var excelFunctions = func() map[string]bool {
+	names := strings.Fields(`
+ABS ACCRINT ACCRINTM ACOS ACOSH ACOT ACOTH ADDRESS AGGREGATE AMORDEGRC AMORLINC AND ARABIC AREAS
+ASC ASIN ASINH ATAN ATAN2 ATANH AVEDEV AVERAGE AVERAGEA AVERAGEIF AVERAGEIFS BAHTTEXT BASE
+BESSELI BESSELJ BESSELK BESSELY BETA.DIST BETA.INV BETADIST BETAINV BIN2DEC BIN2HEX BIN2OCT
+BINOM.DIST BINOM.DIST.RANGE BINOM.INV BINOMDIST BITAND BITLSHIFT BITOR BITRSHIFT BITXOR BYCOL BYROW
+CEILING CEILING.MATH CEILING.PRECISE CELL CHAR CHIDIST CHIINV CHISQ.DIST CHISQ.DIST.RT CHISQ.INV
+CHISQ.INV.RT CHISQ.TEST CHITEST CHOOSE CHOOSECOLS CHOOSEROWS CLEAN CODE COLUMN COLUMNS COMBIN
+COMBINA COMPLEX CONCAT CONCATENATE CONFIDENCE CONFIDENCE.NORM CONFIDENCE.T CONVERT CORREL COS COSH
+COT COTH COUNT COUNTA COUNTBLANK COUNTIF COUNTIFS COUPDAYBS COUPDAYS COUPDAYSNC COUPNCD COUPNUM
+COUPPCD COVAR COVARIANCE.P COVARIANCE.S CRITBINOM CSC CSCH CUMIPMT CUMPRINC DATE DATEDIF DATEVALUE
+DAVERAGE DAY DAYS DAYS360 DB DCOUNT DCOUNTA DDB DEC2BIN DEC2HEX DEC2OCT DECIMAL DEGREES DELTA DEVSQ
+DGET DISC DMAX DMIN DOLLAR DOLLARDE DOLLARFR DPRODUCT DROP DSTDEV DSTDEVP DSUM DURATION DVAR DVARP
+EDATE EFFECT ENCODEURL EOMONTH ERF ERF.PRECISE ERFC ERFC.PRECISE ERROR.TYPE EVEN EXACT EXP EXPAND
+EXPON.DIST EXPONDIST F.DIST F.DIST.RT F.INV F.INV.RT F.TEST FACT FACTDOUBLE FALSE FDIST FILTER FIND
+FINDB FINV FISHER FISHERINV FIXED FLOOR FLOOR.MATH FLOOR.PRECISE FORECAST FORECAST.LINEAR
+FORMULATEXT FREQUENCY FTEST FV FVSCHEDULE GAMMA GAMMA.DIST GAMMA.INV GAMMADIST GAMMAINV GAMMALN
+GAMMALN.PRECISE GAUSS GCD GEOMEAN GESTEP GETPIVOTDATA GROWTH HARMEAN HEX2BIN HEX2DEC HEX2OCT
+HLOOKUP HOUR HSTACK HYPERLINK HYPGEOM.DIST HYPGEOMDIST IF IFERROR IFNA IFS IMABS IMAGINARY
+IMARGUMENT IMCONJUGATE IMCOS IMCOSH IMCOT IMCSC IMCSCH IMDIV IMEXP IMLN IMLOG10 IMLOG2 IMPOWER
+IMPRODUCT IMREAL IMSEC IMSECH IMSIN IMSINH IMSQRT IMSUB IMSUM IMTAN INDEX INDIRECT INFO INT
+INTERCEPT INTRATE IPMT IRR ISBLANK ISERR ISERROR ISEVEN ISFORMULA ISLOGICAL ISNA ISNONTEXT
+ISNUMBER ISODD ISOWEEKNUM ISPMT ISREF ISTEXT KURT LAMBDA LARGE LCM LEFT LEFTB LEN LENB LET LINEST
+LN LOG LOG10 LOGEST LOGINV LOGNORM.DIST LOGNORM.INV LOGNORMDIST LOOKUP LOWER MAKEARRAY MAP MATCH
+MAX MAXA MAXIFS MDETERM MDURATION MEDIAN MID MIDB MIN MINA MINIFS MINUTE MINVERSE MIRR MMULT MOD
+MODE MODE.MULT MODE.SNGL MONTH MROUND MULTINOMIAL MUNIT N NA NEGBINOM.DIST NEGBINOMDIST
+NETWORKDAYS NETWORKDAYS.INTL NOMINAL NORM.DIST NORM.INV NORM.S.DIST NORM.S.INV NORMDIST NORMINV
+NORMSDIST NORMSINV NOT NOW NPER NPV NUMBERVALUE OCT2BIN OCT2DEC OCT2HEX ODD ODDFPRICE ODDFYIELD
+ODDLPRICE ODDLYIELD OFFSET OR PDURATION PEARSON PERCENTILE PERCENTILE.EXC PERCENTILE.INC
+PERCENTRANK PERCENTRANK.EXC PERCENTRANK.INC PERMUT PERMUTATIONA PHI PI PMT POISSON POISSON.DIST
+POWER PPMT PRICE PRICEDISC PRICEMAT PROB PRODUCT PROPER PV QUARTILE QUARTILE.EXC QUARTILE.INC
+QUOTIENT RADIANS RAND RANDARRAY RANDBETWEEN RANK RANK.AVG RANK.EQ RATE RECEIVED REDUCE REPLACE
+REPLACEB REPT RIGHT RIGHTB ROMAN ROUND ROUNDDOWN ROUNDUP ROW ROWS RRI RSQ SCAN SEARCH SEARCHB SEC
+SECH SECOND SEQUENCE SERIESSUM SHEET SHEETS SIGN SIN SINH SKEW SKEW.P SLN SLOPE SMALL SORT SORTBY
+SQRT SQRTPI STANDARDIZE STDEV STDEV.P STDEV.S STDEVA STDEVP STDEVPA STEYX SUBSTITUTE SUBTOTAL SUM
+SUMIF SUMIFS SUMPRODUCT SUMSQ SUMX2MY2 SUMX2PY2 SUMXMY2 SWITCH SYD T T.DIST T.DIST.2T T.DIST.RT
+T.INV T.INV.2T T.TEST TAKE TAN TANH TBILLEQ TBILLPRICE TBILLYIELD TDIST TEXT TEXTAFTER TEXTBEFORE
+TEXTJOIN TEXTSPLIT TIME TIMEVALUE TINV TOCOL TODAY TOROW TRANSPOSE TREND TRIM TRIMMEAN TRUE TRUNC
+TTEST TYPE UNICHAR UNICODE UNIQUE UPPER VALUE VALUETOTEXT VAR VAR.P VAR.S VARA VARP VARPA VDB
+VLOOKUP VSTACK WEBSERVICE WEEKDAY WEEKNUM WEIBULL WEIBULL.DIST WORKDAY WORKDAY.INTL WRAPCOLS
+WRAPROWS XIRR XLOOKUP XMATCH XNPV XOR YEAR YEARFRAC YIELD YIELDDISC YIELDMAT Z.TEST ZTEST`)
+	set := make(map[string]bool, len(names))
+	for _, n := range names {
+		set[n] = true
+	}
+	return set
+}()
+
+// localizedFunctions maps upper-case localized function names (German,
+// French, Spanish and Russian spreadsheet locales) to their Excel names.
+var localizedFunctions = func() map[string]string {
+	table := map[string][]string{
+		"SUM":         {"SUMME", "SOMME", "SUMA", "СУММ"},
+		"IF":          {"WENN", "SI", "ЕСЛИ"},
+		"AVERAGE":     {"MITTELWERT", "MOYENNE", "PROMEDIO", "СРЗНАЧ"},
+		"COUNT":       {"ANZAHL", "NB", "CONTAR", "СЧЁТ", "СЧЕТ"},
+		"COUNTA":      {"ANZAHL2", "NBVAL", "CONTARA", "СЧЁТЗ", "СЧЕТЗ"},
+		"ROUND":       {"RUNDEN", "ARRONDI", "REDONDEAR", "ОКРУГЛ"},
+		"ROUNDUP":     {"AUFRUNDEN", "ARRONDI.SUP", "REDONDEAR.MAS", "ОКРУГЛВВЕРХ"},
+		"ROUNDDOWN":   {"ABRUNDEN", "ARRONDI.INF", "REDONDEAR.MENOS", "ОКРУГЛВНИЗ"},
+		"VLOOKUP":     {"SVERWEIS", "RECHERCHEV", "BUSCARV", "ВПР"},
+		"HLOOKUP":     {"WVERWEIS", "RECHERCHEH", "BUSCARH", "ГПР"},
+		"INDEX":       {"INDICE", "ИНДЕКС"},
+		"MATCH":       {"VERGLEICH", "EQUIV", "COINCIDIR", "ПОИСКПОЗ"},
+		"AND":         {"UND", "ET", "Y", "И"},
+		"OR":          {"ODER", "OU", "O", "ИЛИ"},
+		"NOT":         {"NICHT", "NON", "NO", "НЕ"},
+		"IFERROR":     {"WENNFEHLER", "SIERREUR", "SI.ERROR", "ЕСЛИОШИБКА"},
+		"SUMIF":       {"SUMMEWENN", "SOMME.SI", "SUMAR.SI", "СУММЕСЛИ"},
+		"SUMIFS":      {"SUMMEWENNS", "SOMME.SI.ENS", "SUMAR.SI.CONJUNTO", "СУММЕСЛИМН"},
+		"COUNTIF":     {"ZÄHLENWENN", "NB.SI", "CONTAR.SI", "СЧЁТЕСЛИ", "СЧЕТЕСЛИ"},
+		"COUNTIFS":    {"ZÄHLENWENNS", "NB.SI.ENS", "CONTAR.SI.CONJUNTO", "СЧЁТЕСЛИМН", "СЧЕТЕСЛИМН"},
+		"PRODUCT":     {"PRODUKT", "PRODUIT", "PRODUCTO", "ПРОИЗВЕД"},
+		"TODAY":       {"HEUTE", "AUJOURDHUI", "HOY", "СЕГОДНЯ"},
+		"NOW":         {"JETZT", "MAINTENANT", "AHORA", "ТДАТА"},
+		"DATE":        {"DATUM", "FECHA", "ДАТА"},
+		"YEAR":        {"JAHR", "ANNEE", "AÑO", "ГОД"},
+		"MONTH":       {"MONAT", "MOIS", "MES", "МЕСЯЦ"},
+		"DAY":         {"TAG", "JOUR", "DIA", "ДЕНЬ"},
+		"CONCATENATE": {"VERKETTEN", "CONCATENER", "CONCATENAR", "СЦЕПИТЬ"},
+		"LEFT":        {"LINKS", "GAUCHE", "IZQUIERDA", "ЛЕВСИМВ"},
+		"RIGHT":       {"RECHTS", "DROITE", "DERECHA", "ПРАВСИМВ"},
+		"MID":         {"TEIL", "STXT", "EXTRAE", "ПСТР"},
+		"LEN":         {"LÄNGE", "NBCAR", "LARGO", "ДЛСТР"},
+		"UPPER":       {"GROSS", "MAJUSCULE", "MAYUSC", "ПРОПИСН"},
+		"LOWER":       {"KLEIN", "MINUSCULE", "MINUSC", "СТРОЧН"},
+		"TRIM":        {"GLÄTTEN", "SUPPRESPACE", "ESPACIOS", "СЖПРОБЕЛЫ"},
+		"TEXT":        {"TEXTE", "TEXTO", "ТЕКСТ"},
+		"VALUE":       {"WERT", "CNUM", "VALOR", "ЗНАЧЕН"},
+		"SUBSTITUTE":  {"WECHSELN", "SUBSTITUE", "SUSTITUIR", "ПОДСТАВИТЬ"},
+		"MIN":         {"МИН"},
+		"MAX":         {"МАКС"},
+	}
+	m := make(map[string]string)
+	for excel, names := range table {
+		for _, n := range names {
+			m[n] = excel
+		}
+	}
+	return m
+}()
+
+// localizedConstants maps localized boolean constants to Excel's.
+var localizedConstants = map[string]string{
+	"WAHR": "TRUE", "VRAI": "TRUE", "VERDADERO": "TRUE", "ИСТИНА": "TRUE",
+	"FALSCH": "FALSE", "FAUX": "FALSE", "FALSO": "FALSE", "ЛОЖЬ": "FALSE",
+}
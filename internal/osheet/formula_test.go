@@ -0,0 +1,76 @@
+package osheet
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestTranslateFormula(t *testing.T) {
+	opts := FormulaOptions{Sheets: map[string]string{
+		"Data":      "Data",
+		"My Sheet":  "My Sheet",
+		"Q1/Q2":     "Q1_Q2",
+		"Bad:Name*": "Bad_Name_",
+		"Ann's":     "Ann's",
+		"Sheet2":    "Sheet2",
+		"Отчёт":     "Отчёт",
+	}}
+	cases := []struct {
+		in, want string
+	}{
+		{"=SUM(A1:B2)", "SUM(A1:B2)"},
+		{"sum(a1;b1)", "SUM(a1,b1)"},
+		{"=SUMME(A1;2,5)", "SUM(A1,2.5)"},
+		{"=WENN(A1>0;WAHR;FALSCH)", "IF(A1>0,TRUE,FALSE)"},
+		{"=СУММ(A1:A3)", "SUM(A1:A3)"},
+		{"=SOMME.SI(A1:A3;\">0\")", "SUMIF(A1:A3,\">0\")"},
+		{`=IF(A1="a;b";"x,y";1)`, `IF(A1="a;b","x,y",1)`},
+		{"=Data!A1*2", "Data!A1*2"},
+		{"=Data.A1+$Data.$B$2", "Data!A1+Data!$B$2"},
+		{"=SUM(Data.A1:Data.B3)", "SUM(Data!A1:B3)"},
+		{"=SUM([.A1:.B2])", "SUM(A1:B2)"},
+		{"=[$'My Sheet'.A1]", "'My Sheet'!A1"},
+		{"=$'My Sheet'.A1+1", "'My Sheet'!A1+1"},
+		{"='My Sheet'!A1:B2", "'My Sheet'!A1:B2"},
+		{"='Q1/Q2'!C3", "Q1_Q2!C3"},
+		{"='Bad:Name*'.A1", "Bad_Name_!A1"},
+		{"='Ann''s'!A1", "'Ann''s'!A1"},
+		{"=Отчёт!A1", "'Отчёт'!A1"},
+		{"=SUM(Sheet2!A:A)", "SUM(Sheet2!A:A)"},
+		{"=SUM(1:3)", "SUM(1:3)"},
+		{"={1;2|3;4}", "{1,2;3,4}"},
+		{"={1,2;3,4}", "{1,2;3,4}"},
+		{"=IFERROR(1/0;#DIV/0!)", "IFERROR(1/0,#DIV/0!)"},
+		{"=STDEV.S(A1:A3)", "STDEV.S(A1:A3)"},
+		{"=_xlfn.XLOOKUP(1,A:A,B:B)", "_xlfn.XLOOKUP(1,A:A,B:B)"},
+		{"=SUM(Table1[Amount])", "SUM(Table1[Amount])"},
+		{"=1.5E+3*Rate", "1.5E+3*Rate"},
+	}
+	for _, tc := range cases {
+		got, warnings := TranslateFormula(tc.in, opts)
+		if got != tc.want || len(warnings) != 0 {
+			t.Errorf("TranslateFormula(%q) = %q %v, want %q", tc.in, got, warnings, tc.want)
+		}
+	}
+}
+
+func TestTranslateFormula_UnknownFunctions(t *testing.T) {
+	got, warnings := TranslateFormula("=SUM(SYNO_LOOKUP(A1);MYFUNC())", FormulaOptions{})
+	if got != "SUM(SYNO_LOOKUP(A1),MYFUNC())" {
+		t.Errorf("formula = %q", got)
+	}
+	want := []string{"unknown function SYNO_LOOKUP", "unknown function MYFUNC"}
+	if !reflect.DeepEqual(warnings, want) {
+		t.Errorf("warnings = %v, want %v", warnings, want)
+	}
+}
+
+func TestFormulaReferences(t *testing.T) {
+	sheets, names := FormulaReferences("SUM('My Sheet'!A1:B2,Data!C3,'My Sheet'!D4)*Rate+Rate")
+	if !reflect.DeepEqual(sheets, []string{"My Sheet", "Data"}) || !reflect.DeepEqual(names, []string{"Rate"}) {
+		t.Errorf("FormulaReferences = %q %q", sheets, names)
+	}
+	if sheets, names := FormulaReferences("A1+1"); sheets != nil || names != nil {
+		t.Errorf("unqualified refs = %q %q", sheets, names)
+	}
+}
@@ -0,0 +1,172 @@
+package osheet
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"image"
+	// Decoders for CheckImage and for excelize, which sizes pictures with
+	// image.DecodeConfig.
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+	"io"
+	"net/http"
+	"path"
+	"strings"
+)
+
+// MaxImageSize caps the size of a single embedded image in bytes.
+const MaxImageSize = 10 << 20
+
+// maxImagePixels caps the pixel count of an image, so a small file cannot
+// claim a huge canvas.
+const maxImagePixels = 100_000_000
+
+// imageExtensions maps the image types writers embed to file extensions.
+var imageExtensions = map[string]string{
+	"image/png":  ".png",
+	"image/jpeg": ".jpg",
+	"image/gif":  ".gif",
+}
+
+// CheckImage verifies that data is a PNG, JPEG or GIF of at most
+// MaxImageSize bytes. The type is sniffed from the content, never taken from
+// a file name, and the header must decode. It returns the file extension and
+// the size in pixels.
+func CheckImage(data []byte) (ext string, width, height int, err error) {
+	if len(data) == 0 {
+		return "", 0, 0, fmt.Errorf("no image data")
+	}
+	if len(data) > MaxImageSize {
+		return "", 0, 0, fmt.Errorf("larger than %d MiB", MaxImageSize>>20)
+	}
+	mime := http.DetectContentType(data)
+	ext, ok := imageExtensions[mime]
+	if !ok {
+		return "", 0, 0, fmt.Errorf("unsupported content type %s", mime)
+	}
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return "", 0, 0, fmt.Errorf("invalid %s: %w", mime, err)
+	}
+	if cfg.Width <= 0 || cfg.Height <= 0 || cfg.Width*cfg.Height > maxImagePixels {
+		return "", 0, 0, fmt.Errorf("unreasonable size %dx%d", cfg.Width, cfg.Height)
+	}
+	return ext, cfg.Width, cfg.Height, nil
+}
+
+// Check runs CheckImage on the image data, first reporting inline data
+// that could not be decoded.
+func (img *Image) Check() (ext string, width, height int, err error) {
+	if img.dataErr != nil {
+		return "", 0, 0, img.dataErr
+	}
+	return CheckImage(img.Data)
+}
+
+// parseImages reads an "images" array. Each entry anchors one picture at a
+// cell and takes its bytes from an archive entry or inline base64 data:
+//
+//	{"cell":"B2","src":"images/p1.png","width":64,"height":64,"alt":"Blue mug"}
+//	{"cell":"C2","data":"data:image/png;base64,iVBORw0KGgo..."}
+//
+// Entries without a valid cell or any source are skipped; undecodable inline
+// data is kept for Check to report.
+func parseImages(raw interface{}) []Image {
+	items, ok := raw.([]interface{})
+	if !ok {
+		return nil
+	}
+	var out []Image
+	for _, item := range items {
+		m, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		img := Image{
+			Cell:    normalizeCellRef(toString(firstPresent(m, "cell", "anchor", "ref"))),
+			Source:  strings.TrimSpace(toString(firstPresent(m, "src", "path", "file"))),
+			Width:   max(toInt(m["width"]), 0),
+			Height:  max(toInt(m["height"]), 0),
+			AltText: toString(firstPresent(m, "alt", "altText", "title")),
+		}
+		if data := toString(m["data"]); data != "" {
+			if i := strings.Index(data, ","); strings.HasPrefix(data, "data:") && i >= 0 {
+				data = data[i+1:]
+			}
+			decoded, err := base64.StdEncoding.DecodeString(data)
+			if err != nil {
+				// Kept so writers report it like other unusable images
+				img.dataErr = fmt.Errorf("invalid base64 data: %w", err)
+			} else {
+				img.Data = decoded
+			}
+		}
+		if img.Cell == "" || (img.Source == "" && img.Data == nil && img.dataErr == nil) {
+			continue
+		}
+		out = append(out, img)
+	}
+	return out
+}
+
+// normalizeCellRef returns ref as an upper-case A1 reference without "$",
+// or "" when it is not one.
+func normalizeCellRef(ref string) string {
+	s := strings.ToUpper(strings.ReplaceAll(strings.TrimSpace(ref), "$", ""))
+	letters := 0
+	for letters < len(s) && s[letters] >= 'A' && s[letters] <= 'Z' {
+		letters++
+	}
+	digits := s[letters:]
+	if letters == 0 || letters > 3 || digits == "" || digits[0] == '0' || len(digits) > 7 {
+		return ""
+	}
+	for i := 0; i < len(digits); i++ {
+		if digits[i] < '0' || digits[i] > '9' {
+			return ""
+		}
+	}
+	return s
+}
+
+// loadImages reads the archive entries that sheet images point to. Paths are
+// resolved from the archive root, then next to doc. At most MaxImageSize+1
+// bytes are read, so oversized entries are caught by CheckImage without
+// being held in full; missing entries leave Data empty.
+func loadImages(files []*zip.File, doc *zip.File, sheets []Sheet) {
+	byName := make(map[string]*zip.File, len(files))
+	for _, f := range files {
+		if isRegularFile(f) {
+			byName[f.Name] = f
+		}
+	}
+	dir := ""
+	if doc != nil {
+		dir = path.Dir(doc.Name)
+	}
+	for i := range sheets {
+		for j := range sheets[i].Images {
+			img := &sheets[i].Images[j]
+			if img.Data != nil || img.Source == "" {
+				continue
+			}
+			name := path.Clean(strings.TrimPrefix(img.Source, "/"))
+			f := byName[name]
+			if f == nil {
+				f = byName[path.Join(dir, name)]
+			}
+			if f == nil {
+				continue
+			}
+			rc, err := f.Open()
+			if err != nil {
+				continue
+			}
+			img.Data, _ = io.ReadAll(io.LimitReader(rc, MaxImageSize+1))
+			_ = rc.Close()
+		}
+	}
+}
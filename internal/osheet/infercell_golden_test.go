@@ -18,8 +18,64 @@ func TestInferCell_GoldenNumbersAndDates(t *testing.T) {
 		{"1704067200000", ValueDateTime},
 	}
 	for _, tc := range cases {
-		if got := inferCell(tc.in); got.Type != tc.want {
+		if got := inferCell(tc.in, ReadOptions{}); got.Type != tc.want {
 			t.Fatalf("inferCell(%q) = %v, want %v", tc.in, got.Type, tc.want)
 		}
 	}
 }
+
+func TestInferCell_NumberFormats(t *testing.T) {
+	cases := []struct {
+		in     string
+		value  float64
+		numFmt string
+	}{
+		{"12", 12, ""},
+		{"3.5", 3.5, ""},
+		{"12%", 0.12, "0%"},
+		{"12.5%", 0.125, "0.0%"},
+		{"$1,200.50", 1200.5, `"$"#,##0.00`},
+		// A lone comma is a decimal separator by default
+		{"1,234", 1.234, ""},
+		{"3,125", 3.125, ""},
+		{"1,5", 1.5, ""},
+		{"1 234 567", 1234567, "#,##0"},
+		{"1\u00A0234", 1234, "#,##0"},
+		{"(300)", -300, "0;(0)"},
+		{"(1 234,50)", -1234.5, "#,##0.00;(#,##0.00)"},
+		{"1.234,56 €", 1234.56, `#,##0.00 "€"`},
+		{"-$5", -5, `"$"0`},
+	}
+	for _, tc := range cases {
+		got := inferCell(tc.in, ReadOptions{})
+		if got.Type != ValueNumber || got.NumberValue != tc.value || got.NumFmt != tc.numFmt {
+			t.Fatalf("inferCell(%q) = %v %v %q, want number %v %q", tc.in, got.Type, got.NumberValue, got.NumFmt, tc.value, tc.numFmt)
+		}
+	}
+}
+
+func TestInferCell_NumberLocaleEN(t *testing.T) {
+	opts := ReadOptions{NumberLocale: NumberLocaleEN}
+	cases := []struct {
+		in     string
+		value  float64
+		numFmt string
+	}{
+		{"$1,200", 1200, `"$"#,##0`},
+		{"1,234", 1234, "#,##0"},
+		{"12,345,678", 12345678, "#,##0"},
+		{"$1,234.50", 1234.5, `"$"#,##0.00`},
+		// Commas not followed by three-digit groups stay decimal
+		{"1,5", 1.5, ""},
+		{"0,125", 0.125, ""},
+	}
+	for _, tc := range cases {
+		got := inferCell(tc.in, opts)
+		if got.Type != ValueNumber || got.NumberValue != tc.value || got.NumFmt != tc.numFmt {
+			t.Fatalf("inferCell(%q) = %v %v %q, want number %v %q", tc.in, got.Type, got.NumberValue, got.NumFmt, tc.value, tc.numFmt)
+		}
+	}
+	if err := (ReadOptions{NumberLocale: "fr"}).Validate(); err == nil {
+		t.Errorf("unknown number locale accepted")
+	}
+}
@@ -1,9 +1,68 @@
 package osheet
 
+import "time"
+
 // Book represents a parsed Osheet workbook (minimal model for MVP).
 type Book struct {
+	// Title is the document title; empty when the source has none.
 	Title  string
 	Sheets []Sheet
+	// DefinedNames are the named ranges and formulas of the workbook.
+	DefinedNames []DefinedName
+	// Protection locks the sheet structure of the workbook; nil for none.
+	Protection *WorkbookProtection
+	// Properties hold the document metadata besides the title.
+	Properties DocProperties
+}
+
+// DocProperties describe the document. Empty fields and zero times are
+// unset.
+type DocProperties struct {
+	Author      string
+	Description string
+	// Keywords is a single string; sources listing several keywords are
+	// joined with "; ".
+	Keywords string
+	Created  time.Time
+	Modified time.Time
+	// Custom are user-defined properties in source order.
+	Custom []CustomProperty
+}
+
+// IsZero reports whether no property is set.
+func (p DocProperties) IsZero() bool {
+	return p.Author == "" && p.Description == "" && p.Keywords == "" &&
+		p.Created.IsZero() && p.Modified.IsZero() && len(p.Custom) == 0
+}
+
+// CustomProperty is a named document value. Value is a string, float64, bool
+// or time.Time.
+type CustomProperty struct {
+	Name  string
+	Value interface{}
+}
+
+// WorkbookProtection keeps sheets from being added, removed, renamed or
+// moved (Structure) and the workbook window from being resized (Windows).
+type WorkbookProtection struct {
+	Structure bool `json:"structure"`
+	Windows   bool `json:"windows,omitempty"`
+	// PasswordHash is the legacy 16-bit Excel hash as 4 hex digits, empty
+	// for none; see HashPassword.
+	PasswordHash string `json:"passwordHash,omitempty"`
+}
+
+// DefinedName is a name formulas can use in place of a range, a constant or a
+// formula (e.g. SUM(Revenue)).
+type DefinedName struct {
+	Name string `json:"name"`
+	// RefersTo is the target in source formula syntax without the leading
+	// "=", e.g. "Sales.$B$2:$B$13".
+	RefersTo string `json:"refersTo"`
+	// Scope is the source name of the sheet the name is local to; empty
+	// means the whole workbook.
+	Scope   string `json:"scope,omitempty"`
+	Comment string `json:"comment,omitempty"`
 }
 
 // Sheet represents a single sheet with cell values.
@@ -15,8 +74,77 @@ type Sheet struct {
 	Merges []Merge
 	Cols   []ColSpec
 	Rows   []RowSpec
+	// DefaultColWidth and DefaultRowHeight size columns and rows without a
+	// spec; zero keeps the writer's default.
+	DefaultColWidth  float64
+	DefaultRowHeight float64
+	// View holds frozen panes, tab colour, visibility and zoom.
+	View SheetView
+	// Validations restrict what may be entered into ranges of the sheet.
+	Validations []DataValidation
+	// ConditionalFormats highlight cells by value, in priority order.
+	ConditionalFormats []ConditionalFormat
+	// Images are pictures placed over the cells.
+	Images []Image
+	// AutoFilter is the A1 range with filter buttons on its first row, empty
+	// for none. Tables carry their own filter.
+	AutoFilter string
+	// Tables are structured ranges with a header row and a table style.
+	Tables []Table
+	// Protection locks the sheet's locked cells (see CellProtection); nil
+	// leaves the sheet editable.
+	Protection *SheetProtection
 }
 
+// SheetProtection lists what users may still do on a protected sheet.
+type SheetProtection struct {
+	// PasswordHash is the legacy 16-bit Excel hash as 4 hex digits, empty
+	// for none; see HashPassword.
+	PasswordHash string `json:"passwordHash,omitempty"`
+	// Allow holds Allow* actions; unknown actions are kept so writers can
+	// report them. Empty allows nothing, not even selecting cells.
+	Allow []string `json:"allow"`
+}
+
+// Actions a protected sheet may allow.
+const (
+	AllowSelectLockedCells   = "selectLockedCells"
+	AllowSelectUnlockedCells = "selectUnlockedCells"
+	AllowFormatCells         = "formatCells"
+	AllowFormatColumns       = "formatColumns"
+	AllowFormatRows          = "formatRows"
+	AllowInsertColumns       = "insertColumns"
+	AllowInsertRows          = "insertRows"
+	AllowInsertHyperlinks    = "insertHyperlinks"
+	AllowDeleteColumns       = "deleteColumns"
+	AllowDeleteRows          = "deleteRows"
+	AllowSort                = "sort"
+	AllowAutoFilter          = "autoFilter"
+	AllowPivotTables         = "pivotTables"
+	AllowEditObjects         = "editObjects"
+	AllowEditScenarios       = "editScenarios"
+)
+
+// SheetView describes how a sheet is presented. The zero value is a visible
+// sheet without frozen panes or tab colour at the default zoom.
+type SheetView struct {
+	// FrozenRows and FrozenCols count the rows at the top and the columns
+	// on the left that stay in place while scrolling.
+	FrozenRows int
+	FrozenCols int
+	TabColor   string // RRGGBB, empty for none
+	// Visibility is SheetHidden or SheetVeryHidden; empty means visible.
+	Visibility string
+	Zoom       float64 // percent (10-400), zero for 100
+}
+
+// Sheet visibility states. A very hidden sheet cannot be unhidden from the
+// spreadsheet UI.
+const (
+	SheetHidden     = "hidden"
+	SheetVeryHidden = "veryHidden"
+)
+
 // Cell represents a single cell value.
 type Cell struct {
 	StringValue string
@@ -27,6 +155,92 @@ type Cell struct {
 	// and will be written as an Excel formula (e.g. "SUM(A1:B2)").
 	Formula string
 	Type    ValueType
+	// NumFmt is an Excel number format code (e.g. "0.00%", "#,##0"),
+	// empty for General. Read from the source or derived during inference.
+	NumFmt string
+	// Style is optional visual formatting; nil means default formatting.
+	// Cells sharing a source style table entry share the same pointer.
+	Style *Style
+	// Link and Comment are optional cell annotations.
+	Link    *Hyperlink
+	Comment *Comment
+	// RichText, when set, formats parts of a text cell differently; the run
+	// texts concatenate to StringValue, which targets without inline
+	// formatting write instead.
+	RichText []RichTextRun
+}
+
+// RichTextRun is a piece of cell text with its own font. A nil Font keeps the
+// cell's font; empty family, size and color fall back to it.
+type RichTextRun struct {
+	Text string `json:"text"`
+	Font *Font  `json:"font,omitempty"`
+}
+
+// Hyperlink points either outside the workbook (URL: http, mailto, file...)
+// or to a location inside it (e.g. "Sheet2!A1"); one of the two is set.
+type Hyperlink struct {
+	URL      string `json:"url,omitempty"`
+	Location string `json:"location,omitempty"`
+	Tooltip  string `json:"tooltip,omitempty"`
+}
+
+// Comment is a note attached to a cell.
+type Comment struct {
+	Author string `json:"author,omitempty"`
+	Text   string `json:"text"`
+}
+
+// Style describes visual cell formatting. It is comparable so writers can
+// deduplicate identical styles.
+type Style struct {
+	Font      Font
+	Fill      string // background color as RRGGBB, empty for no fill
+	Border    Border
+	Alignment Alignment
+	// Protection only takes effect on protected sheets.
+	Protection CellProtection
+}
+
+// CellProtection is the cell's behaviour on a protected sheet. The zero value
+// is the spreadsheet default: locked, formula visible.
+type CellProtection struct {
+	Unlocked    bool
+	HideFormula bool
+}
+
+// Font describes text appearance. Color is RRGGBB, empty for default.
+type Font struct {
+	Family    string  `json:"family,omitempty"`
+	Size      float64 `json:"size,omitempty"`
+	Bold      bool    `json:"bold,omitempty"`
+	Italic    bool    `json:"italic,omitempty"`
+	Underline bool    `json:"underline,omitempty"`
+	Color     string  `json:"color,omitempty"`
+}
+
+// Border describes the four cell edges.
+type Border struct {
+	Left   BorderSide
+	Right  BorderSide
+	Top    BorderSide
+	Bottom BorderSide
+}
+
+// BorderSide describes one edge. Style is one of thin, medium, thick, dashed,
+// dotted, double, hair; empty means no border.
+type BorderSide struct {
+	Style string
+	Color string
+}
+
+// Alignment describes cell content placement.
+// Horizontal: left|center|right|justify|fill; Vertical: top|center|bottom.
+type Alignment struct {
+	Horizontal string
+	Vertical   string
+	Wrap       bool
+	Indent     int
 }
 
 // ValueType enumerates supported cell value kinds.
@@ -48,14 +262,114 @@ type Merge struct {
 	EndCol   int
 }
 
-// ColSpec describes explicit column width.
+// ColSpec describes explicit column width and visibility; a zero Width keeps the default.
 type ColSpec struct {
-	Index int
-	Width float64
+	Index  int
+	Width  float64
+	Hidden bool
 }
 
-// RowSpec describes explicit row height.
+// RowSpec describes explicit row height and visibility; a zero Height keeps the default.
 type RowSpec struct {
 	Index  int
 	Height float64
+	Hidden bool
+}
+
+// DataValidation restricts the values allowed in Range. Operands use the
+// source formula syntax; writers translate them like cell formulas.
+type DataValidation struct {
+	// Range is one or more A1 ranges separated by spaces, e.g. "B2:B100 D2".
+	Range string `json:"range"`
+	// Kind is one of the Validation* kinds. Unknown kinds are kept so
+	// writers can report them.
+	Kind string `json:"type"`
+	// Operator compares against Formula1 (and Formula2 for between and
+	// notBetween): between, notBetween, equal, notEqual, greaterThan,
+	// lessThan, greaterThanOrEqual or lessThanOrEqual.
+	Operator string `json:"operator,omitempty"`
+	// Values are the entries of an inline list; a list without values
+	// takes them from the range in Formula1.
+	Values []string `json:"values,omitempty"`
+	// Formula1 and Formula2 are bounds (numbers, date serials or formulas),
+	// a list source or a custom formula.
+	Formula1     string `json:"formula1,omitempty"`
+	Formula2     string `json:"formula2,omitempty"`
+	AllowBlank   bool   `json:"allowBlank,omitempty"`
+	InputTitle   string `json:"inputTitle,omitempty"`
+	InputMessage string `json:"inputMessage,omitempty"`
+	ErrorTitle   string `json:"errorTitle,omitempty"`
+	ErrorMessage string `json:"errorMessage,omitempty"`
+	// ErrorStyle is stop (default), warning or information.
+	ErrorStyle string `json:"errorStyle,omitempty"`
+}
+
+// Data validation kinds.
+const (
+	ValidationList       = "list"
+	ValidationWhole      = "whole"
+	ValidationDecimal    = "decimal"
+	ValidationDate       = "date"
+	ValidationTime       = "time"
+	ValidationTextLength = "textLength"
+	ValidationCustom     = "custom"
+)
+
+// ConditionalFormat highlights the cells of Range that meet a rule.
+type ConditionalFormat struct {
+	// Range is one or more A1 ranges separated by spaces.
+	Range string `json:"range"`
+	// Kind is one of the Conditional* kinds. Unknown kinds are kept so
+	// writers can report them.
+	Kind string `json:"type"`
+	// Operator, Formula1 and Formula2 compare the cell value in cellValue
+	// rules, as in DataValidation; formula rules hold their condition in
+	// Formula1, written for the top-left cell of Range.
+	Operator string `json:"operator,omitempty"`
+	Formula1 string `json:"formula1,omitempty"`
+	Formula2 string `json:"formula2,omitempty"`
+	// Style formats matching cells of cellValue and formula rules; font,
+	// fill and border are used.
+	Style *Style `json:"-"`
+	// Colors are the RRGGBB stops of a color scale (low, optional middle,
+	// high) or the bar colour of a data bar.
+	Colors     []string `json:"colors,omitempty"`
+	StopIfTrue bool     `json:"stopIfTrue,omitempty"`
+}
+
+// Conditional format kinds.
+const (
+	ConditionalCellValue  = "cellValue"
+	ConditionalFormula    = "formula"
+	ConditionalColorScale = "colorScale"
+	ConditionalDataBar    = "dataBar"
+)
+
+// Image is a picture anchored at the top-left corner of a cell.
+type Image struct {
+	Cell string // anchor cell, e.g. "B2"
+	// Source is the archive path the image was read from, if any.
+	Source string
+	// Data is the raw image file, capped at MaxImageSize+1 bytes when read;
+	// CheckImage tells whether writers may embed it.
+	Data []byte
+	// Width and Height are the display size in pixels; zero keeps the
+	// image's own size (or its aspect ratio when the other is set).
+	Width   int
+	Height  int
+	AltText string
+	// dataErr tells why inline data could not be decoded; Check reports it.
+	dataErr error
+}
+
+// Table is a structured range of the sheet. With a header row the first row
+// of Range names the columns and gets filter buttons.
+type Table struct {
+	// Name is unique in the workbook; empty lets writers pick one.
+	Name      string `json:"name,omitempty"`
+	Range     string `json:"range"` // e.g. "A1:D20"
+	HeaderRow bool   `json:"headerRow"`
+	// Style is a built-in table style such as "TableStyleMedium2"; empty
+	// keeps the writer's default.
+	Style string `json:"style,omitempty"`
 }
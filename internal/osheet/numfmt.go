@@ -0,0 +1,100 @@
+package osheet
+
+import (
+	"strings"
+)
+
+// currencySymbols lists currency markers recognised in numeric strings.
+// Multi-character markers come before their single-character prefixes.
+var currencySymbols = []string{"$", "€", "£", "₽", "¥", "₴", "₺", "₹", "zł", "PLN", "USD", "EUR", "RUB"}
+
+// inferNumFmt derives an Excel number format code from the textual form of a
+// number accepted by parseNumber. It returns "" when General displays the value
+// the same way (no percent, currency, grouping or parentheses).
+func inferNumFmt(in string, opts ReadOptions) string {
+	s := strings.TrimSpace(in)
+	percent := false
+	if strings.HasSuffix(s, "%") {
+		percent = true
+		s = strings.TrimSpace(strings.TrimSuffix(s, "%"))
+	}
+	parens := false
+	if strings.HasPrefix(s, "(") && strings.HasSuffix(s, ")") {
+		parens = true
+		s = strings.TrimSpace(strings.TrimPrefix(strings.TrimSuffix(s, ")"), "("))
+	}
+	s = strings.TrimLeft(s, "+-")
+	symbol, prefix, spaced := "", true, false
+	for _, cur := range currencySymbols {
+		if strings.HasPrefix(s, cur) {
+			symbol = cur
+			rest := strings.TrimPrefix(s, cur)
+			spaced = strings.HasPrefix(rest, " ")
+			s = strings.TrimSpace(rest)
+			break
+		}
+		if strings.HasSuffix(s, cur) {
+			symbol, prefix = cur, false
+			rest := strings.TrimSuffix(s, cur)
+			spaced = strings.HasSuffix(rest, " ")
+			s = strings.TrimSpace(rest)
+			break
+		}
+	}
+	s = strings.TrimLeft(s, "+-")
+
+	grouping := strings.ContainsAny(s, " '\u00A0")
+	decimals := 0
+	lastDot := strings.LastIndex(s, ".")
+	lastComma := strings.LastIndex(s, ",")
+	decimalAt := -1
+	switch {
+	case lastDot >= 0 && lastComma >= 0:
+		grouping = true
+		decimalAt = lastDot
+		if lastComma > lastDot {
+			decimalAt = lastComma
+		}
+	case lastDot >= 0:
+		decimalAt = lastDot
+	case lastComma >= 0 && opts.NumberLocale == NumberLocaleEN && commaGrouped(s):
+		grouping = true
+	case lastComma >= 0:
+		// matches normalizeNumberString: other commas are a decimal separator
+		decimalAt = lastComma
+	}
+	if decimalAt >= 0 {
+		decimals = len(s) - decimalAt - 1
+	}
+
+	if !percent && symbol == "" && !grouping && !parens {
+		return ""
+	}
+
+	code := "0"
+	if grouping && !percent {
+		code = "#,##0"
+	}
+	if decimals > 0 {
+		code += "." + strings.Repeat("0", decimals)
+	}
+	if percent {
+		code += "%"
+	}
+	if symbol != "" {
+		literal := `"` + symbol + `"`
+		sep := ""
+		if spaced {
+			sep = " "
+		}
+		if prefix {
+			code = literal + sep + code
+		} else {
+			code = code + sep + literal
+		}
+	}
+	if parens {
+		code = code + ";(" + code + ")"
+	}
+	return code
+}
@@ -0,0 +1,160 @@
+package osheet
+
+import (
+	"sort"
+	"strings"
+	"time"
+)
+
+// parseDocProperties reads the book's "properties" object and returns it
+// with the book title: title, or else the "title" the object carries.
+//
+//	"properties":{"author":"Ann","keywords":["q3","sales"],
+//	  "created":"2024-03-01T09:00:00Z","modified":1709283600000,
+//	  "custom":[{"name":"Reviewed","value":"2024-03-02","type":"date"}]}
+//
+// author has the alias creator and description the alias comments.
+// Keyword lists are joined with "; ". Times are dates or date-times as cells
+// accept them, or Unix milliseconds. custom is a list of {name, value,
+// type} where type "date" reads a text value as a time, or an object of
+// names to values.
+func parseDocProperties(title string, raw interface{}) (string, DocProperties) {
+	title = strings.TrimSpace(title)
+	m, ok := raw.(map[string]interface{})
+	if !ok {
+		return title, DocProperties{}
+	}
+	if title == "" {
+		title = strings.TrimSpace(toString(m["title"]))
+	}
+	p := DocProperties{
+		Author:      strings.TrimSpace(toString(firstPresent(m, "author", "creator"))),
+		Description: toString(firstPresent(m, "description", "comments")),
+		Keywords:    parseKeywords(m["keywords"]),
+		Created:     parsePropertyTime(m["created"]),
+		Modified:    parsePropertyTime(m["modified"]),
+		Custom:      parseCustomProperties(m["custom"]),
+	}
+	return title, p
+}
+
+func parseKeywords(raw interface{}) string {
+	list, ok := raw.([]interface{})
+	if !ok {
+		return strings.TrimSpace(toString(raw))
+	}
+	var out []string
+	for _, item := range list {
+		if k := strings.TrimSpace(toString(item)); k != "" {
+			out = append(out, k)
+		}
+	}
+	return strings.Join(out, "; ")
+}
+
+// parsePropertyTime reads a date text or Unix milliseconds; the zero time
+// for anything else.
+func parsePropertyTime(raw interface{}) time.Time {
+	switch v := raw.(type) {
+	case float64:
+		return time.UnixMilli(int64(v)).UTC()
+	case string:
+		if t, ok := parseDate(strings.TrimSpace(v)); ok {
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+func parseCustomProperties(raw interface{}) []CustomProperty {
+	var out []CustomProperty
+	switch v := raw.(type) {
+	case []interface{}:
+		for _, item := range v {
+			m, ok := item.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			name := strings.TrimSpace(toString(m["name"]))
+			value := m["value"]
+			if strings.EqualFold(toString(m["type"]), "date") {
+				if t := parsePropertyTime(value); !t.IsZero() {
+					value = t
+				}
+			}
+			if prop, ok := customProperty(name, value); ok {
+				out = append(out, prop)
+			}
+		}
+	case map[string]interface{}:
+		names := make([]string, 0, len(v))
+		for name := range v {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			if prop, ok := customProperty(strings.TrimSpace(name), v[name]); ok {
+				out = append(out, prop)
+			}
+		}
+	}
+	return out
+}
+
+// customProperty keeps named text, number, boolean and time values.
+func customProperty(name string, value interface{}) (CustomProperty, bool) {
+	if name == "" {
+		return CustomProperty{}, false
+	}
+	switch value.(type) {
+	case string, float64, bool, time.Time:
+		return CustomProperty{Name: name, Value: value}, true
+	}
+	return CustomProperty{}, false
+}
+
+// propertiesOut is the "properties" object written by
+// GenerateBookDocumentJSON and WriteBinary.
+type propertiesOut struct {
+	Author      string              `json:"author,omitempty"`
+	Description string              `json:"description,omitempty"`
+	Keywords    string              `json:"keywords,omitempty"`
+	Created     string              `json:"created,omitempty"`
+	Modified    string              `json:"modified,omitempty"`
+	Custom      []customPropertyOut `json:"custom,omitempty"`
+}
+
+type customPropertyOut struct {
+	Name  string      `json:"name"`
+	Value interface{} `json:"value"`
+	Type  string      `json:"type,omitempty"`
+}
+
+// toPropertiesOut returns nil when no property is set.
+func toPropertiesOut(p DocProperties) *propertiesOut {
+	if p.IsZero() {
+		return nil
+	}
+	out := &propertiesOut{
+		Author:      p.Author,
+		Description: p.Description,
+		Keywords:    p.Keywords,
+		Created:     formatPropertyTime(p.Created),
+		Modified:    formatPropertyTime(p.Modified),
+	}
+	for _, c := range p.Custom {
+		prop := customPropertyOut{Name: c.Name, Value: c.Value}
+		if t, ok := c.Value.(time.Time); ok {
+			prop.Value, prop.Type = formatPropertyTime(t), "date"
+		}
+		out.Custom = append(out.Custom, prop)
+	}
+	return out
+}
+
+func formatPropertyTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
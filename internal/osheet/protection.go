@@ -0,0 +1,163 @@
+package osheet
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// allowActions maps lower-case action names to the Allow* constants.
+var allowActions = func() map[string]string {
+	out := make(map[string]string)
+	for _, a := range []string{
+		AllowSelectLockedCells, AllowSelectUnlockedCells, AllowFormatCells,
+		AllowFormatColumns, AllowFormatRows, AllowInsertColumns, AllowInsertRows,
+		AllowInsertHyperlinks, AllowDeleteColumns, AllowDeleteRows, AllowSort,
+		AllowAutoFilter, AllowPivotTables, AllowEditObjects, AllowEditScenarios,
+	} {
+		out[strings.ToLower(a)] = a
+	}
+	return out
+}()
+
+// parseSheetProtection reads a sheet's "protection": true for the
+// spreadsheet default (only selecting cells allowed), or an object with an
+// optional password (hashed here) or passwordHash and the allowed actions,
+// either as a list or as flat booleans:
+//
+//	"protection":{"password":"secret","allow":["formatCells","sort"]}
+//	"protection":{"passwordHash":"CBEB","formatCells":true}
+//
+// Without any action the default applies; an empty "allow" list allows
+// nothing.
+func parseSheetProtection(raw interface{}) *SheetProtection {
+	if b, ok := raw.(bool); ok {
+		if !b {
+			return nil
+		}
+		raw = map[string]interface{}{}
+	}
+	m, ok := raw.(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	p := &SheetProtection{PasswordHash: parsePasswordHash(m)}
+	list, _ := m["allow"].([]interface{})
+	_, listed := m["allow"]
+	for _, item := range list {
+		if name := strings.TrimSpace(toString(item)); name != "" {
+			p.Allow = append(p.Allow, canonicalAction(name))
+		}
+	}
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		if action, ok := allowActions[strings.ToLower(k)]; ok {
+			listed = true
+			if toBool(m[k]) {
+				p.Allow = append(p.Allow, action)
+			}
+		}
+	}
+	if !listed {
+		p.Allow = []string{AllowSelectLockedCells, AllowSelectUnlockedCells}
+	}
+	return p
+}
+
+// canonicalAction returns the Allow* constant matching name in any case, or
+// name itself when it is unknown.
+func canonicalAction(name string) string {
+	if action, ok := allowActions[strings.ToLower(name)]; ok {
+		return action
+	}
+	return name
+}
+
+// parseWorkbookProtection reads the book's "protection": true to lock the
+// structure, or an object {structure, windows, password|passwordHash}
+// where structure defaults to true.
+func parseWorkbookProtection(raw interface{}) *WorkbookProtection {
+	if b, ok := raw.(bool); ok {
+		if !b {
+			return nil
+		}
+		return &WorkbookProtection{Structure: true}
+	}
+	m, ok := raw.(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	p := &WorkbookProtection{
+		Structure:    true,
+		Windows:      toBool(firstPresent(m, "windows", "lockWindows")),
+		PasswordHash: parsePasswordHash(m),
+	}
+	if v := firstPresent(m, "structure", "lockStructure"); v != nil {
+		p.Structure = toBool(v)
+	}
+	if !p.Structure && !p.Windows {
+		return nil
+	}
+	return p
+}
+
+// parsePasswordHash returns the passwordHash|hash of m in upper case, or the
+// hash of its plain password.
+func parsePasswordHash(m map[string]interface{}) string {
+	if hash := strings.TrimSpace(toString(firstPresent(m, "passwordHash", "hash"))); hash != "" {
+		return strings.ToUpper(hash)
+	}
+	if password := toString(m["password"]); password != "" {
+		return HashPassword(password)
+	}
+	return ""
+}
+
+// HashPassword returns the legacy 16-bit Excel protection hash of password
+// as 4 upper-case hex digits.
+func HashPassword(password string) string {
+	return fmt.Sprintf("%04X", legacyHash(password))
+}
+
+func legacyHash(password string) uint16 {
+	var hash uint64
+	for i, r := range []rune(password) {
+		v := uint64(r) << uint(i+1)
+		hash ^= v&0x7fff | v>>15
+	}
+	hash ^= uint64(len(password)) ^ 0xCE4B
+	return uint16(hash)
+}
+
+// PasswordForHash returns a password whose legacy hash is hash, so writers
+// that only accept plain passwords can reproduce it. The legacy hash has
+// many such passwords; the one returned is 15 letters long. It fails for
+// text that is not a hash a password can produce.
+func PasswordForHash(hash string) (string, bool) {
+	want, err := strconv.ParseUint(hash, 16, 16)
+	if err != nil {
+		return "", false
+	}
+	// Replacing the i-th 'P' with 'Q' flips bit i mod 15 of the hash.
+	chars := []byte(strings.Repeat("P", 15))
+	diff := uint16(want) ^ legacyHash(string(chars))
+	for bit := 0; bit < 15; bit++ {
+		if diff&(1<<bit) != 0 {
+			pos := bit
+			if pos == 0 {
+				pos = 15
+			}
+			chars[pos-1] = 'Q'
+		}
+	}
+	password := string(chars)
+	if uint64(legacyHash(password)) != want {
+		return "", false
+	}
+	return password, true
+}
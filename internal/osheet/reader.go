@@ -6,16 +6,47 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"math"
 	"path"
 	"strconv"
 	"strings"
 	"time"
 )
 
+// Number locales for ReadOptions.NumberLocale.
+const (
+	// NumberLocaleDefault reads a lone comma as a decimal separator, as in "1,5".
+	NumberLocaleDefault = ""
+	// NumberLocaleEN reads a comma followed by three-digit groups as a
+	// thousands separator, as in "1,200" or "12,345,678".
+	NumberLocaleEN = "en"
+)
+
+// ReadOptions controls how cell text is read into typed values.
+type ReadOptions struct {
+	// NumberLocale is NumberLocaleDefault or NumberLocaleEN.
+	NumberLocale string
+}
+
+// Validate reports unknown option values.
+func (o ReadOptions) Validate() error {
+	switch o.NumberLocale {
+	case NumberLocaleDefault, NumberLocaleEN:
+		return nil
+	default:
+		return fmt.Errorf("invalid number locale %q (want en or empty)", o.NumberLocale)
+	}
+}
+
 // ReadBook parses an Osheet ZIP and extracts basic sheet-like data.
 // MVP: creates one sheet per file under "sheets/" by embedding up to 32KB of text content into cell A1.
 // If no such files found, creates a single sheet "archive" listing entry names.
 func ReadBook(zipPath string) (*Book, error) {
+	return ReadBookWithOptions(zipPath, ReadOptions{})
+}
+
+// ReadBookWithOptions is ReadBook with options.
+func ReadBookWithOptions(zipPath string, opts ReadOptions) (*Book, error) {
 	if !IsLikelyOsheet(zipPath) {
 		return nil, errors.New("unsupported osheet layout or not a zip")
 	}
@@ -26,10 +57,21 @@ func ReadBook(zipPath string) (*Book, error) {
 	}
 	defer rc.Close()
 
-	var sheets []Sheet
+	var (
+		sheets     []Sheet
+		title      string
+		names      []DefinedName
+		protection *WorkbookProtection
+		properties DocProperties
+	)
 
-	if shs, ok := tryParseDocumentJSON(rc.File); ok && len(shs) > 0 {
-		sheets = append(sheets, shs...)
+	if doc, ok := parseDocumentJSON(rc.File, opts); ok && len(doc.Sheets) > 0 {
+		loadImages(rc.File, findDocumentJSON(rc.File), doc.Sheets)
+		sheets = append(sheets, doc.Sheets...)
+		title = doc.Title
+		names = doc.DefinedNames
+		protection = doc.Protection
+		properties = doc.Properties
 	}
 
 	for _, f := range rc.File {
@@ -38,7 +80,7 @@ func ReadBook(zipPath string) (*Book, error) {
 		}
 		// Try JSON-based sheets first
 		if len(sheets) == 0 && strings.HasPrefix(f.Name, "sheets/") && strings.HasSuffix(strings.ToLower(f.Name), ".json") {
-			if sh, ok := tryParseSheetJSON(f); ok {
+			if sh, ok := tryParseSheetJSON(f, opts); ok {
 				sheets = append(sheets, sh)
 				continue
 			}
@@ -82,7 +124,7 @@ func ReadBook(zipPath string) (*Book, error) {
 		})
 	}
 
-	b := &Book{Title: zipPath, Sheets: sheets}
+	b := &Book{Title: title, Sheets: sheets, DefinedNames: names, Protection: protection, Properties: properties}
 	return b, nil
 }
 
@@ -129,7 +171,7 @@ func isRegularFile(f *zip.File) bool {
 // 1) {"name":"Sheet1","rows":[["a","b"],["c","d"]]}
 // 2) [["a","b"],["c","d"]]
 // 3) {"rows":[["a","b"]]}
-func tryParseSheetJSON(f *zip.File) (Sheet, bool) {
+func tryParseSheetJSON(f *zip.File, opts ReadOptions) (Sheet, bool) {
 	r, err := f.Open()
 	if err != nil {
 		return Sheet{}, false
@@ -146,17 +188,17 @@ func tryParseSheetJSON(f *zip.File) (Sheet, bool) {
 	}
 	var rn rowsNamed
 	if json.Unmarshal(data, &rn) == nil && len(rn.Rows) > 0 {
-		return sheetFromRows(defaultName(rn.Name, path.Base(f.Name)), rn.Rows, nil, nil, nil), true
+		return sheetFromRows(defaultName(rn.Name, path.Base(f.Name)), rn.Rows, nil, nil, nil, opts), true
 	}
 	var rowsOnly [][]string
 	if json.Unmarshal(data, &rowsOnly) == nil && len(rowsOnly) > 0 {
-		return sheetFromRows(defaultName("", path.Base(f.Name)), rowsOnly, nil, nil, nil), true
+		return sheetFromRows(defaultName("", path.Base(f.Name)), rowsOnly, nil, nil, nil, opts), true
 	}
 	var r2 struct {
 		Rows [][]string `json:"rows"`
 	}
 	if json.Unmarshal(data, &r2) == nil && len(r2.Rows) > 0 {
-		return sheetFromRows(defaultName("", path.Base(f.Name)), r2.Rows, nil, nil, nil), true
+		return sheetFromRows(defaultName("", path.Base(f.Name)), r2.Rows, nil, nil, nil, opts), true
 	}
 	_ = rn // silence unused in some toolchains
 	_ = r2
@@ -170,102 +212,264 @@ func defaultName(name string, fallback string) string {
 	return strings.TrimSuffix(fallback, ".json")
 }
 
-func sheetFromRows(name string, rows [][]string, merges []Merge, cols []ColSpec, rowSpecs []RowSpec) Sheet {
-	height := len(rows)
+func sheetFromRows(name string, rows [][]string, merges []Merge, cols []ColSpec, rowSpecs []RowSpec, opts ReadOptions) Sheet {
+	cells, width := cellsFromRows(rows, opts)
+	return Sheet{Name: name, Width: width, Height: len(cells), Cells: cells, Merges: merges, Cols: cols, Rows: rowSpecs}
+}
+
+// cellsFromRows infers typed cells from string rows and returns them with the widest row length.
+func cellsFromRows(rows [][]string, opts ReadOptions) ([][]Cell, int) {
 	width := 0
-	for i := 0; i < len(rows); i++ {
-		if len(rows[i]) > width {
-			width = len(rows[i])
+	cells := make([][]Cell, len(rows))
+	for r := 0; r < len(rows); r++ {
+		if len(rows[r]) > width {
+			width = len(rows[r])
 		}
+		cells[r] = rowFromStrings(rows[r], opts)
 	}
-	cells := make([][]Cell, height)
-	for r := 0; r < height; r++ {
-		row := rows[r]
-		cells[r] = make([]Cell, len(row))
-		for c := 0; c < len(row); c++ {
-			cells[r][c] = inferCell(row[c])
-		}
+	return cells, width
+}
+
+// rowFromStrings infers typed cells for a single row.
+func rowFromStrings(row []string, opts ReadOptions) []Cell {
+	cells := make([]Cell, len(row))
+	for c := 0; c < len(row); c++ {
+		cells[c] = inferCell(row[c], opts)
 	}
-	return Sheet{Name: name, Width: width, Height: height, Cells: cells, Merges: merges, Cols: cols, Rows: rowSpecs}
+	return cells
 }
 
-// tryParseDocumentJSON parses document.json with an expected shape.
-func tryParseDocumentJSON(files []*zip.File) ([]Sheet, bool) {
-	var doc *zip.File
+// findDocumentJSON returns the first document.json entry of the archive, or nil.
+func findDocumentJSON(files []*zip.File) *zip.File {
 	for _, f := range files {
 		if strings.EqualFold(path.Base(f.Name), "document.json") {
-			doc = f
-			break
+			return f
 		}
 	}
+	return nil
+}
+
+// tryParseDocumentJSON parses document.json with an expected shape.
+func tryParseDocumentJSON(files []*zip.File, opts ReadOptions) ([]Sheet, bool) {
+	doc, ok := parseDocumentJSON(files, opts)
+	return doc.Sheets, ok
+}
+
+// parseDocumentJSON is tryParseDocumentJSON returning the sheets with the
+// optional document "title", "definedNames" (alias "names"), "protection"
+// and "properties".
+func parseDocumentJSON(files []*zip.File, opts ReadOptions) (Book, bool) {
+	doc := findDocumentJSON(files)
 	if doc == nil {
-		return nil, false
+		return Book{}, false
 	}
 	r, err := doc.Open()
 	if err != nil {
-		return nil, false
+		return Book{}, false
 	}
 	defer r.Close()
 	data, err := io.ReadAll(r)
 	if err != nil {
-		return nil, false
+		return Book{}, false
 	}
 
 	// Flexible parsing strategy: support multiple sheet schemas
 	var docGeneric struct {
+		Title  string            `json:"title"`
 		Sheets []json.RawMessage `json:"sheets"`
+		Styles json.RawMessage   `json:"styles"`
+		// Names, Protection and Properties are decoded loosely; see
+		// parseDefinedNames, parseWorkbookProtection and parseDocProperties.
+		DefinedNames interface{} `json:"definedNames"`
+		Names        interface{} `json:"names"`
+		Protection   interface{} `json:"protection"`
+		Properties   interface{} `json:"properties"`
 	}
 	if json.Unmarshal(data, &docGeneric) != nil || len(docGeneric.Sheets) == 0 {
-		return nil, false
+		return Book{}, false
 	}
+	styles := parseStyleTableJSON(docGeneric.Styles)
 	var out []Sheet
 	for i := 0; i < len(docGeneric.Sheets); i++ {
-		sh, ok := parseDocumentSheet(docGeneric.Sheets[i])
+		sh, ok := parseDocumentSheet(docGeneric.Sheets[i], styles, opts)
 		if ok {
 			out = append(out, sh)
 		}
 	}
 	if len(out) == 0 {
-		return nil, false
+		return Book{}, false
+	}
+	names := docGeneric.DefinedNames
+	if names == nil {
+		names = docGeneric.Names
+	}
+	title, properties := parseDocProperties(docGeneric.Title, docGeneric.Properties)
+	return Book{
+		Title:        title,
+		Sheets:       out,
+		DefinedNames: parseDefinedNames(names),
+		Protection:   parseWorkbookProtection(docGeneric.Protection),
+		Properties:   properties,
+	}, true
+}
+
+// colJSON and rowJSON are the document.json shapes of column and row specs.
+type (
+	colJSON struct {
+		Index int
+		Width float64
 	}
-	return out, true
+	rowJSON struct {
+		Index  int
+		Height float64
+	}
+)
+
+// sheetViewJSON holds the view keys every sheet schema may carry; the binary
+// sections use the same keys.
+type sheetViewJSON struct {
+	FrozenRows int     `json:"frozenRows,omitempty"`
+	FrozenCols int     `json:"frozenCols,omitempty"`
+	TabColor   string  `json:"tabColor,omitempty"`
+	Visibility string  `json:"visibility,omitempty"`
+	Zoom       float64 `json:"zoom,omitempty"`
+}
+
+func viewJSON(v SheetView) sheetViewJSON {
+	return sheetViewJSON{FrozenRows: v.FrozenRows, FrozenCols: v.FrozenCols, TabColor: v.TabColor, Visibility: v.Visibility, Zoom: v.Zoom}
+}
+
+func (v sheetViewJSON) view() SheetView {
+	return newSheetView(v.FrozenRows, v.FrozenCols, v.TabColor, v.Visibility, v.Zoom)
+}
+
+// newSheetView normalizes source view settings, dropping values Excel would reject.
+func newSheetView(frozenRows, frozenCols int, tabColor, visibility string, zoom float64) SheetView {
+	v := SheetView{
+		FrozenRows: max(frozenRows, 0),
+		FrozenCols: max(frozenCols, 0),
+		TabColor:   normalizeColor(tabColor),
+		Visibility: normalizeVisibility(visibility),
+	}
+	if zoom >= 10 && zoom <= 400 {
+		v.Zoom = zoom
+	}
+	return v
+}
+
+func normalizeVisibility(in string) string {
+	switch strings.ToLower(strings.TrimSpace(in)) {
+	case "hidden", "true":
+		return SheetHidden
+	case "veryhidden", "very_hidden", "very-hidden":
+		return SheetVeryHidden
+	default:
+		return ""
+	}
+}
+
+// sheetMetaJSON holds the sheet-level keys shared by the V2 and V3 schemas,
+// i.e. everything except the cell payload ("rows" or "cells").
+type sheetMetaJSON struct {
+	sheetViewJSON
+	Name       string      `json:"name"`
+	Merges     interface{} `json:"merges"`
+	Cols       []colJSON   `json:"cols"`
+	RowHeights []rowJSON   `json:"rowHeights"`
+	Styles     interface{} `json:"styles"`
+	// Validations, ConditionalFormats, Images, AutoFilter, Tables and
+	// Protection are decoded loosely; see parseValidations,
+	// parseConditionalFormats, parseImages, parseAutoFilter, parseTables and
+	// parseSheetProtection.
+	Validations        interface{} `json:"validations"`
+	ConditionalFormats interface{} `json:"conditionalFormats"`
+	Images             interface{} `json:"images"`
+	AutoFilter         interface{} `json:"autoFilter"`
+	Tables             interface{} `json:"tables"`
+	Protection         interface{} `json:"protection"`
+}
+
+// sheet builds a Sheet carrying metadata only (no cells).
+func (m sheetMetaJSON) sheet() Sheet {
+	var cols []ColSpec
+	for j := 0; j < len(m.Cols); j++ {
+		cj := m.Cols[j]
+		cols = append(cols, ColSpec{Index: cj.Index, Width: cj.Width})
+	}
+	var rowsSpec []RowSpec
+	for j := 0; j < len(m.RowHeights); j++ {
+		rj := m.RowHeights[j]
+		rowsSpec = append(rowsSpec, RowSpec{Index: rj.Index, Height: rj.Height})
+	}
+	return Sheet{
+		Name:               defaultName(m.Name, "Sheet"),
+		Merges:             parseFlexibleMerges(m.Merges),
+		Cols:               cols,
+		Rows:               rowsSpec,
+		View:               m.view(),
+		Validations:        parseValidations(m.Validations),
+		ConditionalFormats: parseConditionalFormats(m.ConditionalFormats),
+		Images:             parseImages(m.Images),
+		AutoFilter:         parseAutoFilter(m.AutoFilter),
+		Tables:             parseTables(m.Tables),
+		Protection:         parseSheetProtection(m.Protection),
+	}
+}
+
+// styleTable merges the sheet-level styles table over the document-level one.
+func (m sheetMetaJSON) styleTable(doc styleTable) styleTable {
+	return doc.merge(parseStyleTable(m.Styles))
 }
 
 // parseDocumentSheet tries several schema variants for a single sheet JSON value.
-func parseDocumentSheet(raw json.RawMessage) (Sheet, bool) {
+// Document-level styles may be referenced by id from V3 cells.
+func parseDocumentSheet(raw json.RawMessage, styles styleTable, opts ReadOptions) (Sheet, bool) {
 	// Base variants of metadata
 	type (
 		mergeJSON struct{ SR, SC, ER, EC int }
-		colJSON   struct {
-			Index int
-			Width float64
-		}
-		rowJSON struct {
-			Index  int
-			Height float64
-		}
-		sheetV1 struct {
-			Name       string      `json:"name"`
-			Rows       [][]string  `json:"rows"`
-			Merges     []mergeJSON `json:"merges"`
-			Cols       []colJSON   `json:"cols"`
-			RowHeights []rowJSON   `json:"rowHeights"`
+		sheetV1   struct {
+			sheetViewJSON
+			Name               string      `json:"name"`
+			Rows               [][]string  `json:"rows"`
+			Merges             []mergeJSON `json:"merges"`
+			Cols               []colJSON   `json:"cols"`
+			RowHeights         []rowJSON   `json:"rowHeights"`
+			Validations        interface{} `json:"validations"`
+			ConditionalFormats interface{} `json:"conditionalFormats"`
+			Images             interface{} `json:"images"`
+			AutoFilter         interface{} `json:"autoFilter"`
+			Tables             interface{} `json:"tables"`
+			Protection         interface{} `json:"protection"`
 		}
 		sheetV2 struct {
-			Name       string          `json:"name"`
-			Rows       [][]interface{} `json:"rows"`
-			Merges     interface{}     `json:"merges"`
-			Cols       []colJSON       `json:"cols"`
-			RowHeights []rowJSON       `json:"rowHeights"`
+			sheetMetaJSON
+			Rows [][]interface{} `json:"rows"`
 		}
 		sheetV3 struct {
-			Name       string          `json:"name"`
-			Cells      [][]interface{} `json:"cells"`
-			Merges     interface{}     `json:"merges"`
-			Cols       []colJSON       `json:"cols"`
-			RowHeights []rowJSON       `json:"rowHeights"`
+			sheetMetaJSON
+			Cells [][]interface{} `json:"cells"`
 		}
 	)
+	// Try V3 first: "cells" is unambiguous, while V1 also matches any sheet with cols or merges
+	var v3 sheetV3
+	if json.Unmarshal(raw, &v3) == nil && len(v3.Cells) > 0 {
+		// Build cells with types and formulas
+		sh := v3.sheet()
+		sheetStyles := v3.styleTable(styles)
+		sh.Height = len(v3.Cells)
+		sh.Cells = make([][]Cell, sh.Height)
+		for r := 0; r < sh.Height; r++ {
+			row := v3.Cells[r]
+			if len(row) > sh.Width {
+				sh.Width = len(row)
+			}
+			sh.Cells[r] = make([]Cell, len(row))
+			for c := 0; c < len(row); c++ {
+				sh.Cells[r][c] = parseAnyCell(row[c], sheetStyles, opts)
+			}
+		}
+		return sh, true
+	}
 	// Try V1: rows as [][]string
 	var v1 sheetV1
 	if json.Unmarshal(raw, &v1) == nil && (len(v1.Rows) > 0 || len(v1.Merges) > 0 || len(v1.Cols) > 0) {
@@ -286,56 +490,23 @@ func parseDocumentSheet(raw json.RawMessage) (Sheet, bool) {
 			row := RowSpec{Index: rj.Index, Height: rj.Height}
 			rowsSpec = append(rowsSpec, row)
 		}
-		return sheetFromRows(defaultName(v1.Name, "Sheet"), v1.Rows, merges, cols, rowsSpec), true
+		sh := sheetFromRows(defaultName(v1.Name, "Sheet"), v1.Rows, merges, cols, rowsSpec, opts)
+		sh.View = v1.view()
+		sh.Validations = parseValidations(v1.Validations)
+		sh.ConditionalFormats = parseConditionalFormats(v1.ConditionalFormats)
+		sh.Images = parseImages(v1.Images)
+		sh.AutoFilter = parseAutoFilter(v1.AutoFilter)
+		sh.Tables = parseTables(v1.Tables)
+		sh.Protection = parseSheetProtection(v1.Protection)
+		return sh, true
 	}
 	// Try V2: rows as [][]interface{}
 	var v2 sheetV2
 	if json.Unmarshal(raw, &v2) == nil && len(v2.Rows) > 0 {
-		rows := convertAnyRowsToStrings(v2.Rows)
-		merges := parseFlexibleMerges(v2.Merges)
-		var cols []ColSpec
-		for j := 0; j < len(v2.Cols); j++ {
-			cj := v2.Cols[j]
-			cols = append(cols, ColSpec{Index: cj.Index, Width: cj.Width})
-		}
-		var rowsSpec []RowSpec
-		for j := 0; j < len(v2.RowHeights); j++ {
-			rj := v2.RowHeights[j]
-			rowsSpec = append(rowsSpec, RowSpec{Index: rj.Index, Height: rj.Height})
-		}
-		return sheetFromRows(defaultName(v2.Name, "Sheet"), rows, merges, cols, rowsSpec), true
-	}
-	// Try V3: cells as [][]interface{}
-	var v3 sheetV3
-	if json.Unmarshal(raw, &v3) == nil && len(v3.Cells) > 0 {
-		// Build cells with types and formulas
-		height := len(v3.Cells)
-		width := 0
-		for i := 0; i < len(v3.Cells); i++ {
-			if len(v3.Cells[i]) > width {
-				width = len(v3.Cells[i])
-			}
-		}
-		cells := make([][]Cell, height)
-		for r := 0; r < height; r++ {
-			row := v3.Cells[r]
-			cells[r] = make([]Cell, len(row))
-			for c := 0; c < len(row); c++ {
-				cells[r][c] = parseAnyCell(row[c])
-			}
-		}
-		merges := parseFlexibleMerges(v3.Merges)
-		var cols []ColSpec
-		for j := 0; j < len(v3.Cols); j++ {
-			cj := v3.Cols[j]
-			cols = append(cols, ColSpec{Index: cj.Index, Width: cj.Width})
-		}
-		var rowsSpec []RowSpec
-		for j := 0; j < len(v3.RowHeights); j++ {
-			rj := v3.RowHeights[j]
-			rowsSpec = append(rowsSpec, RowSpec{Index: rj.Index, Height: rj.Height})
-		}
-		return Sheet{Name: defaultName(v3.Name, "Sheet"), Width: width, Height: height, Cells: cells, Merges: merges, Cols: cols, Rows: rowsSpec}, true
+		sh := v2.sheet()
+		sh.Cells, sh.Width = cellsFromRows(convertAnyRowsToStrings(v2.Rows), opts)
+		sh.Height = len(sh.Cells)
+		return sh, true
 	}
 	return Sheet{}, false
 }
@@ -424,7 +595,7 @@ func toInt(v interface{}) int {
 }
 
 // inferCell attempts to parse a string into number, bool, or datetime; falls back to string
-func inferCell(s string) Cell {
+func inferCell(s string, opts ReadOptions) Cell {
 	t := strings.TrimSpace(s)
 	if t == "" {
 		return Cell{Type: ValueEmpty}
@@ -456,8 +627,8 @@ func inferCell(s string) Cell {
 		}
 	}
 	// number with locales, percents, currency, negatives
-	if f, ok := parseNumber(t); ok {
-		return Cell{Type: ValueNumber, NumberValue: f, StringValue: t}
+	if f, ok := parseNumber(t, opts); ok {
+		return Cell{Type: ValueNumber, NumberValue: f, StringValue: t, NumFmt: inferNumFmt(t, opts)}
 	}
 	// datetime: robust parsing across common variants
 	if tm, ok := parseDate(t); ok {
@@ -476,15 +647,131 @@ func inferCell(s string) Cell {
 	return Cell{Type: ValueString, StringValue: s}
 }
 
-// parseAnyCell converts various JSON cell encodings into Cell.
+// parseAnyCell converts a JSON cell into Cell including its style.
+// Object cells may carry "style" (inline object) or "s" (id into the styles table),
+// and a number format code in "numFmt" or "z" which wins over the style's one.
+func parseAnyCell(v interface{}, styles styleTable, opts ReadOptions) Cell {
+	c := parseAnyValue(v, opts)
+	if m, ok := v.(map[string]interface{}); ok {
+		if runs := parseRichText(firstPresent(m, "runs", "richText")); runs != nil && c.Formula == "" {
+			c = richTextCell(runs)
+		}
+		applyStyleEntry(&c, styles.resolve(firstPresent(m, "style", "s")))
+		if nf := toString(firstPresent(m, "numFmt", "z")); nf != "" {
+			c.NumFmt = nf
+		}
+		c.Link = parseLink(firstPresent(m, "link", "hyperlink"))
+		c.Comment = parseComment(firstPresent(m, "comment", "note"))
+	}
+	return c
+}
+
+// parseLink reads a cell link: a string (a "#Sheet!A1" fragment is an
+// internal location, anything else a URL) or {"url"|"location","tooltip"}.
+func parseLink(v interface{}) *Hyperlink {
+	var link Hyperlink
+	switch t := v.(type) {
+	case string:
+		link.URL = t
+	case map[string]interface{}:
+		link.URL = toString(firstPresent(t, "url", "href"))
+		link.Location = toString(t["location"])
+		link.Tooltip = toString(t["tooltip"])
+	}
+	link.URL = strings.TrimSpace(link.URL)
+	if strings.HasPrefix(link.URL, "#") && link.Location == "" {
+		link.URL, link.Location = "", link.URL[1:]
+	}
+	link.Location = strings.TrimPrefix(strings.TrimSpace(link.Location), "#")
+	if link.URL == "" && link.Location == "" {
+		return nil
+	}
+	if link.URL != "" {
+		link.Location = ""
+	}
+	return &link
+}
+
+// parseComment reads a cell comment: plain text or {"author","text"}.
+func parseComment(v interface{}) *Comment {
+	var comment Comment
+	switch t := v.(type) {
+	case string:
+		comment.Text = t
+	case map[string]interface{}:
+		comment.Author = toString(t["author"])
+		comment.Text = toString(t["text"])
+	}
+	if strings.TrimSpace(comment.Text) == "" {
+		return nil
+	}
+	return &comment
+}
+
+// parseRichText reads text runs: each a string or {"text", font keys as in
+// parseStyle, flat or under "font"}. Runs without text are dropped, and nil
+// is returned when no run carries a font, since the text is then plain.
+func parseRichText(v interface{}) []RichTextRun {
+	items, ok := v.([]interface{})
+	if !ok {
+		return nil
+	}
+	var runs []RichTextRun
+	formatted := false
+	for _, item := range items {
+		var run RichTextRun
+		switch t := item.(type) {
+		case string:
+			run.Text = t
+		case map[string]interface{}:
+			run.Text = toString(firstPresent(t, "text", "t"))
+			font := t
+			if f, ok := t["font"].(map[string]interface{}); ok {
+				font = f
+			}
+			if font := parseFont(font); font != (Font{}) {
+				run.Font = &font
+			}
+		}
+		if run.Text == "" {
+			continue
+		}
+		formatted = formatted || run.Font != nil
+		runs = append(runs, run)
+	}
+	if !formatted {
+		return nil
+	}
+	return runs
+}
+
+// richTextCell makes a text cell from runs; rich text is always a string,
+// whatever its text looks like.
+func richTextCell(runs []RichTextRun) Cell {
+	var text strings.Builder
+	for _, run := range runs {
+		text.WriteString(run.Text)
+	}
+	return Cell{Type: ValueString, StringValue: text.String(), RichText: runs}
+}
+
+// applyStyleEntry attaches a resolved style; a source number format replaces the inferred one.
+func applyStyleEntry(c *Cell, entry styleEntry) {
+	c.Style = entry.style
+	if entry.numFmt != "" {
+		c.NumFmt = entry.numFmt
+	}
+}
+
+// parseAnyValue converts various JSON cell encodings into Cell.
 // Supported forms:
 // - primitive: string/number/bool => inferred via inferCell on string or direct mapping
 // - object: {"type":"string|number|bool|date|datetime","value":..., "formula":"..."}
 // - object short keys: {"t":"n|s|b|d","v":..., "f":"..."}
-func parseAnyCell(v interface{}) Cell {
+func parseAnyValue(v interface{}, opts ReadOptions) Cell {
 	switch t := v.(type) {
 	case string:
-		return inferCell(t)
+		return inferCell(t, opts)
 	case float64:
 		return Cell{Type: ValueNumber, NumberValue: t, StringValue: strconv.FormatFloat(t, 'f', -1, 64)}
 	case bool:
@@ -520,7 +807,7 @@ func parseAnyCell(v interface{}) Cell {
 			if val == nil {
 				return Cell{Type: ValueEmpty, Formula: formula}
 			}
-			c := parseAnyCell(val)
+			c := parseAnyValue(val, opts)
 			c.Formula = formula
 			return c
 		}
@@ -533,16 +820,16 @@ func parseAnyCell(v interface{}) Cell {
 			case float64:
 				return Cell{Type: ValueNumber, NumberValue: vv, StringValue: anyToString(val), Formula: formula}
 			case string:
-				nstr := normalizeNumberString(vv)
+				nstr := normalizeNumberString(vv, opts)
 				if f, err := strconv.ParseFloat(nstr, 64); err == nil {
 					return Cell{Type: ValueNumber, NumberValue: f, StringValue: vv, Formula: formula}
 				}
 				// fallback to infer
-				c := inferCell(vv)
+				c := inferCell(vv, opts)
 				c.Formula = formula
 				return c
 			default:
-				c := inferCell(anyToString(val))
+				c := inferCell(anyToString(val), opts)
 				c.Formula = formula
 				return c
 			}
@@ -559,7 +846,7 @@ func parseAnyCell(v interface{}) Cell {
 		case "d", "date", "datetime", "time":
 			switch vv := val.(type) {
 			case string:
-				c := inferCell(vv)
+				c := inferCell(vv, opts)
 				c.Formula = formula
 				if c.Type == ValueDateTime {
 					return c
@@ -571,11 +858,11 @@ func parseAnyCell(v interface{}) Cell {
 				}
 			}
 			// Fallback to string inference
-			c := inferCell(anyToString(val))
+			c := inferCell(anyToString(val), opts)
 			c.Formula = formula
 			return c
 		default:
-			c := inferCell(anyToString(val))
+			c := inferCell(anyToString(val), opts)
 			c.Formula = formula
 			return c
 		}
@@ -584,7 +871,7 @@ func parseAnyCell(v interface{}) Cell {
 	}
 }
 
-func normalizeNumberString(in string) string {
+func normalizeNumberString(in string, opts ReadOptions) string {
 	s := strings.TrimSpace(in)
 	// remove spaces and apostrophes (thousands separators)
 	s = strings.ReplaceAll(s, " ", "")
@@ -606,6 +893,9 @@ func normalizeNumberString(in string) string {
 		return s
 	}
 	if hasComma && !hasDot {
+		if opts.NumberLocale == NumberLocaleEN && commaGrouped(s) {
+			return strings.ReplaceAll(s, ",", "")
+		}
 		// likely decimal comma
 		s = strings.ReplaceAll(s, ",", ".")
 		return s
@@ -613,8 +903,35 @@ func normalizeNumberString(in string) string {
 	return s
 }
 
+// commaGrouped reports whether the commas of s can separate thousands: each
+// is followed by exactly three digits and the leading group is 1-3 digits
+// without a leading zero, as in "1,200" or "12,345,678". NumberLocaleEN reads
+// such commas as thousands separators; "1,5" and "0,125" keep a decimal comma
+// in every locale.
+func commaGrouped(s string) bool {
+	groups := strings.Split(strings.TrimLeft(s, "+-"), ",")
+	if first := groups[0]; len(first) == 0 || len(first) > 3 || first[0] == '0' || !allDigits(first) {
+		return false
+	}
+	for _, g := range groups[1:] {
+		if len(g) != 3 || !allDigits(g) {
+			return false
+		}
+	}
+	return true
+}
+
+func allDigits(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if !isDigit(s[i]) {
+			return false
+		}
+	}
+	return true
+}
+
 // parseNumber handles locales, currency symbols, percent, and parentheses negatives
-func parseNumber(in string) (float64, bool) {
+func parseNumber(in string, opts ReadOptions) (float64, bool) {
 	s := strings.TrimSpace(in)
 	if s == "" {
 		return 0, false
@@ -639,12 +956,11 @@ func parseNumber(in string) (float64, bool) {
 		s = strings.TrimPrefix(s, "-")
 	}
 	// strip currency symbols
-	currency := []string{"$", "€", "£", "₽", "¥", "₴", "₺", "₹", "zł", "PLN", "USD", "EUR", "RUB"}
-	for i := 0; i < len(currency); i++ {
-		s = strings.ReplaceAll(s, currency[i], "")
+	for i := 0; i < len(currencySymbols); i++ {
+		s = strings.ReplaceAll(s, currencySymbols[i], "")
 	}
 	s = strings.TrimSpace(s)
-	s = normalizeNumberString(s)
+	s = normalizeNumberString(s, opts)
 	if f, err := strconv.ParseFloat(s, 64); err == nil {
 		if isPercent {
 			f = f / 100.0
@@ -682,8 +998,24 @@ func parseDate(in string) (time.Time, bool) {
 	return time.Time{}, false
 }
 
+// excelEpoch is day 0 of the Excel 1900 date system (as used by DateEpoch).
+var excelEpoch = time.Date(1899, 12, 30, 0, 0, 0, 0, time.UTC)
+
 func toExcelSerial(t time.Time) float64 {
-	origin := time.Date(1899, 12, 30, 0, 0, 0, 0, time.UTC)
-	diff := t.UTC().Sub(origin)
+	diff := t.UTC().Sub(excelEpoch)
 	return diff.Hours() / 24.0
 }
+
+// SerialToTime converts an Excel serial (Cell.DateEpoch) to UTC, rounded to the second.
+func SerialToTime(serial float64) time.Time {
+	return excelEpoch.Add(time.Duration(math.Round(serial*86400)) * time.Second)
+}
+
+// FormatSerialISO renders an Excel serial as ISO 8601, dropping the time at midnight.
+func FormatSerialISO(serial float64) string {
+	t := SerialToTime(serial)
+	if t.Hour() == 0 && t.Minute() == 0 && t.Second() == 0 {
+		return t.Format("2006-01-02")
+	}
+	return t.Format("2006-01-02T15:04:05")
+}
@@ -2,10 +2,15 @@ package osheet
 
 import (
 	"archive/zip"
+	"bytes"
+	"encoding/base64"
 	"encoding/json"
 	"os"
 	"path/filepath"
+	"reflect"
+	"strings"
 	"testing"
+	"time"
 )
 
 type sheetV1 struct {
@@ -121,3 +126,641 @@ func TestValidateStructure_NoSheets(t *testing.T) {
 		t.Fatalf("expected issues for no sheets")
 	}
 }
+
+func TestReadBook_DocumentJSON_V3_Styles(t *testing.T) {
+	d := t.TempDir()
+	zipPath := filepath.Join(d, "styled.osheet")
+	doc := map[string]interface{}{
+		"styles": []interface{}{
+			map[string]interface{}{"font": map[string]interface{}{"bold": true, "color": "#f00"}, "fill": "#FFFF00", "numFmt": "@"},
+		},
+		"sheets": []interface{}{map[string]interface{}{
+			"name": "Styled",
+			"cells": [][]interface{}{{
+				map[string]interface{}{"v": "head", "s": 0},
+				map[string]interface{}{"v": "12%", "s": 0, "numFmt": "0.0%"},
+				map[string]interface{}{"v": "x", "style": map[string]interface{}{
+					"border": "thin",
+					"align":  map[string]interface{}{"horizontal": "center", "vertical": "middle", "wrap": true, "indent": 2},
+				}},
+				"plain",
+			}},
+		}},
+	}
+	b, err := json.Marshal(doc)
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	writeZip(t, zipPath, map[string][]byte{"document.json": b})
+
+	book, err := ReadBook(zipPath)
+	if err != nil {
+		t.Fatalf("ReadBook: %v", err)
+	}
+	row := book.Sheets[0].Cells[0]
+	head := row[0].Style
+	if head == nil || !head.Font.Bold || head.Font.Color != "FF0000" || head.Fill != "FFFF00" {
+		t.Fatalf("table style not applied: %+v", head)
+	}
+	if row[1].Style != head {
+		t.Fatalf("cells referencing the same style id should share it")
+	}
+	if row[0].NumFmt != "@" || row[1].NumFmt != "0.0%" {
+		t.Fatalf("number formats: %q %q, want table format and cell override", row[0].NumFmt, row[1].NumFmt)
+	}
+	if row[3].NumFmt != "" {
+		t.Fatalf("plain cell got number format %q", row[3].NumFmt)
+	}
+	inline := row[2].Style
+	if inline == nil || inline.Border.Left.Style != "thin" || inline.Border.Bottom.Style != "thin" {
+		t.Fatalf("inline border not parsed: %+v", inline)
+	}
+	if inline.Alignment != (Alignment{Horizontal: "center", Vertical: "center", Wrap: true, Indent: 2}) {
+		t.Fatalf("inline alignment not parsed: %+v", inline.Alignment)
+	}
+	if row[3].Style != nil {
+		t.Fatalf("plain cell should have no style")
+	}
+}
+
+func TestReadBook_DocumentTitle(t *testing.T) {
+	dir := t.TempDir()
+	p := filepath.Join(dir, "titled.osheet")
+	doc := []byte(`{"title":"Quarterly Report","sheets":[{"name":"S","rows":[["a"]]}]}`)
+	writeZip(t, p, map[string][]byte{"document.json": doc})
+
+	book, err := ReadBook(p)
+	if err != nil {
+		t.Fatalf("ReadBook: %v", err)
+	}
+	if book.Title != "Quarterly Report" {
+		t.Fatalf("ReadBook title = %q", book.Title)
+	}
+	br, err := OpenBookReader(p)
+	if err != nil {
+		t.Fatalf("OpenBookReader: %v", err)
+	}
+	defer br.Close()
+	if got := br.Book().Title; got != "Quarterly Report" {
+		t.Fatalf("BookReader title = %q", got)
+	}
+}
+
+func TestWriteBook_ReadBack(t *testing.T) {
+	p := filepath.Join(t.TempDir(), "written.osheet")
+	in := &Book{Title: "Budget", Sheets: []Sheet{
+		{
+			Name: "Main",
+			Cells: [][]Cell{
+				{{Type: ValueString, StringValue: "a"}, {Type: ValueNumber, NumberValue: 2, NumFmt: "0.00"}},
+				{{Formula: "B1*2"}, {Type: ValueBool, BoolValue: true}},
+			},
+			Merges: []Merge{{StartRow: 2, StartCol: 1, EndRow: 2, EndCol: 2}},
+			Cols:   []ColSpec{{Index: 1, Width: 20}},
+			Rows:   []RowSpec{{Index: 2, Height: 28}},
+			View:   SheetView{FrozenRows: 1, FrozenCols: 2, TabColor: "FF8800", Zoom: 150},
+		},
+		{Name: "Empty", View: SheetView{Visibility: SheetVeryHidden}},
+	}}
+	if err := WriteBook(in, p); err != nil {
+		t.Fatalf("WriteBook: %v", err)
+	}
+	book, err := ReadBook(p)
+	if err != nil {
+		t.Fatalf("ReadBook: %v", err)
+	}
+	if book.Title != "Budget" || len(book.Sheets) != 2 || book.Sheets[1].Name != "Empty" {
+		t.Fatalf("book = %q %+v", book.Title, book.Sheets)
+	}
+	// Cols and merges must not divert the sheet to the V1 "rows" schema
+	s := book.Sheets[0]
+	if s.Width != 2 || s.Height != 2 {
+		t.Fatalf("size = %dx%d", s.Width, s.Height)
+	}
+	if c := s.Cells[0][1]; c.Type != ValueNumber || c.NumberValue != 2 || c.NumFmt != "0.00" {
+		t.Fatalf("B1 = %+v", c)
+	}
+	if c := s.Cells[1][0]; c.Type != ValueEmpty || c.Formula != "B1*2" {
+		t.Fatalf("A2 = %+v", c)
+	}
+	if len(s.Merges) != 1 || s.Merges[0] != in.Sheets[0].Merges[0] {
+		t.Fatalf("merges = %+v", s.Merges)
+	}
+	if len(s.Cols) != 1 || s.Cols[0] != in.Sheets[0].Cols[0] || len(s.Rows) != 1 || s.Rows[0] != in.Sheets[0].Rows[0] {
+		t.Fatalf("cols = %+v rows = %+v", s.Cols, s.Rows)
+	}
+	for i := range in.Sheets {
+		if got := book.Sheets[i].View; got != in.Sheets[i].View {
+			t.Errorf("sheet %d view = %+v, want %+v", i, got, in.Sheets[i].View)
+		}
+	}
+
+	br, err := OpenBookReader(p)
+	if err != nil {
+		t.Fatalf("OpenBookReader: %v", err)
+	}
+	defer br.Close()
+	rows, err := br.OpenSheet(0)
+	if err != nil {
+		t.Fatalf("OpenSheet: %v", err)
+	}
+	defer rows.Close()
+	n := 0
+	for rows.Next() {
+		n++
+	}
+	if n != 2 || len(br.Book().Sheets[0].Cols) != 1 {
+		t.Fatalf("streamed rows = %d, cols = %+v", n, br.Book().Sheets[0].Cols)
+	}
+}
+
+func TestReadBook_DocumentJSON_SheetView(t *testing.T) {
+	zipPath := filepath.Join(t.TempDir(), "view.osheet")
+	doc := `{"sheets":[` +
+		`{"name":"A","cells":[["x"]],"frozenRows":1,"tabColor":"rgb(255,0,0)","visibility":"Hidden","zoom":80},` +
+		`{"name":"B","rows":[["y"]],"frozenCols":-2,"tabColor":"blue","visibility":"very_hidden","zoom":1000}]}`
+	writeZip(t, zipPath, map[string][]byte{"document.json": []byte(doc)})
+
+	book, err := ReadBook(zipPath)
+	if err != nil {
+		t.Fatalf("ReadBook: %v", err)
+	}
+	want := []SheetView{
+		{FrozenRows: 1, TabColor: "FF0000", Visibility: SheetHidden, Zoom: 80},
+		// Out-of-range values and unknown colours are dropped
+		{Visibility: SheetVeryHidden},
+	}
+	for i, w := range want {
+		if got := book.Sheets[i].View; got != w {
+			t.Errorf("sheet %d view = %+v, want %+v", i, got, w)
+		}
+	}
+}
+
+func TestReadBook_DocumentJSON_Annotations(t *testing.T) {
+	zipPath := filepath.Join(t.TempDir(), "notes.osheet")
+	doc := `{"sheets":[{"name":"S","cells":[[` +
+		`{"v":"ticket","link":"https://example.com/T-1","comment":{"author":"Ann","text":"check"}},` +
+		`{"v":"back","link":{"location":"#S.A1","tooltip":"top"}},` +
+		`{"note":"empty cell"},` +
+		`{"v":"x","link":"","comment":" "}]]}]}`
+	writeZip(t, zipPath, map[string][]byte{"document.json": []byte(doc)})
+
+	book, err := ReadBook(zipPath)
+	if err != nil {
+		t.Fatalf("ReadBook: %v", err)
+	}
+	row := book.Sheets[0].Cells[0]
+	if l := row[0].Link; l == nil || *l != (Hyperlink{URL: "https://example.com/T-1"}) {
+		t.Errorf("A1 link = %+v", l)
+	}
+	if c := row[0].Comment; c == nil || *c != (Comment{Author: "Ann", Text: "check"}) {
+		t.Errorf("A1 comment = %+v", c)
+	}
+	if l := row[1].Link; l == nil || *l != (Hyperlink{Location: "S.A1", Tooltip: "top"}) {
+		t.Errorf("B1 link = %+v", l)
+	}
+	if c := row[2].Comment; row[2].Type != ValueEmpty || c == nil || c.Text != "empty cell" {
+		t.Errorf("C1 = %+v comment %+v", row[2], c)
+	}
+	if row[3].Link != nil || row[3].Comment != nil {
+		t.Errorf("blank annotations kept: %+v %+v", row[3].Link, row[3].Comment)
+	}
+
+	// Written documents keep annotations
+	out := filepath.Join(t.TempDir(), "out.osheet")
+	if err := WriteBook(book, out); err != nil {
+		t.Fatalf("WriteBook: %v", err)
+	}
+	back, err := ReadBook(out)
+	if err != nil {
+		t.Fatalf("ReadBook back: %v", err)
+	}
+	for c := 0; c < 3; c++ {
+		got, want := back.Sheets[0].Cells[0][c], row[c]
+		if !reflect.DeepEqual(got.Link, want.Link) || !reflect.DeepEqual(got.Comment, want.Comment) {
+			t.Errorf("col %d = %+v %+v, want %+v %+v", c, got.Link, got.Comment, want.Link, want.Comment)
+		}
+	}
+}
+
+func TestReadBook_DocumentJSON_RichText(t *testing.T) {
+	zipPath := filepath.Join(t.TempDir(), "rich.osheet")
+	doc := `{"sheets":[{"name":"S","cells":[[` +
+		`{"runs":["Pay ",{"text":"now","bold":true,"color":"#FF0000"},{"t":"","i":true}," please"],"style":{"font":{"size":9}}},` +
+		`{"v":"42","richText":[{"text":"42","font":{"italic":true}}]},` +
+		`{"runs":["plain"," text"]},` +
+		`{"f":"A1","runs":[{"text":"x","bold":true}]}]]}]}`
+	writeZip(t, zipPath, map[string][]byte{"document.json": []byte(doc)})
+
+	book, err := ReadBook(zipPath)
+	if err != nil {
+		t.Fatalf("ReadBook: %v", err)
+	}
+	row := book.Sheets[0].Cells[0]
+	want := []RichTextRun{{Text: "Pay "}, {Text: "now", Font: &Font{Bold: true, Color: "FF0000"}}, {Text: " please"}}
+	if row[0].Type != ValueString || row[0].StringValue != "Pay now please" || !reflect.DeepEqual(row[0].RichText, want) {
+		t.Errorf("A1 = %q %+v", row[0].StringValue, row[0].RichText)
+	}
+	if row[0].Style == nil || row[0].Style.Font.Size != 9 {
+		t.Errorf("A1 style = %+v", row[0].Style)
+	}
+	// Rich text is text, even when it reads as a number
+	if row[1].Type != ValueString || row[1].StringValue != "42" || len(row[1].RichText) != 1 {
+		t.Errorf("B1 = %+v", row[1])
+	}
+	// Runs without formatting are plain text; formulas ignore runs
+	if row[2].RichText != nil || row[3].RichText != nil || row[3].Formula != "A1" {
+		t.Errorf("C1 = %+v, D1 = %+v", row[2], row[3])
+	}
+
+	out := filepath.Join(t.TempDir(), "out.osheet")
+	if err := WriteBook(book, out); err != nil {
+		t.Fatalf("WriteBook: %v", err)
+	}
+	back, err := ReadBook(out)
+	if err != nil {
+		t.Fatalf("ReadBook back: %v", err)
+	}
+	for c := 0; c < 2; c++ {
+		got, want := back.Sheets[0].Cells[0][c], row[c]
+		if got.StringValue != want.StringValue || !reflect.DeepEqual(got.RichText, want.RichText) {
+			t.Errorf("col %d = %q %+v, want %q %+v", c, got.StringValue, got.RichText, want.StringValue, want.RichText)
+		}
+	}
+}
+
+func TestReadBook_DocumentJSON_Validations(t *testing.T) {
+	zipPath := filepath.Join(t.TempDir(), "rules.osheet")
+	doc := `{"sheets":[{"name":"S","cells":[["status","qty","due","end"]],"validations":[` +
+		`{"range":"A2:A50","type":"dropdown","values":["Open","Done"],"inputMessage":"Pick a status"},` +
+		`{"range":"B2:B50, D2","type":"integer","min":1,"max":10,"errorStyle":"info"},` +
+		`{"range":"C2:C50","kind":"date","min":"2024-01-01"},` +
+		`{"range":"D2:D50","type":"custom","formula":"=D2>C2"},` +
+		`{"range":"E2","type":"textLength","operator":"<=","value":5},` +
+		`{"range":"F2","type":"any"},` +
+		`{"type":"list","values":["x"]}]}]}`
+	writeZip(t, zipPath, map[string][]byte{"document.json": []byte(doc)})
+
+	book, err := ReadBook(zipPath)
+	if err != nil {
+		t.Fatalf("ReadBook: %v", err)
+	}
+	want := []DataValidation{
+		{Range: "A2:A50", Kind: ValidationList, Values: []string{"Open", "Done"}, InputMessage: "Pick a status"},
+		{Range: "B2:B50 D2", Kind: ValidationWhole, Operator: "between", Formula1: "1", Formula2: "10", ErrorStyle: "information"},
+		{Range: "C2:C50", Kind: ValidationDate, Operator: "greaterThanOrEqual", Formula1: "45292"},
+		{Range: "D2:D50", Kind: ValidationCustom, Formula1: "=D2>C2"},
+		{Range: "E2", Kind: ValidationTextLength, Operator: "lessThanOrEqual", Formula1: "5"},
+		// Unknown kinds are kept for the writers to report; rules without a range are not
+		{Range: "F2", Kind: "any"},
+	}
+	if got := book.Sheets[0].Validations; !reflect.DeepEqual(got, want) {
+		t.Errorf("validations = %+v, want %+v", got, want)
+	}
+
+	// Written documents keep validations
+	out := filepath.Join(t.TempDir(), "out.osheet")
+	if err := WriteBook(book, out); err != nil {
+		t.Fatalf("WriteBook: %v", err)
+	}
+	back, err := ReadBook(out)
+	if err != nil {
+		t.Fatalf("ReadBook back: %v", err)
+	}
+	if got := back.Sheets[0].Validations; !reflect.DeepEqual(got, want) {
+		t.Errorf("validations after write = %+v, want %+v", got, want)
+	}
+}
+
+func TestReadBook_DocumentJSON_ConditionalFormats(t *testing.T) {
+	zipPath := filepath.Join(t.TempDir(), "highlights.osheet")
+	doc := `{"sheets":[{"name":"S","cells":[["due","qty"]],"conditionalFormats":[` +
+		`{"range":"A2:A50","type":"expression","formula":"=A2<TODAY()","style":{"font":{"color":"#C00000","bold":true}},"stopIfTrue":true},` +
+		`{"range":"B2:B50","type":"cell","operator":">","value":100,"style":{"fill":"rgb(255,199,206)"}},` +
+		`{"range":"C2:C50","type":"3_color_scale","colors":["#F8696B","#FFEB84","#63BE7B"]},` +
+		`{"range":"D2:D50","type":"bar","color":"#638EC6"},` +
+		`{"range":"E2:E50","type":"iconSet"},` +
+		`{"type":"formula","formula":"=TRUE"}]}]}`
+	writeZip(t, zipPath, map[string][]byte{"document.json": []byte(doc)})
+
+	book, err := ReadBook(zipPath)
+	if err != nil {
+		t.Fatalf("ReadBook: %v", err)
+	}
+	want := []ConditionalFormat{
+		{Range: "A2:A50", Kind: ConditionalFormula, Formula1: "=A2<TODAY()", Style: &Style{Font: Font{Bold: true, Color: "C00000"}}, StopIfTrue: true},
+		{Range: "B2:B50", Kind: ConditionalCellValue, Operator: "greaterThan", Formula1: "100", Style: &Style{Fill: "FFC7CE"}},
+		{Range: "C2:C50", Kind: ConditionalColorScale, Colors: []string{"F8696B", "FFEB84", "63BE7B"}},
+		{Range: "D2:D50", Kind: ConditionalDataBar, Colors: []string{"638EC6"}},
+		// Unknown kinds are kept for the writers to report; rules without a range are not
+		{Range: "E2:E50", Kind: "iconSet"},
+	}
+	if got := book.Sheets[0].ConditionalFormats; !reflect.DeepEqual(got, want) {
+		t.Errorf("conditional formats = %+v, want %+v", got, want)
+	}
+
+	// Written documents keep conditional formats
+	out := filepath.Join(t.TempDir(), "out.osheet")
+	if err := WriteBook(book, out); err != nil {
+		t.Fatalf("WriteBook: %v", err)
+	}
+	back, err := ReadBook(out)
+	if err != nil {
+		t.Fatalf("ReadBook back: %v", err)
+	}
+	if got := back.Sheets[0].ConditionalFormats; !reflect.DeepEqual(got, want) {
+		t.Errorf("conditional formats after write = %+v, want %+v", got, want)
+	}
+}
+
+func TestReadBook_DocumentJSON_Tables(t *testing.T) {
+	zipPath := filepath.Join(t.TempDir(), "tables.osheet")
+	doc := `{"sheets":[` +
+		`{"name":"Orders","cells":[["id","qty"]],"tables":[` +
+		`{"name":"Orders","range":"a1:$D$20","style":"TableStyleMedium9"},` +
+		`{"displayName":"Totals","ref":"G8:F2","headerRow":false},` +
+		`{"name":"Broken","range":"1A:B2"},"F1:G2"]},` +
+		`{"name":"Log","cells":[["when"]],"autoFilter":{"range":"e40:a1"}},` +
+		`{"name":"Raw","cells":[["x"]],"autoFilter":"A1:B"}]}`
+	writeZip(t, zipPath, map[string][]byte{"document.json": []byte(doc)})
+
+	book, err := ReadBook(zipPath)
+	if err != nil {
+		t.Fatalf("ReadBook: %v", err)
+	}
+	wantTables := []Table{
+		{Name: "Orders", Range: "A1:D20", HeaderRow: true, Style: "TableStyleMedium9"},
+		{Name: "Totals", Range: "F2:G8"},
+	}
+	check := func(stage string, b *Book) {
+		if got := b.Sheets[0].Tables; !reflect.DeepEqual(got, wantTables) {
+			t.Errorf("%s: tables = %+v, want %+v", stage, got, wantTables)
+		}
+		if got := b.Sheets[1].AutoFilter; got != "A1:E40" {
+			t.Errorf("%s: autofilter = %q, want A1:E40", stage, got)
+		}
+		// Invalid ranges read as none
+		if got := b.Sheets[2].AutoFilter; got != "" {
+			t.Errorf("%s: invalid autofilter = %q", stage, got)
+		}
+	}
+	check("ReadBook", book)
+
+	// Written documents keep tables and autofilters
+	out := filepath.Join(t.TempDir(), "out.osheet")
+	if err := WriteBook(book, out); err != nil {
+		t.Fatalf("WriteBook: %v", err)
+	}
+	back, err := ReadBook(out)
+	if err != nil {
+		t.Fatalf("ReadBook back: %v", err)
+	}
+	check("after write", back)
+}
+
+func TestReadBook_DocumentJSON_Protection(t *testing.T) {
+	zipPath := filepath.Join(t.TempDir(), "protected.osheet")
+	doc := `{"protection":{"windows":true,"password":"abcdefghij"},"sheets":[` +
+		`{"name":"Form","protection":{"passwordHash":"cbeb","allow":["FormatCells","sort","pasteValues"]},"cells":[[` +
+		`{"v":"input","style":{"locked":false}},{"v":"=1+1","style":{"protection":{"hidden":true}}},"label"]]},` +
+		`{"name":"Defaults","protection":true,"cells":[["x"]]},` +
+		`{"name":"Flags","protection":{"insertRows":true,"deleteRows":false},"cells":[["x"]]},` +
+		`{"name":"Locked","protection":{"allow":[]},"cells":[["x"]]},` +
+		`{"name":"Open","protection":false,"cells":[["x"]]}]}`
+	writeZip(t, zipPath, map[string][]byte{"document.json": []byte(doc)})
+
+	wantBook := &WorkbookProtection{Structure: true, Windows: true, PasswordHash: "FEF1"}
+	wantSheets := []*SheetProtection{
+		{PasswordHash: "CBEB", Allow: []string{AllowFormatCells, AllowSort, "pasteValues"}},
+		{Allow: []string{AllowSelectLockedCells, AllowSelectUnlockedCells}},
+		{Allow: []string{AllowInsertRows}},
+		{},
+		nil,
+	}
+	check := func(stage string, b *Book) {
+		if !reflect.DeepEqual(b.Protection, wantBook) {
+			t.Errorf("%s: book protection = %+v, want %+v", stage, b.Protection, wantBook)
+		}
+		for i, want := range wantSheets {
+			got := b.Sheets[i].Protection
+			// Nil and empty Allow both allow nothing
+			if got != nil && want != nil && len(got.Allow) == 0 && len(want.Allow) == 0 {
+				got.Allow = nil
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("%s: %s protection = %+v, want %+v", stage, b.Sheets[i].Name, got, want)
+			}
+		}
+		row := b.Sheets[0].Cells[0]
+		if st := row[0].Style; st == nil || st.Protection != (CellProtection{Unlocked: true}) {
+			t.Errorf("%s: unlocked cell style = %+v", stage, st)
+		}
+		if st := row[1].Style; st == nil || st.Protection != (CellProtection{HideFormula: true}) {
+			t.Errorf("%s: hidden formula style = %+v", stage, st)
+		}
+		if row[2].Style != nil {
+			t.Errorf("%s: plain cell style = %+v", stage, row[2].Style)
+		}
+	}
+	book, err := ReadBook(zipPath)
+	if err != nil {
+		t.Fatalf("ReadBook: %v", err)
+	}
+	check("ReadBook", book)
+
+	br, err := OpenBookReader(zipPath)
+	if err != nil {
+		t.Fatalf("OpenBookReader: %v", err)
+	}
+	meta := br.Book()
+	_ = br.Close()
+	if !reflect.DeepEqual(meta.Protection, wantBook) || !reflect.DeepEqual(meta.Sheets[0].Protection, wantSheets[0]) {
+		t.Errorf("OpenBookReader: protection = %+v, %+v", meta.Protection, meta.Sheets[0].Protection)
+	}
+
+	out := filepath.Join(t.TempDir(), "out.osheet")
+	if err := WriteBook(book, out); err != nil {
+		t.Fatalf("WriteBook: %v", err)
+	}
+	back, err := ReadBook(out)
+	if err != nil {
+		t.Fatalf("ReadBook back: %v", err)
+	}
+	check("after write", back)
+}
+
+func TestReadBook_DocumentJSON_DefinedNames(t *testing.T) {
+	want := []DefinedName{
+		{Name: "Revenue", RefersTo: "Sales.$B$2:$B$13", Comment: "monthly"},
+		{Name: "TaxRate", RefersTo: "0.2", Scope: "Sales"},
+	}
+	docs := map[string]string{
+		"list": `{"sheets":[{"name":"Sales","cells":[[1]]}],"definedNames":[` +
+			`{"name":"Revenue","refersTo":"=Sales.$B$2:$B$13","comment":"monthly"},` +
+			`{"name":"TaxRate","formula":"0.2","sheet":"Sales"},` +
+			`{"name":"","ref":"A1"},{"name":"Empty"}]}`,
+		"map": `{"sheets":[{"name":"Sales","cells":[[1]]}],"names":{` +
+			`"TaxRate":{"ref":"0.2","scope":"Sales"},"Revenue":{"range":"Sales.$B$2:$B$13","comment":"monthly"}}}`,
+	}
+	for form, doc := range docs {
+		zipPath := filepath.Join(t.TempDir(), "names.osheet")
+		writeZip(t, zipPath, map[string][]byte{"document.json": []byte(doc)})
+		book, err := ReadBook(zipPath)
+		if err != nil {
+			t.Fatalf("%s: ReadBook: %v", form, err)
+		}
+		br, err := OpenBookReader(zipPath)
+		if err != nil {
+			t.Fatalf("%s: OpenBookReader: %v", form, err)
+		}
+		meta := br.Book()
+		_ = br.Close()
+		for name, got := range map[string][]DefinedName{"ReadBook": book.DefinedNames, "OpenBookReader": meta.DefinedNames} {
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("%s: %s: names = %+v, want %+v", form, name, got, want)
+			}
+		}
+
+		out := filepath.Join(t.TempDir(), "out.osheet")
+		if err := WriteBook(book, out); err != nil {
+			t.Fatalf("%s: WriteBook: %v", form, err)
+		}
+		back, err := ReadBook(out)
+		if err != nil {
+			t.Fatalf("%s: ReadBook back: %v", form, err)
+		}
+		if !reflect.DeepEqual(back.DefinedNames, want) {
+			t.Errorf("%s: names after write = %+v, want %+v", form, back.DefinedNames, want)
+		}
+	}
+}
+
+func TestReadBook_DocumentJSON_Images(t *testing.T) {
+	zipPath := filepath.Join(t.TempDir(), "catalogue.osheet")
+	thumb, inline := testPNG(t, 8, 8), testPNG(t, 2, 3)
+	doc := `{"sheets":[{"name":"S","cells":[["sku","photo"]],"images":[` +
+		`{"cell":"b2","src":"media/mug.png","width":32,"alt":"Blue mug"},` +
+		`{"cell":"$B$3","data":"data:image/png;base64,` + base64.StdEncoding.EncodeToString(inline) + `"},` +
+		`{"cell":"B4","src":"/media/missing.png"},` +
+		`{"cell":"B5","src":"media/evil.png"},` +
+		`{"cell":"B6","data":"data:image/png;base64,not*base64"},` +
+		`{"cell":"not a cell","src":"media/mug.png"}]}]}`
+	writeZip(t, zipPath, map[string][]byte{
+		"document.json":  []byte(doc),
+		"media/mug.png":  thumb,
+		"media/evil.png": []byte("<html>not an image</html>"),
+	})
+
+	want := []Image{
+		{Cell: "B2", Source: "media/mug.png", Data: thumb, Width: 32, AltText: "Blue mug"},
+		{Cell: "B3", Data: inline},
+		// Missing and malformed sources stay in the model; writers check them
+		{Cell: "B4", Source: "/media/missing.png"},
+		{Cell: "B5", Source: "media/evil.png", Data: []byte("<html>not an image</html>")},
+	}
+	book, err := ReadBook(zipPath)
+	if err != nil {
+		t.Fatalf("ReadBook: %v", err)
+	}
+	br, err := OpenBookReader(zipPath)
+	if err != nil {
+		t.Fatalf("OpenBookReader: %v", err)
+	}
+	defer br.Close()
+	for name, got := range map[string][]Image{"ReadBook": book.Sheets[0].Images, "OpenBookReader": br.Book().Sheets[0].Images} {
+		if len(got) != len(want)+1 || !reflect.DeepEqual(got[:len(want)], want) {
+			t.Fatalf("%s: images = %+v, want %+v", name, got, want)
+		}
+		// Undecodable inline data is kept for writers to report
+		bad := got[len(want)]
+		if _, _, _, err := bad.Check(); bad.Cell != "B6" || bad.Data != nil || err == nil || !strings.Contains(err.Error(), "invalid base64 data") {
+			t.Errorf("%s: bad inline image = %+v, check %v", name, bad, err)
+		}
+	}
+
+	// Written documents keep the valid images as archive entries
+	out := filepath.Join(t.TempDir(), "out.osheet")
+	if err := WriteBook(book, out); err != nil {
+		t.Fatalf("WriteBook: %v", err)
+	}
+	back, err := ReadBook(out)
+	if err != nil {
+		t.Fatalf("ReadBook back: %v", err)
+	}
+	got := back.Sheets[0].Images
+	if len(got) != 2 || got[0].Cell != "B2" || !bytes.Equal(got[0].Data, thumb) || got[0].Width != 32 || got[0].AltText != "Blue mug" ||
+		got[1].Cell != "B3" || !bytes.Equal(got[1].Data, inline) {
+		t.Errorf("images after write = %+v", got)
+	}
+}
+
+func TestReadBook_DocumentJSON_Properties(t *testing.T) {
+	zipPath := filepath.Join(t.TempDir(), "props.osheet")
+	doc := `{"properties":{"title":"Q3 Sales","creator":"Ann","description":"Quarterly figures",` +
+		`"keywords":["q3"," sales",""],"created":"2024-03-01T09:00:00+02:00","modified":1709283600000,` +
+		`"custom":[{"name":"Reviewed","value":"2024-03-02","type":"date"},{"name":"Pages","value":3},` +
+		`{"name":"Final","value":true},{"name":"Owner","value":"Finance"},{"name":"","value":"x"},{"name":"Bad","value":[1]}]},` +
+		`"sheets":[{"name":"S","cells":[["x"]]}]}`
+	writeZip(t, zipPath, map[string][]byte{"document.json": []byte(doc)})
+
+	want := DocProperties{
+		Author:      "Ann",
+		Description: "Quarterly figures",
+		Keywords:    "q3; sales",
+		Created:     time.Date(2024, 3, 1, 7, 0, 0, 0, time.UTC),
+		Modified:    time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC),
+		Custom: []CustomProperty{
+			{Name: "Reviewed", Value: time.Date(2024, 3, 2, 0, 0, 0, 0, time.UTC)},
+			{Name: "Pages", Value: 3.0},
+			{Name: "Final", Value: true},
+			{Name: "Owner", Value: "Finance"},
+		},
+	}
+	check := func(stage string, b *Book) {
+		if b.Title != "Q3 Sales" {
+			t.Errorf("%s: title = %q", stage, b.Title)
+		}
+		if !reflect.DeepEqual(b.Properties, want) {
+			t.Errorf("%s: properties = %+v, want %+v", stage, b.Properties, want)
+		}
+	}
+	book, err := ReadBook(zipPath)
+	if err != nil {
+		t.Fatalf("ReadBook: %v", err)
+	}
+	check("ReadBook", book)
+
+	br, err := OpenBookReader(zipPath)
+	if err != nil {
+		t.Fatalf("OpenBookReader: %v", err)
+	}
+	check("OpenBookReader", br.Book())
+	_ = br.Close()
+
+	out := filepath.Join(t.TempDir(), "out.osheet")
+	if err := WriteBook(book, out); err != nil {
+		t.Fatalf("WriteBook: %v", err)
+	}
+	back, err := ReadBook(out)
+	if err != nil {
+		t.Fatalf("ReadBook back: %v", err)
+	}
+	check("after write", back)
+}
+
+func TestReadBook_NoTitle(t *testing.T) {
+	zipPath := filepath.Join(t.TempDir(), "untitled.osheet")
+	writeZip(t, zipPath, map[string][]byte{"document.json": []byte(`{"properties":{"custom":{"b":1,"a":"x"}},"sheets":[{"name":"S","rows":[["a"]]}]}`)})
+	book, err := ReadBook(zipPath)
+	if err != nil {
+		t.Fatalf("ReadBook: %v", err)
+	}
+	if book.Title != "" {
+		t.Errorf("title = %q, want empty", book.Title)
+	}
+	want := []CustomProperty{{Name: "a", Value: "x"}, {Name: "b", Value: 1.0}}
+	if !reflect.DeepEqual(book.Properties.Custom, want) {
+		t.Errorf("custom = %+v, want %+v", book.Properties.Custom, want)
+	}
+}
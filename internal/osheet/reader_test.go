@@ -1,18 +1,89 @@
 package osheet
 
-import "testing"
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"strings"
+	"testing"
+)
 
 func TestInferCell(t *testing.T) {
-	if c := inferCell("true"); c.Type != ValueBool || !c.BoolValue {
+	if c := inferCell("true", ReadOptions{}); c.Type != ValueBool || !c.BoolValue {
 		t.Fatalf("bool true failed")
 	}
-	if c := inferCell("12"); c.Type != ValueNumber || c.NumberValue != 12 {
+	if c := inferCell("12", ReadOptions{}); c.Type != ValueNumber || c.NumberValue != 12 {
 		t.Fatalf("number failed")
 	}
-	if c := inferCell("2024-01-02"); c.Type != ValueDateTime {
+	if c := inferCell("2024-01-02", ReadOptions{}); c.Type != ValueDateTime {
 		t.Fatalf("date failed")
 	}
-	if c := inferCell("hello"); c.Type != ValueString || c.StringValue != "hello" {
+	if c := inferCell("hello", ReadOptions{}); c.Type != ValueString || c.StringValue != "hello" {
 		t.Fatalf("string failed")
 	}
 }
+
+// testPNG encodes a blank w x h PNG.
+func testPNG(t *testing.T, w, h int) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, w, h))); err != nil {
+		t.Fatalf("png: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func TestCheckImage(t *testing.T) {
+	var jpg, gf bytes.Buffer
+	if err := jpeg.Encode(&jpg, image.NewRGBA(image.Rect(0, 0, 3, 2)), nil); err != nil {
+		t.Fatalf("jpeg: %v", err)
+	}
+	if err := gif.Encode(&gf, image.NewPaletted(image.Rect(0, 0, 1, 1), color.Palette{color.Black}), nil); err != nil {
+		t.Fatalf("gif: %v", err)
+	}
+	for _, tc := range []struct {
+		data []byte
+		ext  string
+		w, h int
+	}{{testPNG(t, 4, 5), ".png", 4, 5}, {jpg.Bytes(), ".jpg", 3, 2}, {gf.Bytes(), ".gif", 1, 1}} {
+		ext, w, h, err := CheckImage(tc.data)
+		if err != nil || ext != tc.ext || w != tc.w || h != tc.h {
+			t.Errorf("CheckImage(%s) = %q %dx%d, %v", tc.ext, ext, w, h, err)
+		}
+	}
+
+	// A PNG signature followed by junk must not pass
+	truncated := testPNG(t, 1, 1)[:12]
+	oversized := append(testPNG(t, 1, 1), make([]byte, MaxImageSize)...)
+	for name, data := range map[string][]byte{
+		"empty":     nil,
+		"html":      []byte("<html><script>alert(1)</script></html>"),
+		"truncated": truncated,
+		"oversized": oversized,
+	} {
+		if _, _, _, err := CheckImage(data); err == nil {
+			t.Errorf("CheckImage(%s) accepted", name)
+		}
+	}
+}
+
+func TestPasswordForHash(t *testing.T) {
+	if got := HashPassword("abcdefghij"); got != "FEF1" {
+		t.Fatalf("HashPassword = %s, want FEF1", got)
+	}
+	for _, hash := range []string{"FEF1", "CE4B", "dAa7", "8000", "FFFF"} {
+		password, ok := PasswordForHash(hash)
+		if !ok || !strings.EqualFold(HashPassword(password), hash) {
+			t.Errorf("PasswordForHash(%s) = %q, %v", hash, password, ok)
+		}
+	}
+	// The hash always has its top bit set
+	for _, hash := range []string{"1234", "", "XYZ", "12345"} {
+		if password, ok := PasswordForHash(hash); ok {
+			t.Errorf("PasswordForHash(%q) = %q, want failure", hash, password)
+		}
+	}
+}
@@ -0,0 +1,523 @@
+package osheet
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+)
+
+// ErrRowOrder is returned by a SheetReader whose source stores rows out of
+// ascending order. Callers that need ordered rows can fall back to ReadBookUniversal.
+var ErrRowOrder = errors.New("rows are not stored in ascending order")
+
+// SheetReader yields the rows of a single sheet one at a time without
+// materialising the whole cell matrix. Rows come in ascending order and
+// rows absent from the source may be skipped:
+//
+//	for r.Next() {
+//		index, cells := r.Row()
+//		...
+//	}
+//	if err := r.Err(); err != nil { ... }
+type SheetReader interface {
+	// Next advances to the next row; it returns false at the end or on error.
+	Next() bool
+	// Row returns the 1-based row index and the cells of the current row.
+	// The slice is only valid until the next call to Next.
+	Row() (int, []Cell)
+	// Err returns the first error encountered during iteration.
+	Err() error
+	// Close releases resources held by the reader.
+	Close() error
+}
+
+// BookReader gives access to a workbook's metadata and opens a SheetReader per sheet.
+type BookReader struct {
+	meta   Book
+	open   func(i int) (SheetReader, error)
+	closer io.Closer
+}
+
+// OpenBookReader detects the format of path and prepares row-by-row reading.
+// Sources that cannot be streamed (e.g. sheets/*.json fallbacks) are parsed in
+// memory and served through the same API.
+func OpenBookReader(path string) (*BookReader, error) {
+	return OpenBookReaderWithOptions(path, ReadOptions{})
+}
+
+// OpenBookReaderWithOptions is OpenBookReader with options.
+func OpenBookReaderWithOptions(path string, opts ReadOptions) (*BookReader, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to detect format: %w", err)
+	}
+	isZIP := isZIPFormat(file)
+	if err := file.Close(); err != nil {
+		return nil, err
+	}
+	if isZIP {
+		return openZipBookReader(path, opts)
+	}
+	return openBinaryBookReader(path, opts)
+}
+
+// Book returns the workbook metadata. Sheets carry everything except cells,
+// so Width and Height are zero.
+func (b *BookReader) Book() *Book {
+	return &b.meta
+}
+
+// OpenSheet returns a reader over the rows of the i-th sheet.
+func (b *BookReader) OpenSheet(i int) (SheetReader, error) {
+	if i < 0 || i >= len(b.meta.Sheets) {
+		return nil, fmt.Errorf("sheet index %d out of range", i)
+	}
+	return b.open(i)
+}
+
+// Close releases the underlying file.
+func (b *BookReader) Close() error {
+	if b.closer == nil {
+		return nil
+	}
+	return b.closer.Close()
+}
+
+// newMemoryBookReader serves a fully parsed book through the BookReader API.
+func newMemoryBookReader(book *Book) *BookReader {
+	meta := *book
+	meta.Sheets = make([]Sheet, len(book.Sheets))
+	for i := range book.Sheets {
+		meta.Sheets[i] = book.Sheets[i]
+		meta.Sheets[i].Cells = nil
+		meta.Sheets[i].Width = 0
+		meta.Sheets[i].Height = 0
+	}
+	return &BookReader{
+		meta: meta,
+		open: func(i int) (SheetReader, error) {
+			return NewSheetReader(&book.Sheets[i]), nil
+		},
+	}
+}
+
+// NewSheetReader iterates the rows of an in-memory sheet.
+func NewSheetReader(s *Sheet) SheetReader {
+	return &matrixSheetReader{cells: s.Cells}
+}
+
+type matrixSheetReader struct {
+	cells [][]Cell
+	row   int
+}
+
+func (r *matrixSheetReader) Next() bool {
+	if r.row >= len(r.cells) {
+		return false
+	}
+	r.row++
+	return true
+}
+
+func (r *matrixSheetReader) Row() (int, []Cell) {
+	if r.row == 0 {
+		return 0, nil
+	}
+	return r.row, r.cells[r.row-1]
+}
+
+func (r *matrixSheetReader) Err() error   { return nil }
+func (r *matrixSheetReader) Close() error { return nil }
+
+// zipSheetMeta locates a streamable sheet inside document.json.
+type zipSheetMeta struct {
+	index   int    // position in the "sheets" array
+	payload string // "rows", "cells" or "" when the sheet has none
+	sheet   Sheet
+	styles  styleTable
+}
+
+// openZipBookReader streams document.json sheets with a token decoder.
+// Without a streamable document.json it falls back to ReadBook.
+func openZipBookReader(path string, opts ReadOptions) (*BookReader, error) {
+	zr, err := zip.OpenReader(path)
+	if err != nil {
+		return nil, err
+	}
+	doc := findDocumentJSON(zr.File)
+	var (
+		metas   []zipSheetMeta
+		docMeta Book
+	)
+	if doc != nil {
+		if rc, openErr := doc.Open(); openErr == nil {
+			docMeta, metas, err = scanDocumentMeta(rc, opts)
+			_ = rc.Close()
+			if err != nil {
+				metas = nil
+			}
+		}
+	}
+	if len(metas) == 0 {
+		_ = zr.Close()
+		book, err := ReadBookWithOptions(path, opts)
+		if err != nil {
+			return nil, err
+		}
+		return newMemoryBookReader(book), nil
+	}
+
+	meta := Book{
+		Title:        docMeta.Title,
+		DefinedNames: docMeta.DefinedNames,
+		Protection:   docMeta.Protection,
+		Properties:   docMeta.Properties,
+	}
+	for i := range metas {
+		meta.Sheets = append(meta.Sheets, metas[i].sheet)
+	}
+	loadImages(zr.File, doc, meta.Sheets)
+	return &BookReader{
+		meta: meta,
+		open: func(i int) (SheetReader, error) {
+			return openZipSheetReader(doc, metas[i], opts)
+		},
+		closer: zr,
+	}, nil
+}
+
+// scanDocumentMeta walks document.json once, skipping cell payloads, and
+// returns the document title, defined names, protection and properties (as
+// a Book without sheets) and the metadata of every sheet that
+// parseDocumentSheet would keep.
+func scanDocumentMeta(r io.Reader, opts ReadOptions) (Book, []zipSheetMeta, error) {
+	var doc Book
+	dec := json.NewDecoder(r)
+	if err := expectDelim(dec, '{'); err != nil {
+		return doc, nil, err
+	}
+	type scanned struct {
+		fields  map[string]json.RawMessage
+		payload string
+	}
+	var (
+		docStyles json.RawMessage
+		docNames  interface{}
+		docProt   interface{}
+		docProps  interface{}
+		sheets    []scanned
+	)
+	for dec.More() {
+		key, err := nextKey(dec)
+		if err != nil {
+			return doc, nil, err
+		}
+		switch key {
+		case "sheets":
+			if err := expectDelim(dec, '['); err != nil {
+				return doc, nil, err
+			}
+			for dec.More() {
+				fields, payload, err := scanSheetObject(dec)
+				if err != nil {
+					return doc, nil, err
+				}
+				sheets = append(sheets, scanned{fields: fields, payload: payload})
+			}
+			if err := expectDelim(dec, ']'); err != nil {
+				return doc, nil, err
+			}
+		case "title":
+			if err := dec.Decode(&doc.Title); err != nil {
+				return doc, nil, err
+			}
+		case "definedNames", "names":
+			var names interface{}
+			if err := dec.Decode(&names); err != nil {
+				return doc, nil, err
+			}
+			if docNames == nil || key == "definedNames" {
+				docNames = names
+			}
+		case "protection":
+			if err := dec.Decode(&docProt); err != nil {
+				return doc, nil, err
+			}
+		case "properties":
+			if err := dec.Decode(&docProps); err != nil {
+				return doc, nil, err
+			}
+		case "styles":
+			if err := dec.Decode(&docStyles); err != nil {
+				return doc, nil, err
+			}
+		default:
+			if err := skipValue(dec); err != nil {
+				return doc, nil, err
+			}
+		}
+	}
+
+	docTable := parseStyleTableJSON(docStyles)
+	var out []zipSheetMeta
+	for i, sc := range sheets {
+		raw, err := json.Marshal(sc.fields)
+		if err != nil {
+			return doc, nil, err
+		}
+		if sc.payload == "" {
+			// Sheets without rows are kept when parseDocumentSheet keeps them,
+			// e.g. V1 sheets carrying only cols or merges
+			if sh, ok := parseDocumentSheet(raw, docTable, opts); ok {
+				sh.Cells = nil
+				out = append(out, zipSheetMeta{index: i, sheet: sh})
+			}
+			continue
+		}
+		var m sheetMetaJSON
+		if err := json.Unmarshal(raw, &m); err != nil {
+			continue
+		}
+		out = append(out, zipSheetMeta{index: i, payload: sc.payload, sheet: m.sheet(), styles: m.styleTable(docTable)})
+	}
+	doc.DefinedNames = parseDefinedNames(docNames)
+	doc.Protection = parseWorkbookProtection(docProt)
+	doc.Title, doc.Properties = parseDocProperties(doc.Title, docProps)
+	return doc, out, nil
+}
+
+// scanSheetObject reads one element of the "sheets" array. It returns the
+// non-payload fields and which payload ("cells" wins over "rows", matching
+// parseDocumentSheet) holds at least one row.
+func scanSheetObject(dec *json.Decoder) (map[string]json.RawMessage, string, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, "", err
+	}
+	if d, ok := tok.(json.Delim); !ok || d != '{' {
+		return nil, "", skipRest(dec, tok)
+	}
+	fields := make(map[string]json.RawMessage)
+	var hasRows, hasCells bool
+	for dec.More() {
+		key, err := nextKey(dec)
+		if err != nil {
+			return nil, "", err
+		}
+		if key == "rows" || key == "cells" {
+			n, err := skipArray(dec)
+			if err != nil {
+				return nil, "", err
+			}
+			if key == "rows" {
+				hasRows = n > 0
+			} else {
+				hasCells = n > 0
+			}
+			continue
+		}
+		var raw json.RawMessage
+		if err := dec.Decode(&raw); err != nil {
+			return nil, "", err
+		}
+		fields[key] = raw
+	}
+	if err := expectDelim(dec, '}'); err != nil {
+		return nil, "", err
+	}
+	switch {
+	case hasCells:
+		return fields, "cells", nil
+	case hasRows:
+		return fields, "rows", nil
+	default:
+		return fields, "", nil
+	}
+}
+
+type zipSheetReader struct {
+	rc      io.ReadCloser
+	dec     *json.Decoder
+	payload string
+	styles  styleTable
+	opts    ReadOptions
+	row     int
+	cells   []Cell
+	err     error
+	done    bool
+}
+
+// openZipSheetReader positions a decoder at the payload array of one sheet.
+// A sheet without payload yields no rows.
+func openZipSheetReader(doc *zip.File, meta zipSheetMeta, opts ReadOptions) (SheetReader, error) {
+	if meta.payload == "" {
+		return NewSheetReader(&meta.sheet), nil
+	}
+	rc, err := doc.Open()
+	if err != nil {
+		return nil, err
+	}
+	r := &zipSheetReader{rc: rc, dec: json.NewDecoder(rc), payload: meta.payload, styles: meta.styles, opts: opts}
+	if err := r.seek(meta.index); err != nil {
+		_ = rc.Close()
+		return nil, fmt.Errorf("failed to locate sheet %q: %w", meta.sheet.Name, err)
+	}
+	return r, nil
+}
+
+// seek advances the decoder to just inside sheets[index].<payload>.
+func (r *zipSheetReader) seek(index int) error {
+	dec := r.dec
+	if err := expectDelim(dec, '{'); err != nil {
+		return err
+	}
+	for dec.More() {
+		key, err := nextKey(dec)
+		if err != nil {
+			return err
+		}
+		if key != "sheets" {
+			if err := skipValue(dec); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := expectDelim(dec, '['); err != nil {
+			return err
+		}
+		for i := 0; i < index; i++ {
+			if err := skipValue(dec); err != nil {
+				return err
+			}
+		}
+		if err := expectDelim(dec, '{'); err != nil {
+			return err
+		}
+		for dec.More() {
+			key, err := nextKey(dec)
+			if err != nil {
+				return err
+			}
+			if key == r.payload {
+				return expectDelim(dec, '[')
+			}
+			if err := skipValue(dec); err != nil {
+				return err
+			}
+		}
+		break
+	}
+	return fmt.Errorf("no %q payload", r.payload)
+}
+
+func (r *zipSheetReader) Next() bool {
+	if r.done || r.err != nil {
+		return false
+	}
+	if !r.dec.More() {
+		r.done = true
+		return false
+	}
+	var raw []interface{}
+	if err := r.dec.Decode(&raw); err != nil {
+		r.err = err
+		return false
+	}
+	r.row++
+	if r.payload == "rows" {
+		strs := make([]string, len(raw))
+		for c := 0; c < len(raw); c++ {
+			strs[c] = anyToString(raw[c])
+		}
+		r.cells = rowFromStrings(strs, r.opts)
+		return true
+	}
+	r.cells = make([]Cell, len(raw))
+	for c := 0; c < len(raw); c++ {
+		r.cells[c] = parseAnyCell(raw[c], r.styles, r.opts)
+	}
+	return true
+}
+
+func (r *zipSheetReader) Row() (int, []Cell) { return r.row, r.cells }
+func (r *zipSheetReader) Err() error         { return r.err }
+func (r *zipSheetReader) Close() error       { return r.rc.Close() }
+
+// expectDelim consumes the next token and checks it is the given delimiter.
+func expectDelim(dec *json.Decoder, want json.Delim) error {
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	if d, ok := tok.(json.Delim); !ok || d != want {
+		return fmt.Errorf("expected %q, got %v", want, tok)
+	}
+	return nil
+}
+
+// nextKey reads an object key.
+func nextKey(dec *json.Decoder) (string, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return "", err
+	}
+	key, ok := tok.(string)
+	if !ok {
+		return "", fmt.Errorf("expected object key, got %v", tok)
+	}
+	return key, nil
+}
+
+// skipValue consumes the next JSON value without retaining it.
+func skipValue(dec *json.Decoder) error {
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	return skipRest(dec, tok)
+}
+
+// skipRest consumes the remainder of a value whose first token was already read.
+func skipRest(dec *json.Decoder, first json.Token) error {
+	d, ok := first.(json.Delim)
+	if !ok || d == '}' || d == ']' {
+		return nil
+	}
+	depth := 1
+	for depth > 0 {
+		tok, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		if d, ok := tok.(json.Delim); ok {
+			if d == '{' || d == '[' {
+				depth++
+			} else {
+				depth--
+			}
+		}
+	}
+	return nil
+}
+
+// skipArray consumes an array (or any other value) and returns its element count.
+func skipArray(dec *json.Decoder) (int, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return 0, err
+	}
+	if d, ok := tok.(json.Delim); !ok || d != '[' {
+		return 0, skipRest(dec, tok)
+	}
+	n := 0
+	for dec.More() {
+		if err := skipValue(dec); err != nil {
+			return n, err
+		}
+		n++
+	}
+	return n, expectDelim(dec, ']')
+}
@@ -0,0 +1,251 @@
+package osheet
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+)
+
+// binarySheetSection locates the text/sh_N payload of one binary sheet.
+type binarySheetSection struct {
+	offset int64 // offset of the opening brace, -1 when the sheet has no payload
+	styles map[int]styleEntry
+}
+
+// openBinaryBookReader indexes a binary .osheet without loading it into memory:
+// the gcVer header is decoded, each text/sh_N section is located, and the
+// non-cell keys of every section are read up front.
+func openBinaryBookReader(path string, opts ReadOptions) (*BookReader, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open file: %w", err)
+	}
+	br, err := indexBinaryBook(file, path, opts)
+	if err != nil {
+		_ = file.Close()
+		return nil, err
+	}
+	return br, nil
+}
+
+func indexBinaryBook(file *os.File, path string, opts ReadOptions) (*BookReader, error) {
+	st, err := file.Stat()
+	if err != nil {
+		return nil, err
+	}
+	size := st.Size()
+
+	headerAt := indexInFile(file, size, []byte(`{"gcVer"`), 0)
+	if headerAt == -1 {
+		return nil, errors.New("unsupported or unknown format")
+	}
+	var header map[string]interface{}
+	if err := json.NewDecoder(io.NewSectionReader(file, headerAt, size-headerAt)).Decode(&header); err != nil {
+		return nil, fmt.Errorf("failed to parse JSON: %w", err)
+	}
+	sheets, ok := header["sheets"].(map[string]interface{})
+	if !ok || len(sheets) == 0 {
+		return nil, fmt.Errorf("no sheets found in JSON")
+	}
+	headerStyles := parseStyleTable(header["styles"])
+
+	meta := Book{
+		DefinedNames: parseDefinedNames(firstPresent(header, "definedNames", "names")),
+		Protection:   parseWorkbookProtection(header["protection"]),
+	}
+	meta.Title, meta.Properties = parseDocProperties(toString(header["title"]), header["properties"])
+	var sections []binarySheetSection
+	found := 0
+	for _, entry := range orderBinarySheets(sheets) {
+		binary := BinarySheet{Title: entry.title, Styles: binaryStyles(headerStyles)}
+		section := binarySheetSection{offset: -1}
+		if at := findSectionInFile(file, size, "text/"+entry.id); at != -1 {
+			section.offset = indexInFile(file, size, []byte("{"), at)
+		}
+		if section.offset != -1 {
+			fields, err := scanBinarySheetMeta(io.NewSectionReader(file, section.offset, size-section.offset))
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse sheet JSON for %s: %w", entry.id, err)
+			}
+			applyBinarySheetJSON(&binary, fields, headerStyles)
+			found++
+		}
+		section.styles = binaryStyleEntries(binary.Styles)
+		meta.Sheets = append(meta.Sheets, binarySheetMeta(&binary))
+		sections = append(sections, section)
+	}
+	if found == 0 {
+		return nil, fmt.Errorf("no sheet data section found")
+	}
+
+	return &BookReader{
+		meta: meta,
+		open: func(i int) (SheetReader, error) {
+			section := sections[i]
+			if section.offset == -1 {
+				return NewSheetReader(&Sheet{}), nil
+			}
+			return openBinarySheetReader(io.NewSectionReader(file, section.offset, size-section.offset), section.styles, opts)
+		},
+		closer: file,
+	}, nil
+}
+
+// scanBinarySheetMeta decodes a text/sh_N object, skipping the "cells" map.
+func scanBinarySheetMeta(r io.Reader) (map[string]interface{}, error) {
+	dec := json.NewDecoder(r)
+	if err := expectDelim(dec, '{'); err != nil {
+		return nil, err
+	}
+	fields := make(map[string]interface{})
+	for dec.More() {
+		key, err := nextKey(dec)
+		if err != nil {
+			return nil, err
+		}
+		if key == "cells" {
+			if err := skipValue(dec); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		var v interface{}
+		if err := dec.Decode(&v); err != nil {
+			return nil, err
+		}
+		fields[key] = v
+	}
+	return fields, nil
+}
+
+type binarySheetReader struct {
+	dec    *json.Decoder
+	styles map[int]styleEntry
+	opts   ReadOptions
+	row    int
+	cells  []Cell
+	err    error
+	done   bool
+}
+
+// openBinarySheetReader positions a decoder inside the "cells" map of a section.
+func openBinarySheetReader(r io.Reader, styles map[int]styleEntry, opts ReadOptions) (SheetReader, error) {
+	dec := json.NewDecoder(r)
+	if err := expectDelim(dec, '{'); err != nil {
+		return nil, err
+	}
+	for dec.More() {
+		key, err := nextKey(dec)
+		if err != nil {
+			return nil, err
+		}
+		if key == "cells" {
+			if err := expectDelim(dec, '{'); err != nil {
+				return nil, err
+			}
+			return &binarySheetReader{dec: dec, styles: styles, opts: opts}, nil
+		}
+		if err := skipValue(dec); err != nil {
+			return nil, err
+		}
+	}
+	return nil, fmt.Errorf("no cells data found")
+}
+
+func (r *binarySheetReader) Next() bool {
+	for !r.done && r.err == nil {
+		if !r.dec.More() {
+			r.done = true
+			return false
+		}
+		key, err := nextKey(r.dec)
+		if err != nil {
+			r.err = err
+			return false
+		}
+		var rowMap map[string]interface{}
+		if err := r.dec.Decode(&rowMap); err != nil {
+			r.err = err
+			return false
+		}
+		rowIndex, err := strconv.Atoi(key)
+		if err != nil {
+			continue
+		}
+		if rowIndex+1 <= r.row {
+			r.err = ErrRowOrder
+			return false
+		}
+		r.row = rowIndex + 1
+		r.cells = binaryRowCells(parseBinaryRow(rowMap), r.styles, r.opts)
+		return true
+	}
+	return false
+}
+
+func (r *binarySheetReader) Row() (int, []Cell) { return r.row, r.cells }
+func (r *binarySheetReader) Err() error         { return r.err }
+func (r *binarySheetReader) Close() error       { return nil }
+
+// binaryRowCells converts a binary row into a dense slice up to its last column.
+func binaryRowCells(row map[string]CellData, styles map[int]styleEntry, opts ReadOptions) []Cell {
+	width := 0
+	for colKey := range row {
+		if colIndex, err := strconv.Atoi(colKey); err == nil && colIndex+1 > width {
+			width = colIndex + 1
+		}
+	}
+	cells := make([]Cell, width)
+	for colKey, data := range row {
+		colIndex, err := strconv.Atoi(colKey)
+		if err != nil || colIndex < 0 {
+			continue
+		}
+		cells[colIndex] = binaryCell(data, styles, opts)
+	}
+	return cells
+}
+
+// indexInFile returns the offset of the first occurrence of pattern at or
+// after from, reading the file in chunks. It returns -1 when not found.
+func indexInFile(r io.ReaderAt, size int64, pattern []byte, from int64) int64 {
+	const chunk = 64 * 1024
+	buf := make([]byte, chunk+len(pattern)-1)
+	for off := from; off < size; off += chunk {
+		n, err := r.ReadAt(buf, off)
+		if n <= 0 {
+			return -1
+		}
+		if i := bytes.Index(buf[:n], pattern); i >= 0 {
+			return off + int64(i)
+		}
+		if err != nil {
+			return -1
+		}
+	}
+	return -1
+}
+
+// findSectionInFile is the streaming counterpart of findSection.
+func findSectionInFile(r io.ReaderAt, size int64, name string) int64 {
+	from := int64(0)
+	next := make([]byte, 1)
+	for {
+		at := indexInFile(r, size, []byte(name), from)
+		if at == -1 {
+			return -1
+		}
+		end := at + int64(len(name))
+		if end >= size {
+			return at
+		}
+		if _, err := r.ReadAt(next, end); err != nil || !isSectionNameChar(next[0]) {
+			return at
+		}
+		from = end
+	}
+}
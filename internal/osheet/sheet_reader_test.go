@@ -0,0 +1,193 @@
+package osheet
+
+import (
+	"encoding/json"
+	"errors"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// readAllRows drains every sheet of br into dense matrices keyed by 1-based row index.
+func readAllRows(t *testing.T, br *BookReader) [][][]Cell {
+	t.Helper()
+	var out [][][]Cell
+	for i := range br.Book().Sheets {
+		r, err := br.OpenSheet(i)
+		if err != nil {
+			t.Fatalf("OpenSheet(%d): %v", i, err)
+		}
+		var rows [][]Cell
+		for r.Next() {
+			idx, cells := r.Row()
+			for len(rows) < idx {
+				rows = append(rows, nil)
+			}
+			rows[idx-1] = append([]Cell(nil), cells...)
+		}
+		if err := r.Err(); err != nil {
+			t.Fatalf("sheet %d: %v", i, err)
+		}
+		if err := r.Close(); err != nil {
+			t.Fatalf("close sheet %d: %v", i, err)
+		}
+		out = append(out, rows)
+	}
+	return out
+}
+
+func TestOpenBookReader_DocumentJSONMatchesReadBook(t *testing.T) {
+	// Metadata placed after the payload must still be available before iteration.
+	doc := `{"sheets":[
+		{"name":"Typed","cells":[[{"t":"n","v":1},{"v":"x","s":0}],[{"f":"SUM(A1:B1)"},"02.01.2024"]],
+		 "cols":[{"Index":1,"Width":20}],"merges":[[1,1,1,2]],"rowHeights":[{"Index":2,"Height":30}]},
+		{"name":"Plain","rows":[["1","2"],["3","hello"]]},
+		{"name":"Empty","cells":[]},
+		{"name":"Layout","cols":[{"Index":1,"Width":12}],"merges":[{"SR":1,"SC":1,"ER":2,"EC":2}]}
+	],"styles":[{"font":{"bold":true}}]}`
+	zipPath := filepath.Join(t.TempDir(), "doc.osheet")
+	writeZip(t, zipPath, map[string][]byte{"document.json": []byte(doc)})
+
+	want, err := ReadBook(zipPath)
+	if err != nil {
+		t.Fatalf("ReadBook: %v", err)
+	}
+	br, err := OpenBookReader(zipPath)
+	if err != nil {
+		t.Fatalf("OpenBookReader: %v", err)
+	}
+	defer br.Close()
+
+	meta := br.Book()
+	if len(meta.Sheets) != len(want.Sheets) {
+		t.Fatalf("sheets = %d, want %d", len(meta.Sheets), len(want.Sheets))
+	}
+	for i := range want.Sheets {
+		w := want.Sheets[i]
+		got := meta.Sheets[i]
+		if got.Name != w.Name || !reflect.DeepEqual(got.Cols, w.Cols) || !reflect.DeepEqual(got.Merges, w.Merges) || !reflect.DeepEqual(got.Rows, w.Rows) {
+			t.Errorf("sheet %d meta = %+v, want %+v", i, got, w)
+		}
+		if got.Cells != nil {
+			t.Errorf("sheet %d metadata should not carry cells", i)
+		}
+	}
+	rows := readAllRows(t, br)
+	for i := range want.Sheets {
+		if len(rows[i]) == 0 && len(want.Sheets[i].Cells) == 0 {
+			continue
+		}
+		if !reflect.DeepEqual(rows[i], want.Sheets[i].Cells) {
+			t.Errorf("sheet %d rows = %+v, want %+v", i, rows[i], want.Sheets[i].Cells)
+		}
+	}
+}
+
+func TestOpenBookReader_FallbackInMemory(t *testing.T) {
+	zipPath := filepath.Join(t.TempDir(), "sheets.osheet")
+	b, err := json.Marshal(map[string]interface{}{"name": "S", "rows": [][]string{{"a"}}})
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	writeZip(t, zipPath, map[string][]byte{"sheets/s.json": b})
+
+	br, err := OpenBookReader(zipPath)
+	if err != nil {
+		t.Fatalf("OpenBookReader: %v", err)
+	}
+	defer br.Close()
+	rows := readAllRows(t, br)
+	if len(rows) != 1 || len(rows[0]) != 1 || rows[0][0][0].StringValue != "a" {
+		t.Fatalf("unexpected rows: %+v", rows)
+	}
+}
+
+func TestOpenBookReader_BinaryMatchesReadBinaryBook(t *testing.T) {
+	header := `{"gcVer":1,"properties":{"title":"Stock","author":"Ann"},"styles":{"1":{"font":{"bold":true}}},"sheets":{"sh_1":{"title":"A"},"sh_2":{"title":"B"}}}`
+	sections := map[string]string{
+		"sh_1": `{"cells":{"0":{"0":{"v":"1","s":1},"2":{"v":"x"}},"3":{"1":{"v":"12%"}}},"cols":{"1":{"w":12}}}`,
+		"sh_2": `{"cols":{"0":{"w":9}},"cells":{"0":{"0":{"v":"b"}}}}`,
+	}
+	path := writeBinaryFixture(t, header, sections, []string{"sh_1", "sh_2"})
+
+	want, err := ReadBinaryBook(path)
+	if err != nil {
+		t.Fatalf("ReadBinaryBook: %v", err)
+	}
+	br, err := OpenBookReader(path)
+	if err != nil {
+		t.Fatalf("OpenBookReader: %v", err)
+	}
+	defer br.Close()
+
+	if got := br.Book(); got.Title != "Stock" || !reflect.DeepEqual(got.Properties, want.Properties) || want.Properties.Author != "Ann" {
+		t.Errorf("book = %q %+v, want Stock %+v", got.Title, got.Properties, want.Properties)
+	}
+	rows := readAllRows(t, br)
+	for i := range want.Sheets {
+		if br.Book().Sheets[i].Name != want.Sheets[i].Name {
+			t.Errorf("sheet %d name = %q, want %q", i, br.Book().Sheets[i].Name, want.Sheets[i].Name)
+		}
+		if !reflect.DeepEqual(br.Book().Sheets[i].Cols, want.Sheets[i].Cols) {
+			t.Errorf("sheet %d cols = %+v, want %+v", i, br.Book().Sheets[i].Cols, want.Sheets[i].Cols)
+		}
+		// The dense matrix pads rows to the sheet width; compare populated cells only.
+		for r, row := range rows[i] {
+			for c, cell := range row {
+				if !reflect.DeepEqual(cell, want.Sheets[i].Cells[r][c]) {
+					t.Errorf("sheet %d cell %d,%d = %+v, want %+v", i, r, c, cell, want.Sheets[i].Cells[r][c])
+				}
+			}
+		}
+	}
+	if len(rows[0]) != 4 || rows[0][1] != nil {
+		t.Errorf("missing rows should be skipped, got %d rows", len(rows[0]))
+	}
+}
+
+func TestOpenBookReader_BinaryWrittenRowOrder(t *testing.T) {
+	in := &Book{Sheets: []Sheet{{Name: "Long"}}}
+	for r := 0; r < 25; r++ {
+		in.Sheets[0].Cells = append(in.Sheets[0].Cells, []Cell{{Type: ValueNumber, NumberValue: float64(r + 1)}})
+	}
+	path := filepath.Join(t.TempDir(), "long.osheet")
+	if err := WriteBinaryBook(in, path); err != nil {
+		t.Fatalf("WriteBinaryBook: %v", err)
+	}
+	br, err := OpenBookReader(path)
+	if err != nil {
+		t.Fatalf("OpenBookReader: %v", err)
+	}
+	defer br.Close()
+	// readAllRows fails on ErrRowOrder
+	rows := readAllRows(t, br)[0]
+	if len(rows) != 25 {
+		t.Fatalf("rows = %d, want 25", len(rows))
+	}
+	for r, row := range rows {
+		if len(row) != 1 || row[0].NumberValue != float64(r+1) {
+			t.Errorf("row %d = %+v", r+1, row)
+		}
+	}
+}
+
+func TestOpenBookReader_BinaryRowOrder(t *testing.T) {
+	header := `{"gcVer":1,"sheets":{"sh_1":{"title":"A"}}}`
+	sections := map[string]string{"sh_1": `{"cells":{"2":{"0":{"v":"late"}},"0":{"0":{"v":"early"}}}}`}
+	path := writeBinaryFixture(t, header, sections, []string{"sh_1"})
+
+	br, err := OpenBookReader(path)
+	if err != nil {
+		t.Fatalf("OpenBookReader: %v", err)
+	}
+	defer br.Close()
+	r, err := br.OpenSheet(0)
+	if err != nil {
+		t.Fatalf("OpenSheet: %v", err)
+	}
+	for r.Next() {
+	}
+	if !errors.Is(r.Err(), ErrRowOrder) {
+		t.Fatalf("Err = %v, want ErrRowOrder", r.Err())
+	}
+}
@@ -0,0 +1,387 @@
+package osheet
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// styleTable maps style ids from a source styles table to parsed styles.
+type styleTable map[string]styleEntry
+
+// styleEntry is a resolved style reference: visual style plus number format code.
+type styleEntry struct {
+	style  *Style
+	numFmt string
+}
+
+// parseStyleTable accepts either an array of style objects (ids are indexes)
+// or an object keyed by style id.
+func parseStyleTable(raw interface{}) styleTable {
+	out := styleTable{}
+	switch t := raw.(type) {
+	case []interface{}:
+		for i, item := range t {
+			if entry, ok := parseStyleEntry(item); ok {
+				out[strconv.Itoa(i)] = entry
+			}
+		}
+	case map[string]interface{}:
+		for id, item := range t {
+			if entry, ok := parseStyleEntry(item); ok {
+				out[id] = entry
+			}
+		}
+	}
+	return out
+}
+
+// parseStyleTableJSON decodes a raw styles table; invalid input yields an empty table.
+func parseStyleTableJSON(raw json.RawMessage) styleTable {
+	if len(raw) == 0 {
+		return styleTable{}
+	}
+	var v interface{}
+	if json.Unmarshal(raw, &v) != nil {
+		return styleTable{}
+	}
+	return parseStyleTable(v)
+}
+
+// merge returns a table with entries of other overriding entries of t.
+func (t styleTable) merge(other styleTable) styleTable {
+	out := make(styleTable, len(t)+len(other))
+	for k, v := range t {
+		out[k] = v
+	}
+	for k, v := range other {
+		out[k] = v
+	}
+	return out
+}
+
+// resolve maps a cell style reference to a style: either an inline style
+// object or an id (string or number) into the table.
+func (t styleTable) resolve(ref interface{}) styleEntry {
+	switch r := ref.(type) {
+	case map[string]interface{}:
+		entry, _ := parseStyleEntry(r)
+		return entry
+	case float64:
+		return t[strconv.Itoa(int(r))]
+	case string:
+		return t[r]
+	default:
+		return styleEntry{}
+	}
+}
+
+// parseStyleEntry parses a style object together with its optional
+// number format code (numFmt).
+func parseStyleEntry(v interface{}) (styleEntry, bool) {
+	m, ok := v.(map[string]interface{})
+	if !ok {
+		return styleEntry{}, false
+	}
+	entry := styleEntry{numFmt: toString(m["numFmt"])}
+	entry.style, _ = parseStyle(m)
+	return entry, entry.style != nil || entry.numFmt != ""
+}
+
+// parseStyle parses a style object with the keys Synology Office writes:
+// font{family,size,bold,italic,underline,color}, fill (a color or
+// {"color":...}), border (a style for all edges or {left,right,top,bottom},
+// each a style or {"style","color"}), align{horizontal,vertical,wrap,indent}
+// and locked/hidden, flat or under protection.
+func parseStyle(v interface{}) (*Style, bool) {
+	m, ok := v.(map[string]interface{})
+	if !ok {
+		return nil, false
+	}
+	var st Style
+	if font, ok := m["font"].(map[string]interface{}); ok {
+		st.Font = parseFont(font)
+	}
+	switch fill := m["fill"].(type) {
+	case string:
+		st.Fill = normalizeColor(fill)
+	case map[string]interface{}:
+		st.Fill = normalizeColor(toString(fill["color"]))
+	}
+	switch border := m["border"].(type) {
+	case string:
+		side := BorderSide{Style: normalizeBorderStyle(border)}
+		st.Border = Border{Left: side, Right: side, Top: side, Bottom: side}
+	case map[string]interface{}:
+		st.Border = Border{
+			Left:   parseBorderSide(border["left"]),
+			Right:  parseBorderSide(border["right"]),
+			Top:    parseBorderSide(border["top"]),
+			Bottom: parseBorderSide(border["bottom"]),
+		}
+	}
+	if align, ok := m["align"].(map[string]interface{}); ok {
+		st.Alignment = Alignment{
+			Horizontal: normalizeHorizontal(toString(align["horizontal"])),
+			Vertical:   normalizeVertical(toString(align["vertical"])),
+			Wrap:       toBool(align["wrap"]),
+			Indent:     toInt(align["indent"]),
+		}
+	}
+	st.Protection = parseCellProtection(m)
+	if st == (Style{}) {
+		return nil, false
+	}
+	return &st, true
+}
+
+// parseFont reads the font keys of parseStyle from a font object.
+func parseFont(font map[string]interface{}) Font {
+	return Font{
+		Family:    toString(font["family"]),
+		Size:      toFloat(font["size"]),
+		Bold:      toBool(font["bold"]),
+		Italic:    toBool(font["italic"]),
+		Underline: toBool(font["underline"]),
+		Color:     normalizeColor(toString(font["color"])),
+	}
+}
+
+// parseCellProtection reads the protection keys of parseStyle from a nested
+// "protection" object or from m itself.
+func parseCellProtection(m map[string]interface{}) CellProtection {
+	prot := m
+	if p, ok := m["protection"].(map[string]interface{}); ok {
+		prot = p
+	}
+	var out CellProtection
+	if locked, ok := prot["locked"]; ok {
+		out.Unlocked = !toBool(locked)
+	}
+	if toBool(prot["unlocked"]) {
+		out.Unlocked = true
+	}
+	out.HideFormula = toBool(firstPresent(prot, "hidden", "hideFormula"))
+	return out
+}
+
+func parseBorderSide(v interface{}) BorderSide {
+	switch t := v.(type) {
+	case string:
+		return BorderSide{Style: normalizeBorderStyle(t)}
+	case map[string]interface{}:
+		side := BorderSide{
+			Style: normalizeBorderStyle(toString(t["style"])),
+			Color: normalizeColor(toString(t["color"])),
+		}
+		if side.Style == "" && side.Color != "" {
+			side.Style = "thin"
+		}
+		return side
+	default:
+		return BorderSide{}
+	}
+}
+
+// styleToMap serialises a style into the long-key object form accepted by parseStyleEntry.
+func styleToMap(st *Style, numFmt string) map[string]interface{} {
+	out := map[string]interface{}{}
+	if numFmt != "" {
+		out["numFmt"] = numFmt
+	}
+	if st == nil {
+		return out
+	}
+	font := map[string]interface{}{}
+	if st.Font.Family != "" {
+		font["family"] = st.Font.Family
+	}
+	if st.Font.Size > 0 {
+		font["size"] = st.Font.Size
+	}
+	if st.Font.Bold {
+		font["bold"] = true
+	}
+	if st.Font.Italic {
+		font["italic"] = true
+	}
+	if st.Font.Underline {
+		font["underline"] = true
+	}
+	if st.Font.Color != "" {
+		font["color"] = "#" + st.Font.Color
+	}
+	if len(font) > 0 {
+		out["font"] = font
+	}
+	if st.Fill != "" {
+		out["fill"] = "#" + st.Fill
+	}
+	border := map[string]interface{}{}
+	sides := []struct {
+		name string
+		side BorderSide
+	}{{"left", st.Border.Left}, {"right", st.Border.Right}, {"top", st.Border.Top}, {"bottom", st.Border.Bottom}}
+	for _, s := range sides {
+		if s.side.Style == "" {
+			continue
+		}
+		side := map[string]interface{}{"style": s.side.Style}
+		if s.side.Color != "" {
+			side["color"] = "#" + s.side.Color
+		}
+		border[s.name] = side
+	}
+	if len(border) > 0 {
+		out["border"] = border
+	}
+	align := map[string]interface{}{}
+	if st.Alignment.Horizontal != "" {
+		align["horizontal"] = st.Alignment.Horizontal
+	}
+	if st.Alignment.Vertical != "" {
+		align["vertical"] = st.Alignment.Vertical
+	}
+	if st.Alignment.Wrap {
+		align["wrap"] = true
+	}
+	if st.Alignment.Indent > 0 {
+		align["indent"] = st.Alignment.Indent
+	}
+	if len(align) > 0 {
+		out["align"] = align
+	}
+	prot := map[string]interface{}{}
+	if st.Protection.Unlocked {
+		prot["locked"] = false
+	}
+	if st.Protection.HideFormula {
+		prot["hidden"] = true
+	}
+	if len(prot) > 0 {
+		out["protection"] = prot
+	}
+	return out
+}
+
+// normalizeColor converts #RGB, #RRGGBB, #AARRGGBB and rgb(r,g,b) into uppercase RRGGBB.
+func normalizeColor(in string) string {
+	s := strings.TrimSpace(in)
+	if s == "" {
+		return ""
+	}
+	lower := strings.ToLower(s)
+	if strings.HasPrefix(lower, "rgb(") && strings.HasSuffix(lower, ")") {
+		parts := strings.Split(lower[4:len(lower)-1], ",")
+		if len(parts) != 3 {
+			return ""
+		}
+		var out string
+		for _, p := range parts {
+			n, err := strconv.Atoi(strings.TrimSpace(p))
+			if err != nil || n < 0 || n > 255 {
+				return ""
+			}
+			out += fmt.Sprintf("%02X", n)
+		}
+		return out
+	}
+	s = strings.TrimPrefix(s, "#")
+	switch len(s) {
+	case 3:
+		s = string([]byte{s[0], s[0], s[1], s[1], s[2], s[2]})
+	case 8:
+		s = s[2:] // drop alpha
+	case 6:
+	default:
+		return ""
+	}
+	if _, err := strconv.ParseUint(s, 16, 32); err != nil {
+		return ""
+	}
+	return strings.ToUpper(s)
+}
+
+func normalizeBorderStyle(in string) string {
+	s := strings.ToLower(strings.TrimSpace(in))
+	switch s {
+	case "thin", "medium", "thick", "dashed", "dotted", "double", "hair":
+		return s
+	case "solid", "1", "true":
+		return "thin"
+	case "dash":
+		return "dashed"
+	case "dot":
+		return "dotted"
+	default:
+		return ""
+	}
+}
+
+func normalizeHorizontal(in string) string {
+	s := strings.ToLower(strings.TrimSpace(in))
+	switch s {
+	case "left", "center", "right", "justify", "fill":
+		return s
+	case "centre", "middle":
+		return "center"
+	default:
+		return ""
+	}
+}
+
+func normalizeVertical(in string) string {
+	s := strings.ToLower(strings.TrimSpace(in))
+	switch s {
+	case "top", "center", "bottom":
+		return s
+	case "middle", "centre":
+		return "center"
+	default:
+		return ""
+	}
+}
+
+// firstPresent returns the value of the first key present in m.
+func firstPresent(m map[string]interface{}, keys ...string) interface{} {
+	for _, k := range keys {
+		if v, ok := m[k]; ok {
+			return v
+		}
+	}
+	return nil
+}
+
+func toString(v interface{}) string {
+	if s, ok := v.(string); ok {
+		return s
+	}
+	return ""
+}
+
+func toFloat(v interface{}) float64 {
+	switch t := v.(type) {
+	case float64:
+		return t
+	case string:
+		if f, err := strconv.ParseFloat(strings.TrimSpace(t), 64); err == nil {
+			return f
+		}
+	}
+	return 0
+}
+
+func toBool(v interface{}) bool {
+	switch t := v.(type) {
+	case bool:
+		return t
+	case float64:
+		return t != 0
+	case string:
+		s := strings.ToLower(strings.TrimSpace(t))
+		return s == "true" || s == "1" || s == "yes"
+	default:
+		return false
+	}
+}
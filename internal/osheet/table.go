@@ -0,0 +1,84 @@
+package osheet
+
+import (
+	"strconv"
+	"strings"
+)
+
+// parseTables reads a "tables" array. Entries are objects with a range and
+// optional name, header row flag (default true) and style:
+//
+//	{"name":"Orders","range":"A1:D20","style":"TableStyleMedium9"}
+//	{"range":"F1:G8","headerRow":false}
+//
+// Entries without a valid range are skipped.
+func parseTables(raw interface{}) []Table {
+	items, ok := raw.([]interface{})
+	if !ok {
+		return nil
+	}
+	var out []Table
+	for _, item := range items {
+		m, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		t := Table{
+			Name:      strings.TrimSpace(toString(firstPresent(m, "name", "displayName"))),
+			Range:     normalizeRangeRef(toString(firstPresent(m, "range", "ref"))),
+			HeaderRow: true,
+			Style:     strings.TrimSpace(toString(firstPresent(m, "style", "styleName"))),
+		}
+		if header := firstPresent(m, "headerRow", "header"); header != nil {
+			t.HeaderRow = toBool(header)
+		}
+		if t.Range == "" {
+			continue
+		}
+		out = append(out, t)
+	}
+	return out
+}
+
+// parseAutoFilter reads an "autoFilter" range, given as a string or as an
+// object with a range. An invalid range reads as none.
+//
+//	"autoFilter":"A1:E40"
+//	"autoFilter":{"range":"A1:E40"}
+func parseAutoFilter(raw interface{}) string {
+	if m, ok := raw.(map[string]interface{}); ok {
+		raw = firstPresent(m, "range", "ref")
+	}
+	return normalizeRangeRef(toString(raw))
+}
+
+// normalizeRangeRef returns ref as an upper-case "A1:B2" range from its
+// top-left to its bottom-right cell, or "" when it is not a range. A single
+// cell spans itself.
+func normalizeRangeRef(ref string) string {
+	first, last, ok := strings.Cut(ref, ":")
+	if !ok {
+		last = first
+	}
+	first, last = normalizeCellRef(first), normalizeCellRef(last)
+	if first == "" || last == "" {
+		return ""
+	}
+	c1, r1 := splitCellRef(first)
+	c2, r2 := splitCellRef(last)
+	if len(c1) > len(c2) || (len(c1) == len(c2) && c1 > c2) {
+		c1, c2 = c2, c1
+	}
+	if r1 > r2 {
+		r1, r2 = r2, r1
+	}
+	return c1 + strconv.Itoa(r1) + ":" + c2 + strconv.Itoa(r2)
+}
+
+// splitCellRef splits a reference normalized by normalizeCellRef into its
+// column letters and row number.
+func splitCellRef(ref string) (col string, row int) {
+	i := strings.IndexFunc(ref, func(r rune) bool { return r >= '0' && r <= '9' })
+	row, _ = strconv.Atoi(ref[i:])
+	return ref[:i], row
+}
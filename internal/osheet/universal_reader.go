@@ -6,6 +6,11 @@ import (
 
 // ReadBookUniversal automatically detects the format and reads the book
 func ReadBookUniversal(path string) (*Book, error) {
+	return ReadBookUniversalWithOptions(path, ReadOptions{})
+}
+
+// ReadBookUniversalWithOptions is ReadBookUniversal with options.
+func ReadBookUniversalWithOptions(path string, opts ReadOptions) (*Book, error) {
 	format, err := DetectFormat(path)
 	if err != nil {
 		return nil, fmt.Errorf("failed to detect format: %w", err)
@@ -13,9 +18,9 @@ func ReadBookUniversal(path string) (*Book, error) {
 
 	switch format {
 	case FormatZIP:
-		return ReadBook(path)
+		return ReadBookWithOptions(path, opts)
 	case FormatBinary:
-		return ReadBinaryBook(path)
+		return readBinaryBook(path, opts)
 	case FormatUnknown:
 		return nil, fmt.Errorf("unsupported or unknown format")
 	default:
@@ -25,18 +30,29 @@ func ReadBookUniversal(path string) (*Book, error) {
 
 // ReadBinaryBook reads a binary .osheet file and returns a Book
 func ReadBinaryBook(path string) (*Book, error) {
-	binarySheet, err := ParseBinaryOsheet(path)
+	return readBinaryBook(path, ReadOptions{})
+}
+
+func readBinaryBook(path string, opts ReadOptions) (*Book, error) {
+	binaryBook, err := ParseBinaryBook(path)
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse binary .osheet: %w", err)
 	}
 
-	sheet, err := ConvertBinaryToSheet(binarySheet)
-	if err != nil {
-		return nil, fmt.Errorf("failed to convert binary sheet: %w", err)
+	sheets := make([]Sheet, 0, len(binaryBook.Sheets))
+	for i := range binaryBook.Sheets {
+		sheet, err := convertBinarySheet(&binaryBook.Sheets[i], opts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to convert binary sheet %q: %w", binaryBook.Sheets[i].Title, err)
+		}
+		sheets = append(sheets, *sheet)
 	}
 
 	return &Book{
-		Title:  path,
-		Sheets: []Sheet{*sheet},
+		Title:        binaryBook.Title,
+		Sheets:       sheets,
+		DefinedNames: binaryBook.DefinedNames,
+		Protection:   binaryBook.Protection,
+		Properties:   binaryBook.Properties,
 	}, nil
 }
@@ -36,7 +36,7 @@ func ValidateStructureDetailed(zipPath string) ([]ValidationIssue, error) {
 	defer zr.Close()
 
 	// document.json present and parseable?
-	if shs, ok := tryParseDocumentJSON(zr.File); ok && len(shs) > 0 {
+	if shs, ok := tryParseDocumentJSON(zr.File, ReadOptions{}); ok && len(shs) > 0 {
 		return issues, nil
 	} else {
 		// document.json present but invalid?
@@ -62,7 +62,7 @@ func ValidateStructureDetailed(zipPath string) ([]ValidationIssue, error) {
 		}
 		if path.Dir(f.Name) == "sheets" && path.Ext(f.Name) == ".json" {
 			anySheet = true
-			if _, ok := tryParseSheetJSON(f); ok {
+			if _, ok := tryParseSheetJSON(f, ReadOptions{}); ok {
 				return issues, nil
 			}
 		}
@@ -0,0 +1,238 @@
+package osheet
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	appfs "github.com/romanitalian/osheet2xlsx/v3/internal/fs"
+)
+
+// Document JSON shapes written by GenerateBookDocumentJSON; field names are
+// the keys parseDocumentSheet reads back.
+type (
+	documentOut struct {
+		Title        string              `json:"title,omitempty"`
+		Sheets       []sheetOut          `json:"sheets"`
+		DefinedNames []DefinedName       `json:"definedNames,omitempty"`
+		Protection   *WorkbookProtection `json:"protection,omitempty"`
+		Properties   *propertiesOut      `json:"properties,omitempty"`
+	}
+	sheetOut struct {
+		Name               string                 `json:"name"`
+		Cells              [][]interface{}        `json:"cells"`
+		Merges             []mergeOut             `json:"merges,omitempty"`
+		Cols               []colOut               `json:"cols,omitempty"`
+		RowHeights         []rowOut               `json:"rowHeights,omitempty"`
+		Validations        []DataValidation       `json:"validations,omitempty"`
+		ConditionalFormats []conditionalFormatOut `json:"conditionalFormats,omitempty"`
+		Images             []imageOut             `json:"images,omitempty"`
+		AutoFilter         string                 `json:"autoFilter,omitempty"`
+		Tables             []Table                `json:"tables,omitempty"`
+		Protection         *SheetProtection       `json:"protection,omitempty"`
+		sheetViewJSON
+	}
+	// imageOut references an archive entry (Src) or carries base64 Data.
+	imageOut struct {
+		Cell   string `json:"cell"`
+		Src    string `json:"src,omitempty"`
+		Data   string `json:"data,omitempty"`
+		Width  int    `json:"width,omitempty"`
+		Height int    `json:"height,omitempty"`
+		Alt    string `json:"alt,omitempty"`
+	}
+	// conditionalFormatOut adds the style in the form parseStyle reads.
+	conditionalFormatOut struct {
+		ConditionalFormat
+		Style map[string]interface{} `json:"style,omitempty"`
+	}
+	mergeOut struct {
+		StartRow int `json:"startRow"`
+		StartCol int `json:"startCol"`
+		EndRow   int `json:"endRow"`
+		EndCol   int `json:"endCol"`
+	}
+	colOut struct {
+		Index int     `json:"index"`
+		Width float64 `json:"width"`
+	}
+	rowOut struct {
+		Index  int     `json:"index"`
+		Height float64 `json:"height"`
+	}
+)
+
+// GenerateBookDocumentJSON renders every sheet of book as a document.json in
+// the V3 "cells" schema: typed cells with formulas and inline styles, plus
+// merges, cols and rowHeights. Images refer to the archive entries Write
+// stores next to it.
+func GenerateBookDocumentJSON(book *Book) ([]byte, error) {
+	doc := documentOut{
+		Title:        book.Title,
+		Sheets:       make([]sheetOut, 0, len(book.Sheets)),
+		DefinedNames: book.DefinedNames,
+		Protection:   book.Protection,
+		Properties:   toPropertiesOut(book.Properties),
+	}
+	for i := range book.Sheets {
+		out := documentSheet(&book.Sheets[i])
+		forEachImage(&book.Sheets[i], i, func(img *Image, name string) {
+			out.Images = append(out.Images, imageOut{Cell: img.Cell, Src: name, Width: img.Width, Height: img.Height, Alt: img.AltText})
+		})
+		doc.Sheets = append(doc.Sheets, out)
+	}
+	result, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal document: %w", err)
+	}
+	return result, nil
+}
+
+func conditionalFormatsOut(cfs []ConditionalFormat) []conditionalFormatOut {
+	var out []conditionalFormatOut
+	for _, cf := range cfs {
+		o := conditionalFormatOut{ConditionalFormat: cf}
+		if cf.Style != nil {
+			o.Style = styleToMap(cf.Style, "")
+		}
+		out = append(out, o)
+	}
+	return out
+}
+
+func documentSheet(s *Sheet) sheetOut {
+	out := sheetOut{
+		Name:               s.Name,
+		Cells:              make([][]interface{}, len(s.Cells)),
+		Validations:        s.Validations,
+		ConditionalFormats: conditionalFormatsOut(s.ConditionalFormats),
+		AutoFilter:         s.AutoFilter,
+		Tables:             s.Tables,
+		Protection:         s.Protection,
+		sheetViewJSON:      viewJSON(s.View),
+	}
+	for r, row := range s.Cells {
+		out.Cells[r] = make([]interface{}, len(row))
+		for c, cell := range row {
+			out.Cells[r][c] = documentCell(cell)
+		}
+	}
+	if len(out.Cells) == 0 {
+		// Sheets without cells are skipped by the reader; keep one empty cell
+		out.Cells = [][]interface{}{{nil}}
+	}
+	for _, m := range s.Merges {
+		out.Merges = append(out.Merges, mergeOut{StartRow: m.StartRow, StartCol: m.StartCol, EndRow: m.EndRow, EndCol: m.EndCol})
+	}
+	for _, c := range s.Cols {
+		out.Cols = append(out.Cols, colOut{Index: c.Index, Width: c.Width})
+	}
+	for _, r := range s.Rows {
+		out.RowHeights = append(out.RowHeights, rowOut{Index: r.Index, Height: r.Height})
+	}
+	return out
+}
+
+// documentCell encodes one cell with short keys; plain empty cells are null.
+func documentCell(cell Cell) interface{} {
+	cellData := make(map[string]interface{})
+	switch cell.Type {
+	case ValueString:
+		cellData["t"] = "s"
+		cellData["v"] = cell.StringValue
+	case ValueNumber:
+		cellData["t"] = "n"
+		cellData["v"] = cell.NumberValue
+	case ValueBool:
+		cellData["t"] = "b"
+		cellData["v"] = cell.BoolValue
+	case ValueDateTime:
+		cellData["t"] = "d"
+		cellData["v"] = cell.DateEpoch
+	}
+	if cell.Formula != "" {
+		cellData["f"] = cell.Formula
+	}
+	if cell.Style != nil || cell.NumFmt != "" {
+		cellData["style"] = styleToMap(cell.Style, cell.NumFmt)
+	}
+	if cell.Link != nil {
+		cellData["link"] = cell.Link
+	}
+	if cell.Comment != nil {
+		cellData["comment"] = cell.Comment
+	}
+	if len(cell.RichText) > 0 {
+		cellData["runs"] = cell.RichText
+	}
+	if len(cellData) == 0 {
+		return nil
+	}
+	return cellData
+}
+
+// WriteBook writes book to outPath as a ZIP .osheet holding document.json.
+func WriteBook(book *Book, outPath string) error {
+	if book == nil {
+		return errors.New("nil book")
+	}
+	if err := appfs.EnsureParentDir(outPath); err != nil {
+		return err
+	}
+	f, err := os.Create(outPath)
+	if err != nil {
+		return err
+	}
+	if err := Write(f, book); err != nil {
+		_ = f.Close()
+		return fmt.Errorf("write %s: %w", outPath, err)
+	}
+	return f.Close()
+}
+
+// Write encodes book as a ZIP .osheet.
+func Write(w io.Writer, book *Book) error {
+	doc, err := GenerateBookDocumentJSON(book)
+	if err != nil {
+		return err
+	}
+	zw := zip.NewWriter(w)
+	dw, err := zw.CreateHeader(&zip.FileHeader{Name: "document.json", Method: zip.Deflate, Modified: time.Now()})
+	if err != nil {
+		return err
+	}
+	if _, err := dw.Write(doc); err != nil {
+		return err
+	}
+	for i := range book.Sheets {
+		forEachImage(&book.Sheets[i], i, func(img *Image, name string) {
+			if err != nil {
+				return
+			}
+			var iw io.Writer
+			// Images are compressed already
+			if iw, err = zw.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Store, Modified: time.Now()}); err == nil {
+				_, err = iw.Write(img.Data)
+			}
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return zw.Close()
+}
+
+// forEachImage calls fn for each image of the i-th sheet that passes
+// CheckImage, with the archive entry name Write stores it under.
+func forEachImage(s *Sheet, i int, fn func(img *Image, name string)) {
+	for j := range s.Images {
+		img := &s.Images[j]
+		if ext, _, _, err := img.Check(); err == nil {
+			fn(img, fmt.Sprintf("images/sheet%d_%d%s", i+1, j+1, ext))
+		}
+	}
+}
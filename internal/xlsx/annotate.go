@@ -0,0 +1,42 @@
+package xlsx
+
+import (
+	"fmt"
+
+	"github.com/xuri/excelize/v2"
+
+	"github.com/romanitalian/osheet2xlsx/v3/internal/osheet"
+)
+
+// annotateCell attaches the cell's hyperlink and comment. Both live outside
+// the sheet data, so streamed sheets can be annotated until the stream is
+// flushed.
+func annotateCell(f *excelize.File, formulas *formulaTranslator, sheet, axis string, cell osheet.Cell) {
+	if link := cell.Link; link != nil {
+		target, linkType := link.URL, "External"
+		if target == "" {
+			target, linkType = formulas.location(link.Location), "Location"
+		}
+		var opts []excelize.HyperlinkOpts
+		if link.Tooltip != "" {
+			opts = append(opts, excelize.HyperlinkOpts{Tooltip: &link.Tooltip})
+		}
+		if err := f.SetCellHyperLink(sheet, axis, target, linkType, opts...); err != nil {
+			formulas.report(sheet, axis, fmt.Sprintf("cannot set hyperlink: %v", err))
+		}
+	}
+	if comment := cell.Comment; comment != nil {
+		note := excelize.Comment{Cell: axis, Author: comment.Author, Text: comment.Text}
+		if comment.Author != "" {
+			// Like Excel, lead with the author in bold; excelize records the
+			// author id of a repeated author as the first one's
+			note.Text, note.Paragraph = "", []excelize.RichTextRun{
+				{Text: comment.Author + ":", Font: &excelize.Font{Bold: true}},
+				{Text: "\n" + comment.Text},
+			}
+		}
+		if err := f.AddComment(sheet, note); err != nil {
+			formulas.report(sheet, axis, fmt.Sprintf("cannot add comment: %v", err))
+		}
+	}
+}
@@ -0,0 +1,211 @@
+package xlsx
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/xuri/excelize/v2"
+
+	"github.com/romanitalian/osheet2xlsx/v3/internal/osheet"
+)
+
+// Formula modes for Options.Formulas.
+const (
+	FormulasKeep   = "keep"   // write formulas without cached results
+	FormulasCache  = "cache"  // write formulas with their computed results cached
+	FormulasValues = "values" // replace formulas with their computed results
+)
+
+// Validate reports unknown option values.
+func (o Options) Validate() error {
+	switch o.Formulas {
+	case "", FormulasKeep, FormulasCache, FormulasValues:
+		return nil
+	default:
+		return fmt.Errorf("invalid formulas mode %q (want keep, cache or values)", o.Formulas)
+	}
+}
+
+// evaluates reports whether formula results are computed.
+func (o Options) evaluates() bool {
+	return o.Formulas == FormulasCache || o.Formulas == FormulasValues
+}
+
+// evaluate computes every formula of book with excelize's calc engine and
+// keeps the results for the real write. Sheets are evaluated one at a time,
+// each on a scratch in-memory workbook holding only the values and formulas
+// of that sheet and of the sheets and defined names it refers to. Formulas
+// that cannot be evaluated are reported as warnings and keep no result.
+func (t *formulaTranslator) evaluate(book *osheet.Book) {
+	names := sheetNames(book.Sheets)
+	// Translation warnings are reported by the real write
+	scratch := &formulaTranslator{opts: t.opts}
+	deps, hasFormulas := calcDeps(scratch, names, book)
+
+	t.results = make(map[string]interface{})
+	for i := range book.Sheets {
+		if hasFormulas[i] {
+			t.evaluateSheet(scratch, names, book, i, calcClosure(deps, i))
+		}
+	}
+}
+
+// evaluateSheet computes the formulas of sheet i on a scratch workbook with
+// the sheets and defined names marked in need, indexed as by calcDeps.
+func (t *formulaTranslator) evaluateSheet(scratch *formulaTranslator, names []string, book *osheet.Book, i int, need []bool) {
+	f := excelize.NewFile()
+	defer func() { _ = f.Close() }()
+	defaultSheet := f.GetSheetName(0)
+	first := true
+	for j := range book.Sheets {
+		if !need[j] {
+			continue
+		}
+		if first {
+			safeSetSheetName(f, defaultSheet, names[j])
+			first = false
+		} else {
+			safeNewSheet(f, names[j])
+		}
+		writeCalcCells(f, scratch, names[j], &book.Sheets[j])
+	}
+	var definedNames []osheet.DefinedName
+	for j, dn := range book.DefinedNames {
+		if need[len(book.Sheets)+j] {
+			definedNames = append(definedNames, dn)
+		}
+	}
+	addDefinedNames(f, scratch, definedNames)
+
+	s := &book.Sheets[i]
+	for r := range s.Cells {
+		for c, cell := range s.Cells[r] {
+			axis := safeCoordinatesToCellName(c+1, r+1)
+			formula := scratch.formula(names[i], axis, cell)
+			if formula == "" {
+				continue
+			}
+			result, err := f.CalcCellValue(names[i], axis, excelize.Options{RawCellValue: true})
+			if err != nil {
+				if t.warn != nil {
+					t.warn(fmt.Sprintf("%s!%s: cannot evaluate %s: %v", names[i], axis, formula, err))
+				}
+				continue
+			}
+			t.results[names[i]+"!"+axis] = calcValue(result)
+		}
+	}
+}
+
+// writeCalcCells writes the values and formulas of s, all the calc engine
+// reads, to the scratch sheet name.
+func writeCalcCells(f *excelize.File, scratch *formulaTranslator, name string, s *osheet.Sheet) {
+	for r := range s.Cells {
+		for c, cell := range s.Cells[r] {
+			axis := safeCoordinatesToCellName(c+1, r+1)
+			if formula := scratch.formula(name, axis, cell); formula != "" {
+				safeSetCellFormula(f, name, axis, formula)
+				continue
+			}
+			switch cell.Type {
+			case osheet.ValueEmpty:
+			case osheet.ValueNumber:
+				safeSetCellFloat(f, name, axis, cell.NumberValue, -1, 64)
+			case osheet.ValueBool:
+				safeSetCellBool(f, name, axis, cell.BoolValue)
+			case osheet.ValueDateTime:
+				safeSetCellFloat(f, name, axis, cell.DateEpoch, -1, 64)
+			default:
+				safeSetCellStr(f, name, axis, cell.StringValue)
+			}
+		}
+	}
+}
+
+// calcDeps returns what every sheet and then every defined name of book
+// refers to, as indexes into the same list (sheets first, then defined
+// names), and which sheets hold formulas.
+func calcDeps(scratch *formulaTranslator, names []string, book *osheet.Book) ([][]int, []bool) {
+	sheetIndex := make(map[string]int, len(names))
+	for i, name := range names {
+		sheetIndex[strings.ToLower(name)] = i
+	}
+	nameIndex := make(map[string][]int, len(book.DefinedNames))
+	for j, dn := range book.DefinedNames {
+		key := strings.ToUpper(dn.Name)
+		nameIndex[key] = append(nameIndex[key], len(names)+j)
+	}
+	refs := func(formula string, out []int) []int {
+		sheets, definedNames := osheet.FormulaReferences(formula)
+		for _, sheet := range sheets {
+			if i, ok := sheetIndex[strings.ToLower(sheet)]; ok {
+				out = append(out, i)
+			}
+		}
+		for _, name := range definedNames {
+			out = append(out, nameIndex[strings.ToUpper(name)]...)
+		}
+		return out
+	}
+
+	deps := make([][]int, len(names)+len(book.DefinedNames))
+	hasFormulas := make([]bool, len(names))
+	for i := range book.Sheets {
+		s := &book.Sheets[i]
+		for r := range s.Cells {
+			for c, cell := range s.Cells[r] {
+				formula := scratch.formula(names[i], safeCoordinatesToCellName(c+1, r+1), cell)
+				if formula != "" {
+					hasFormulas[i] = true
+					deps[i] = refs(formula, deps[i])
+				}
+			}
+		}
+	}
+	for j, dn := range book.DefinedNames {
+		k := len(names) + j
+		if scope, ok := scratch.opts.Sheets[dn.Scope]; ok && dn.Scope != "" {
+			deps[k] = append(deps[k], sheetIndex[strings.ToLower(scope)])
+		}
+		deps[k] = refs(scratch.translateName(dn.Name, dn.RefersTo), deps[k])
+	}
+	return deps, hasFormulas
+}
+
+// calcClosure marks i and everything it refers to, directly or not.
+func calcClosure(deps [][]int, i int) []bool {
+	need := make([]bool, len(deps))
+	need[i] = true
+	queue := []int{i}
+	for len(queue) > 0 {
+		k := queue[0]
+		queue = queue[1:]
+		for _, d := range deps[k] {
+			if !need[d] {
+				need[d] = true
+				queue = append(queue, d)
+			}
+		}
+	}
+	return need
+}
+
+// calcValue types a calc engine result by its text. The engine writes
+// numbers in Go's shortest form (or with 15 significant digits) and
+// booleans as TRUE or FALSE; anything else, such as "00123" or "1.50", is
+// a text result and stays text.
+func calcValue(s string) interface{} {
+	switch s {
+	case "TRUE":
+		return true
+	case "FALSE":
+		return false
+	}
+	if n, err := strconv.ParseFloat(s, 64); err == nil {
+		if s == strconv.FormatFloat(n, 'f', -1, 64) || s == strings.ToUpper(strconv.FormatFloat(n, 'G', 15, 64)) {
+			return n
+		}
+	}
+	return s
+}
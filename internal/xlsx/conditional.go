@@ -0,0 +1,96 @@
+package xlsx
+
+import (
+	"fmt"
+
+	"github.com/xuri/excelize/v2"
+
+	"github.com/romanitalian/osheet2xlsx/v3/internal/osheet"
+)
+
+// conditionalCriteria maps comparison operators to excelize criteria.
+var conditionalCriteria = map[string]string{
+	"between":            "between",
+	"notBetween":         "not between",
+	"equal":              "==",
+	"notEqual":           "!=",
+	"greaterThan":        ">",
+	"lessThan":           "<",
+	"greaterThanOrEqual": ">=",
+	"lessThanOrEqual":    "<=",
+}
+
+// addConditionalFormats writes the sheet's conditional formats in priority
+// order. They live outside the sheet data, so streamed sheets can add them
+// until the stream is flushed. Rules Excel cannot express are reported
+// through the translator's warn and skipped.
+func addConditionalFormats(f *excelize.File, styles *styleCache, formulas *formulaTranslator, name string, rules []osheet.ConditionalFormat) {
+	for i := range rules {
+		cf := &rules[i]
+		opts, problem := conditionalFormat(styles, formulas, name, cf)
+		if problem != "" {
+			formulas.report(name, cf.Range, problem)
+			continue
+		}
+		if err := f.SetConditionalFormat(name, cf.Range, []excelize.ConditionalFormatOptions{opts}); err != nil {
+			formulas.report(name, cf.Range, fmt.Sprintf("cannot set conditional format: %v", err))
+		}
+	}
+}
+
+// conditionalFormat converts a rule into its excelize form, or explains why
+// it cannot be written.
+func conditionalFormat(styles *styleCache, formulas *formulaTranslator, sheet string, cf *osheet.ConditionalFormat) (excelize.ConditionalFormatOptions, string) {
+	opts := excelize.ConditionalFormatOptions{StopIfTrue: cf.StopIfTrue}
+	switch cf.Kind {
+	case osheet.ConditionalCellValue:
+		criteria, ok := conditionalCriteria[cf.Operator]
+		if !ok || cf.Formula1 == "" {
+			return opts, "cell value rule needs an operator and a value"
+		}
+		opts.Type, opts.Criteria = "cell", criteria
+		value := formulas.translate(sheet, cf.Range, cf.Formula1)
+		if cf.Operator == "between" || cf.Operator == "notBetween" {
+			if cf.Formula2 == "" {
+				return opts, "cell value rule is missing a bound"
+			}
+			opts.MinValue, opts.MaxValue = value, formulas.translate(sheet, cf.Range, cf.Formula2)
+		} else {
+			opts.Value = value
+		}
+	case osheet.ConditionalFormula:
+		if cf.Formula1 == "" {
+			return opts, "formula rule has no formula"
+		}
+		opts.Type, opts.Criteria = "formula", formulas.translate(sheet, cf.Range, cf.Formula1)
+	case osheet.ConditionalColorScale:
+		if len(cf.Colors) != 2 && len(cf.Colors) != 3 {
+			return opts, fmt.Sprintf("color scale needs 2 or 3 colors, got %d", len(cf.Colors))
+		}
+		opts.Type, opts.Criteria = "2_color_scale", "="
+		opts.MinType, opts.MinColor = "min", cf.Colors[0]
+		opts.MaxType, opts.MaxColor = "max", cf.Colors[len(cf.Colors)-1]
+		if len(cf.Colors) == 3 {
+			opts.Type = "3_color_scale"
+			opts.MidType, opts.MidValue, opts.MidColor = "percentile", "50", cf.Colors[1]
+		}
+		return opts, ""
+	case osheet.ConditionalDataBar:
+		if len(cf.Colors) == 0 {
+			return opts, "data bar has no color"
+		}
+		opts.Type, opts.Criteria = "data_bar", "="
+		opts.MinType, opts.MaxType, opts.BarColor = "min", "max", cf.Colors[0]
+		return opts, ""
+	default:
+		return opts, fmt.Sprintf("unsupported conditional format type %q", cf.Kind)
+	}
+	if cf.Style != nil {
+		id, err := styles.conditionalID(*cf.Style)
+		if err != nil {
+			return opts, fmt.Sprintf("conditional format style: %v", err)
+		}
+		opts.Format = &id
+	}
+	return opts, ""
+}
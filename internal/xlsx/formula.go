@@ -0,0 +1,90 @@
+package xlsx
+
+import (
+	"fmt"
+
+	"github.com/romanitalian/osheet2xlsx/v3/internal/osheet"
+)
+
+// formulaTranslator rewrites source formulas into Excel syntax for one
+// workbook, reporting untranslatable functions through warn. After evaluate,
+// results holds the computed value of each formula by "Sheet!A1".
+type formulaTranslator struct {
+	opts    osheet.FormulaOptions
+	warn    func(string)
+	results map[string]interface{}
+	// valuesOnly writes computed results in place of their formulas
+	valuesOnly bool
+}
+
+// newFormulaTranslator maps every source sheet name to the name addSheets
+// gives it, so references follow sanitised renames. A name used by several
+// sheets refers to the first.
+func newFormulaTranslator(sheets []osheet.Sheet, warn func(string)) *formulaTranslator {
+	out := sheetNames(sheets)
+	names := make(map[string]string, len(sheets))
+	for i := range sheets {
+		if _, ok := names[sheets[i].Name]; !ok && sheets[i].Name != "" {
+			names[sheets[i].Name] = out[i]
+		}
+	}
+	return &formulaTranslator{opts: osheet.FormulaOptions{Sheets: names}, warn: warn}
+}
+
+// formula returns the Excel formula of cell at sheet!axis, or "" when the
+// cell has none. String values starting with "=" count as formulas.
+func (t *formulaTranslator) formula(sheet, axis string, cell osheet.Cell) string {
+	src := cell.Formula
+	if src == "" && cell.Type == osheet.ValueString && len(cell.StringValue) > 0 && cell.StringValue[0] == '=' {
+		src = cell.StringValue
+	}
+	if src == "" {
+		return ""
+	}
+	return t.translate(sheet, axis, src)
+}
+
+// translate rewrites a formula found at sheet!axis, reporting its warnings.
+func (t *formulaTranslator) translate(sheet, axis, src string) string {
+	out, warnings := osheet.TranslateFormula(src, t.opts)
+	for _, w := range warnings {
+		t.report(sheet, axis, w)
+	}
+	return out
+}
+
+// report passes a problem at sheet!axis to warn, if set.
+func (t *formulaTranslator) report(sheet, axis, msg string) {
+	if t.warn != nil {
+		t.warn(fmt.Sprintf("%s!%s: %s", sheet, axis, msg))
+	}
+}
+
+// translateName rewrites the target of the defined name, reporting its warnings.
+func (t *formulaTranslator) translateName(name, src string) string {
+	out, warnings := osheet.TranslateFormula(src, t.opts)
+	for _, w := range warnings {
+		t.reportName(name, w)
+	}
+	return out
+}
+
+// reportName passes a problem with the defined name to warn, if set.
+func (t *formulaTranslator) reportName(name, msg string) {
+	if t.warn != nil {
+		t.warn(fmt.Sprintf("name %s: %s", name, msg))
+	}
+}
+
+// location rewrites an internal link target such as "Sheet 2.A1" into Excel
+// syntax, following sheet renames.
+func (t *formulaTranslator) location(loc string) string {
+	out, _ := osheet.TranslateFormula(loc, t.opts)
+	return out
+}
+
+// result returns the computed value of the formula at sheet!axis, or nil
+// when formulas were not evaluated or this one failed.
+func (t *formulaTranslator) result(sheet, axis string) interface{} {
+	return t.results[sheet+"!"+axis]
+}
@@ -0,0 +1,61 @@
+package xlsx
+
+import (
+	"fmt"
+
+	"github.com/xuri/excelize/v2"
+
+	"github.com/romanitalian/osheet2xlsx/v3/internal/osheet"
+)
+
+// addImages places the sheet's images over their anchor cells. Like
+// annotations they live outside the sheet data, so streamed sheets can add
+// them until the stream is flushed. Images failing Image.Check are
+// reported through the translator's warn and skipped.
+func addImages(f *excelize.File, formulas *formulaTranslator, name string, images []osheet.Image) {
+	for i := range images {
+		img := &images[i]
+		ext, width, height, err := img.Check()
+		if err != nil {
+			source := img.Source
+			if source == "" {
+				source = "inline data"
+			}
+			formulas.report(name, img.Cell, fmt.Sprintf("image %s skipped: %v", source, err))
+			continue
+		}
+		scaleX, scaleY := imageScale(img.Width, img.Height, width, height)
+		pic := &excelize.Picture{
+			Extension: ext,
+			File:      img.Data,
+			Format: &excelize.GraphicOptions{
+				AltText:     img.AltText,
+				ScaleX:      scaleX,
+				ScaleY:      scaleY,
+				Positioning: "oneCell",
+			},
+		}
+		if err := f.AddPictureFromBytes(name, img.Cell, pic); err != nil {
+			formulas.report(name, img.Cell, fmt.Sprintf("cannot add image: %v", err))
+		}
+	}
+}
+
+// imageScale returns the factors that display a width x height image at the
+// wanted size; a missing dimension follows the other one's scale.
+func imageScale(wantWidth, wantHeight, width, height int) (float64, float64) {
+	scaleX, scaleY := 1.0, 1.0
+	if wantWidth > 0 {
+		scaleX = float64(wantWidth) / float64(width)
+	}
+	if wantHeight > 0 {
+		scaleY = float64(wantHeight) / float64(height)
+	}
+	switch {
+	case wantWidth > 0 && wantHeight <= 0:
+		scaleY = scaleX
+	case wantHeight > 0 && wantWidth <= 0:
+		scaleX = scaleY
+	}
+	return scaleX, scaleY
+}
@@ -0,0 +1,51 @@
+package xlsx
+
+import (
+	"fmt"
+	"regexp"
+
+	"github.com/xuri/excelize/v2"
+
+	"github.com/romanitalian/osheet2xlsx/v3/internal/osheet"
+)
+
+// addDefinedNames registers the workbook's defined names once every sheet
+// exists. Targets are translated like formulas, so they follow sheet renames.
+// Names Excel rejects and scopes naming a missing sheet are reported through
+// the translator's warn and skipped.
+func addDefinedNames(f *excelize.File, formulas *formulaTranslator, names []osheet.DefinedName) {
+	for _, dn := range names {
+		if cellLikeName(dn.Name) {
+			formulas.reportName(dn.Name, "name reads as a cell reference")
+			continue
+		}
+		scope := ""
+		if dn.Scope != "" {
+			var ok bool
+			if scope, ok = formulas.opts.Sheets[dn.Scope]; !ok {
+				formulas.reportName(dn.Name, fmt.Sprintf("scope %q is not a sheet", dn.Scope))
+				continue
+			}
+		}
+		err := f.SetDefinedName(&excelize.DefinedName{
+			Name:     dn.Name,
+			RefersTo: formulas.translateName(dn.Name, dn.RefersTo),
+			Scope:    scope,
+			Comment:  dn.Comment,
+		})
+		if err != nil {
+			formulas.reportName(dn.Name, fmt.Sprintf("skipped: %v", err))
+		}
+	}
+}
+
+// r1c1Name matches names Excel reads as R1C1 references, such as R, C2 or R1C1.
+var r1c1Name = regexp.MustCompile(`(?i)^(r\d*)?(c\d*)?$`)
+
+// cellLikeName reports whether Excel would take name for a cell reference.
+func cellLikeName(name string) bool {
+	if _, _, err := excelize.CellNameToCoordinates(name); err == nil {
+		return true
+	}
+	return r1c1Name.MatchString(name)
+}
@@ -0,0 +1,250 @@
+package xlsx
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/xuri/excelize/v2"
+
+	"github.com/romanitalian/osheet2xlsx/v3/internal/osheet"
+)
+
+// Package parts of the custom document properties, which excelize does not
+// write or read: addCustomProps adds them to a saved workbook and
+// customProps reads them back.
+const (
+	customPropsPath        = "docProps/custom.xml"
+	customPropsContentType = "application/vnd.openxmlformats-officedocument.custom-properties+xml"
+	customPropsRelType     = "http://schemas.openxmlformats.org/officeDocument/2006/relationships/custom-properties"
+	customPropsNS          = "http://schemas.openxmlformats.org/officeDocument/2006/custom-properties"
+	customPropsVTNS        = "http://schemas.openxmlformats.org/officeDocument/2006/docPropsVTypes"
+	// customPropsFMTID is the format id Office gives user-defined properties.
+	customPropsFMTID = "{D5CDD505-2E9C-101B-9397-08002B2CF9AE}"
+)
+
+// setDocProps writes the title and properties of book into the core
+// document properties. Unset fields keep excelize's defaults.
+func setDocProps(f *excelize.File, book *osheet.Book, warn func(string)) {
+	p := book.Properties
+	props := &excelize.DocProperties{
+		Title:       book.Title,
+		Creator:     p.Author,
+		Description: p.Description,
+		Keywords:    p.Keywords,
+	}
+	if !p.Created.IsZero() {
+		props.Created = p.Created.UTC().Format(time.RFC3339)
+	}
+	if !p.Modified.IsZero() {
+		props.Modified = p.Modified.UTC().Format(time.RFC3339)
+	}
+	if err := f.SetDocProps(props); err != nil {
+		if warn != nil {
+			warn(fmt.Sprintf("cannot set document properties: %v", err))
+		}
+	}
+}
+
+// saveWithProps saves f to path and adds the custom properties of book.
+func saveWithProps(f *excelize.File, book *osheet.Book, path string) error {
+	if err := f.SaveAs(path); err != nil {
+		return err
+	}
+	return addCustomProps(path, book.Properties.Custom)
+}
+
+// addCustomProps rewrites the saved package at path with a
+// docProps/custom.xml part holding props, registered in the content types
+// and the package relationships.
+func addCustomProps(path string, props []osheet.CustomProperty) error {
+	if len(props) == 0 {
+		return nil
+	}
+	zr, err := zip.OpenReader(path)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = zr.Close() }()
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".custom-*.xlsx")
+	if err != nil {
+		return err
+	}
+	defer func() { _ = os.Remove(tmp.Name()) }()
+
+	zw := zip.NewWriter(tmp)
+	for _, entry := range zr.File {
+		var patch func([]byte) []byte
+		switch entry.Name {
+		case "[Content_Types].xml":
+			patch = func(b []byte) []byte {
+				return insertBefore(b, "</Types>", `<Override PartName="/`+customPropsPath+`" ContentType="`+customPropsContentType+`"/>`)
+			}
+		case "_rels/.rels":
+			patch = func(b []byte) []byte {
+				return insertBefore(b, "</Relationships>", `<Relationship Id="rIdCustomProps" Type="`+customPropsRelType+`" Target="`+customPropsPath+`"/>`)
+			}
+		case customPropsPath:
+			continue
+		}
+		if patch == nil {
+			if err := zw.Copy(entry); err != nil {
+				_ = tmp.Close()
+				return err
+			}
+			continue
+		}
+		if err := patchEntry(zw, entry, patch); err != nil {
+			_ = tmp.Close()
+			return err
+		}
+	}
+	w, err := zw.Create(customPropsPath)
+	if err == nil {
+		_, err = w.Write(customPropsXML(props))
+	}
+	if err == nil {
+		err = zw.Close()
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	_ = zr.Close()
+	return os.Rename(tmp.Name(), path)
+}
+
+// patchEntry copies entry into zw with its content passed through patch.
+func patchEntry(zw *zip.Writer, entry *zip.File, patch func([]byte) []byte) error {
+	rc, err := entry.Open()
+	if err != nil {
+		return err
+	}
+	b, err := io.ReadAll(rc)
+	_ = rc.Close()
+	if err != nil {
+		return err
+	}
+	w, err := zw.Create(entry.Name)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(patch(b))
+	return err
+}
+
+// insertBefore inserts s before the last occurrence of end in b.
+func insertBefore(b []byte, end, s string) []byte {
+	i := bytes.LastIndex(b, []byte(end))
+	if i < 0 {
+		return b
+	}
+	out := make([]byte, 0, len(b)+len(s))
+	out = append(out, b[:i]...)
+	out = append(out, s...)
+	return append(out, b[i:]...)
+}
+
+// customPropsXML renders props as a custom properties part. Numbers are
+// written as doubles and times as UTC file times.
+func customPropsXML(props []osheet.CustomProperty) []byte {
+	var b bytes.Buffer
+	b.WriteString(xml.Header)
+	b.WriteString(`<Properties xmlns="` + customPropsNS + `" xmlns:vt="` + customPropsVTNS + `">`)
+	for i, c := range props {
+		var typ, text string
+		switch v := c.Value.(type) {
+		case string:
+			typ, text = "lpwstr", v
+		case float64:
+			typ, text = "r8", strconv.FormatFloat(v, 'f', -1, 64)
+		case bool:
+			typ, text = "bool", strconv.FormatBool(v)
+		case time.Time:
+			typ, text = "filetime", v.UTC().Format(time.RFC3339)
+		default:
+			continue
+		}
+		// Property ids start at 2; 0 and 1 are reserved
+		fmt.Fprintf(&b, `<property fmtid="%s" pid="%d" name="`, customPropsFMTID, i+2)
+		_ = xml.EscapeText(&b, []byte(c.Name))
+		b.WriteString(`"><vt:` + typ + `>`)
+		_ = xml.EscapeText(&b, []byte(text))
+		b.WriteString(`</vt:` + typ + `></property>`)
+	}
+	b.WriteString(`</Properties>`)
+	return b.Bytes()
+}
+
+// docProps reads the title and properties of an opened workbook.
+func docProps(f *excelize.File) (string, osheet.DocProperties) {
+	var p osheet.DocProperties
+	core, err := f.GetDocProps()
+	if err != nil {
+		return "", p
+	}
+	p.Author = core.Creator
+	p.Description = core.Description
+	p.Keywords = core.Keywords
+	p.Created, _ = time.Parse(time.RFC3339, core.Created)
+	p.Modified, _ = time.Parse(time.RFC3339, core.Modified)
+	p.Custom = customProps(f)
+	return core.Title, p
+}
+
+// customProps reads docProps/custom.xml. Text, numbers, booleans and times
+// are kept; numbers of every width are read as float64.
+func customProps(f *excelize.File) []osheet.CustomProperty {
+	raw, ok := f.Pkg.Load(customPropsPath)
+	if !ok {
+		return nil
+	}
+	var doc struct {
+		Properties []struct {
+			Name   string `xml:"name,attr"`
+			Values []struct {
+				XMLName xml.Name
+				Text    string `xml:",chardata"`
+			} `xml:",any"`
+		} `xml:"property"`
+	}
+	if err := xml.Unmarshal(raw.([]byte), &doc); err != nil {
+		return nil
+	}
+	var out []osheet.CustomProperty
+	for _, prop := range doc.Properties {
+		if prop.Name == "" || len(prop.Values) == 0 {
+			continue
+		}
+		v := prop.Values[0]
+		text := strings.TrimSpace(v.Text)
+		var value interface{}
+		switch v.XMLName.Local {
+		case "lpwstr", "lpstr", "bstr":
+			value = v.Text
+		case "r4", "r8", "decimal", "i1", "i2", "i4", "i8", "int", "ui1", "ui2", "ui4", "ui8", "uint":
+			if n, err := strconv.ParseFloat(text, 64); err == nil {
+				value = n
+			}
+		case "bool":
+			value = text == "true" || text == "1"
+		case "filetime", "date":
+			if t, err := time.Parse(time.RFC3339, text); err == nil {
+				value = t
+			}
+		}
+		if value != nil {
+			out = append(out, osheet.CustomProperty{Name: prop.Name, Value: value})
+		}
+	}
+	return out
+}
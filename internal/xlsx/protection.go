@@ -0,0 +1,89 @@
+package xlsx
+
+import (
+	"fmt"
+
+	"github.com/xuri/excelize/v2"
+
+	"github.com/romanitalian/osheet2xlsx/v3/internal/osheet"
+)
+
+// protectSheet protects a sheet, allowing the listed actions. The password
+// hash is carried over through an equivalent password, since excelize only
+// hashes plain passwords; unknown actions and hashes no password produces
+// are passed to warn. Streamed sheets must be protected before their stream
+// writer is created.
+func protectSheet(f *excelize.File, name string, p *osheet.SheetProtection, warn func(string)) {
+	if p == nil {
+		return
+	}
+	opts := &excelize.SheetProtectionOptions{}
+	if p.PasswordHash != "" {
+		password, ok := osheet.PasswordForHash(p.PasswordHash)
+		if !ok && warn != nil {
+			warn(fmt.Sprintf("%s: invalid protection password hash %q; protecting without a password", name, p.PasswordHash))
+		}
+		opts.Password = password
+	}
+	for _, action := range p.Allow {
+		switch action {
+		case osheet.AllowSelectLockedCells:
+			opts.SelectLockedCells = true
+		case osheet.AllowSelectUnlockedCells:
+			opts.SelectUnlockedCells = true
+		case osheet.AllowFormatCells:
+			opts.FormatCells = true
+		case osheet.AllowFormatColumns:
+			opts.FormatColumns = true
+		case osheet.AllowFormatRows:
+			opts.FormatRows = true
+		case osheet.AllowInsertColumns:
+			opts.InsertColumns = true
+		case osheet.AllowInsertRows:
+			opts.InsertRows = true
+		case osheet.AllowInsertHyperlinks:
+			opts.InsertHyperlinks = true
+		case osheet.AllowDeleteColumns:
+			opts.DeleteColumns = true
+		case osheet.AllowDeleteRows:
+			opts.DeleteRows = true
+		case osheet.AllowSort:
+			opts.Sort = true
+		case osheet.AllowAutoFilter:
+			opts.AutoFilter = true
+		case osheet.AllowPivotTables:
+			opts.PivotTables = true
+		case osheet.AllowEditObjects:
+			opts.EditObjects = true
+		case osheet.AllowEditScenarios:
+			opts.EditScenarios = true
+		default:
+			if warn != nil {
+				warn(fmt.Sprintf("%s: unknown protection action %q ignored", name, action))
+			}
+		}
+	}
+	if err := f.ProtectSheet(name, opts); err != nil {
+		if warn != nil {
+			warn(fmt.Sprintf("%s: cannot protect sheet: %v", name, err))
+		}
+	}
+}
+
+// protectWorkbook locks the workbook structure and windows. excelize only
+// writes SHA-512 workbook passwords, which a legacy hash cannot be turned
+// into, so a password hash is dropped with a warning.
+func protectWorkbook(f *excelize.File, p *osheet.WorkbookProtection, warn func(string)) {
+	if p == nil {
+		return
+	}
+	if p.PasswordHash != "" && warn != nil {
+		warn("workbook protection password dropped: only sheet passwords can be carried over")
+	}
+	opts := &excelize.WorkbookProtectionOptions{LockStructure: p.Structure, LockWindows: p.Windows}
+	if err := f.ProtectWorkbook(opts); err != nil {
+		if warn != nil {
+			warn(fmt.Sprintf("cannot protect workbook: %v", err))
+		}
+	}
+}
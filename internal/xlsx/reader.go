@@ -0,0 +1,660 @@
+package xlsx
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/xuri/excelize/v2"
+
+	"github.com/romanitalian/osheet2xlsx/v3/internal/osheet"
+)
+
+// defaultColWidth is excelize's width for columns without an explicit width.
+const defaultColWidth = 9.140625
+
+// builtinNumFmts maps the built-in Excel number format ids to their codes.
+var builtinNumFmts = map[int]string{
+	1: "0", 2: "0.00", 3: "#,##0", 4: "#,##0.00",
+	9: "0%", 10: "0.00%", 11: "0.00E+00", 12: "# ?/?", 13: "# ??/??",
+	14: "mm-dd-yy", 15: "d-mmm-yy", 16: "d-mmm", 17: "mmm-yy",
+	18: "h:mm AM/PM", 19: "h:mm:ss AM/PM", 20: "h:mm", 21: "h:mm:ss", 22: "m/d/yy h:mm",
+	37: "#,##0 ;(#,##0)", 38: "#,##0 ;[Red](#,##0)", 39: "#,##0.00;(#,##0.00)", 40: "#,##0.00;[Red](#,##0.00)",
+	45: "mm:ss", 46: "[h]:mm:ss", 47: "mmss.0", 48: "##0.0E+0", 49: "@",
+}
+
+// cellFormat is a workbook style resolved into the osheet model.
+type cellFormat struct {
+	style  *osheet.Style
+	numFmt string
+	date   bool
+}
+
+// bookReader converts one workbook, resolving each style id once.
+type bookReader struct {
+	f       *excelize.File
+	base    excelize.Font
+	formats map[int]cellFormat
+}
+
+// ReadBook reads an .xlsx workbook into the osheet model: cell values and
+// types, formulas, number formats, styles, merges, column widths, row
+// heights, sheet view, tables, autofilters, defined names and document
+// properties. Title is the workbook's title property, empty when unset.
+func ReadBook(path string) (*osheet.Book, error) {
+	f, err := excelize.OpenFile(path)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = f.Close() }()
+
+	r := &bookReader{f: f, formats: make(map[int]cellFormat)}
+	if st, err := f.GetStyle(0); err == nil && st.Font != nil {
+		r.base = *st.Font
+	}
+	book := &osheet.Book{}
+	book.Title, book.Properties = docProps(f)
+	for _, name := range f.GetSheetList() {
+		s, err := r.sheet(name)
+		if err != nil {
+			return nil, fmt.Errorf("read sheet %s: %w", name, err)
+		}
+		book.Sheets = append(book.Sheets, s)
+	}
+	book.DefinedNames = definedNames(f)
+	return book, nil
+}
+
+// definedNames reads the workbook's names in Excel syntax, which the writer
+// accepts as is. Built-in names (print areas, filters) belong to the features
+// that define them and are skipped.
+func definedNames(f *excelize.File) []osheet.DefinedName {
+	var out []osheet.DefinedName
+	for _, dn := range f.GetDefinedName() {
+		if strings.HasPrefix(dn.Name, "_xlnm.") {
+			continue
+		}
+		scope := dn.Scope
+		if scope == "Workbook" {
+			scope = ""
+		}
+		out = append(out, osheet.DefinedName{
+			Name:     dn.Name,
+			RefersTo: strings.TrimPrefix(dn.RefersTo, "="),
+			Scope:    scope,
+			Comment:  dn.Comment,
+		})
+	}
+	return out
+}
+
+// maxDimensionCells caps the grid a sheet's stored dimension may extend to.
+const maxDimensionCells = 1 << 20
+
+// sheet reads one worksheet. The used range covers the cells actually
+// present and the merges, extended to the stored dimension unless that
+// exceeds maxDimensionCells.
+func (r *bookReader) sheet(name string) (osheet.Sheet, error) {
+	rows, err := r.f.GetRows(name, excelize.Options{RawCellValue: true})
+	if err != nil {
+		return osheet.Sheet{}, err
+	}
+	s := osheet.Sheet{Name: name, Height: len(rows)}
+	for _, row := range rows {
+		if len(row) > s.Width {
+			s.Width = len(row)
+		}
+	}
+
+	merges, err := r.f.GetMergeCells(name)
+	if err != nil {
+		return osheet.Sheet{}, err
+	}
+	for _, m := range merges {
+		sc, sr, err1 := excelize.CellNameToCoordinates(m.GetStartAxis())
+		ec, er, err2 := excelize.CellNameToCoordinates(m.GetEndAxis())
+		if err1 != nil || err2 != nil {
+			continue
+		}
+		s.Merges = append(s.Merges, osheet.Merge{StartRow: sr, StartCol: sc, EndRow: er, EndCol: ec})
+		s.Width, s.Height = max(s.Width, ec), max(s.Height, er)
+	}
+	// The stored dimension also covers styled blank cells, but is only
+	// trusted while the grid stays small: writers may declare A1:XFD1048576
+	if dim, err := r.f.GetSheetDimension(name); err == nil && dim != "" {
+		ref := dim[strings.LastIndex(dim, ":")+1:]
+		if col, row, err := excelize.CellNameToCoordinates(ref); err == nil {
+			width, height := max(s.Width, col), max(s.Height, row)
+			if int64(width)*int64(height) <= maxDimensionCells {
+				s.Width, s.Height = width, height
+			}
+		}
+	}
+
+	s.Cells = make([][]osheet.Cell, s.Height)
+	for ri := range s.Cells {
+		s.Cells[ri] = make([]osheet.Cell, s.Width)
+		for ci := range s.Cells[ri] {
+			raw := ""
+			if ri < len(rows) && ci < len(rows[ri]) {
+				raw = rows[ri][ci]
+			}
+			if s.Cells[ri][ci], err = r.cell(name, ci+1, ri+1, raw, covered(s.Merges, ri+1, ci+1)); err != nil {
+				return osheet.Sheet{}, err
+			}
+		}
+	}
+
+	if err := r.layout(name, &s); err != nil {
+		return osheet.Sheet{}, err
+	}
+	if s.View, err = r.view(name); err != nil {
+		return osheet.Sheet{}, err
+	}
+	if s.Validations, err = r.validations(name); err != nil {
+		return osheet.Sheet{}, err
+	}
+	if s.ConditionalFormats, err = r.conditionalFormats(name); err != nil {
+		return osheet.Sheet{}, err
+	}
+	if s.Images, err = r.images(name); err != nil {
+		return osheet.Sheet{}, err
+	}
+	if s.Tables, err = r.tables(name); err != nil {
+		return osheet.Sheet{}, err
+	}
+	s.AutoFilter = r.autoFilter(name)
+	return s, nil
+}
+
+// covered reports whether row, col lies inside a merge without being its anchor.
+func covered(merges []osheet.Merge, row, col int) bool {
+	for _, m := range merges {
+		if row >= m.StartRow && row <= m.EndRow && col >= m.StartCol && col <= m.EndCol {
+			return row != m.StartRow || col != m.StartCol
+		}
+	}
+	return false
+}
+
+// cell converts the cell at col, row whose raw (unformatted) value is raw.
+// excelize reports the anchor's value and formula for cells covered by a
+// merge, so those keep only their own style.
+func (r *bookReader) cell(sheet string, col, row int, raw string, merged bool) (osheet.Cell, error) {
+	axis, err := excelize.CoordinatesToCellName(col, row)
+	if err != nil {
+		return osheet.Cell{}, err
+	}
+	styleID, err := r.f.GetCellStyle(sheet, axis)
+	if err != nil {
+		return osheet.Cell{}, err
+	}
+	format := r.format(styleID)
+	if merged {
+		return osheet.Cell{NumFmt: format.numFmt, Style: format.style}, nil
+	}
+	typ, err := r.f.GetCellType(sheet, axis)
+	if err != nil {
+		return osheet.Cell{}, err
+	}
+	formula, err := r.f.GetCellFormula(sheet, axis)
+	if err != nil {
+		return osheet.Cell{}, err
+	}
+
+	c := osheet.Cell{Formula: formula, NumFmt: format.numFmt, Style: format.style}
+	switch {
+	case raw == "":
+		// Empty, or a formula without a cached value
+	case typ == excelize.CellTypeBool:
+		c.Type = osheet.ValueBool
+		c.BoolValue = raw == "1" || strings.EqualFold(raw, "true")
+		c.StringValue = strings.ToUpper(strconv.FormatBool(c.BoolValue))
+	case typ == excelize.CellTypeNumber || typ == excelize.CellTypeUnset:
+		n, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			c.Type, c.StringValue = osheet.ValueString, raw
+			break
+		}
+		c.StringValue = raw
+		if format.date {
+			c.Type, c.DateEpoch = osheet.ValueDateTime, n
+		} else {
+			c.Type, c.NumberValue = osheet.ValueNumber, n
+		}
+	default:
+		// Shared, inline and formula strings, errors and ISO dates keep their text
+		c.Type, c.StringValue = osheet.ValueString, raw
+		if formula == "" && (typ == excelize.CellTypeSharedString || typ == excelize.CellTypeInlineString) {
+			if c.RichText, err = r.richText(sheet, axis, format.style); err != nil {
+				return osheet.Cell{}, err
+			}
+		}
+	}
+	if c.Type == osheet.ValueDateTime && format.numFmt == builtinNumFmts[dateTimeNumFmt] {
+		// The writer applies this format to every date; leave it implicit
+		c.NumFmt = ""
+	}
+	return c, nil
+}
+
+// richText reads the runs of a text cell, or nil when none is formatted. Run
+// font attributes equal to the cell's or the workbook default are dropped, as
+// the writer fills them in from the cell.
+func (r *bookReader) richText(sheet, axis string, style *osheet.Style) ([]osheet.RichTextRun, error) {
+	runs, err := r.f.GetCellRichText(sheet, axis)
+	if err != nil {
+		return nil, err
+	}
+	var cellFont osheet.Font
+	if style != nil {
+		cellFont = style.Font
+	}
+	var out []osheet.RichTextRun
+	formatted := false
+	for _, run := range runs {
+		o := osheet.RichTextRun{Text: run.Text}
+		if fn := run.Font; fn != nil {
+			font := osheet.Font{
+				Bold:      fn.Bold,
+				Italic:    fn.Italic,
+				Underline: fn.Underline != "" && fn.Underline != "none",
+			}
+			if fn.Family != cellFont.Family && fn.Family != r.base.Family {
+				font.Family = fn.Family
+			}
+			if fn.Size != cellFont.Size && fn.Size != r.base.Size {
+				font.Size = fn.Size
+			}
+			if c := hexColor(fn.Color); c != cellFont.Color && c != hexColor(r.base.Color) {
+				font.Color = c
+			}
+			o.Font = &font
+			formatted = true
+		}
+		out = append(out, o)
+	}
+	if !formatted {
+		return nil, nil
+	}
+	return out, nil
+}
+
+// layout reads column widths and row heights that differ from the sheet defaults.
+func (r *bookReader) layout(name string, s *osheet.Sheet) error {
+	props, err := r.f.GetSheetProps(name)
+	if err != nil {
+		return err
+	}
+	defWidth := defaultColWidth
+	if props.DefaultColWidth != nil && *props.DefaultColWidth > 0 {
+		defWidth = *props.DefaultColWidth
+	}
+	for c := 1; c <= s.Width; c++ {
+		w, err := r.f.GetColWidth(name, columnName(c))
+		if err != nil {
+			return err
+		}
+		if w != defWidth {
+			s.Cols = append(s.Cols, osheet.ColSpec{Index: c, Width: w})
+		}
+	}
+	// Rows past the last stored row report the sheet default
+	defHeight, err := r.f.GetRowHeight(name, excelize.TotalRows)
+	if err != nil {
+		return err
+	}
+	for row := 1; row <= s.Height; row++ {
+		h, err := r.f.GetRowHeight(name, row)
+		if err != nil {
+			return err
+		}
+		if h != defHeight {
+			s.Rows = append(s.Rows, osheet.RowSpec{Index: row, Height: h})
+		}
+	}
+	return nil
+}
+
+// view reads frozen panes, tab colour, visibility and zoom. excelize does not
+// tell very hidden sheets apart, so they read back as hidden.
+func (r *bookReader) view(name string) (osheet.SheetView, error) {
+	var v osheet.SheetView
+	panes, err := r.f.GetPanes(name)
+	if err != nil {
+		return v, err
+	}
+	if panes.Freeze {
+		v.FrozenRows, v.FrozenCols = panes.YSplit, panes.XSplit
+	}
+	props, err := r.f.GetSheetProps(name)
+	if err != nil {
+		return v, err
+	}
+	if props.TabColorRGB != nil {
+		v.TabColor = hexColor(*props.TabColorRGB)
+	}
+	if visible, err := r.f.GetSheetVisible(name); err != nil {
+		return v, err
+	} else if !visible {
+		v.Visibility = osheet.SheetHidden
+	}
+	opts, err := r.f.GetSheetView(name, 0)
+	if err != nil {
+		return v, err
+	}
+	if opts.ZoomScale != nil && *opts.ZoomScale != 100 {
+		v.Zoom = *opts.ZoomScale
+	}
+	return v, nil
+}
+
+// validations reads data validation rules. Inline dropdown lists come back
+// as values; every other operand stays an Excel formula.
+func (r *bookReader) validations(name string) ([]osheet.DataValidation, error) {
+	dvs, err := r.f.GetDataValidations(name)
+	if err != nil {
+		return nil, err
+	}
+	var out []osheet.DataValidation
+	for _, dv := range dvs {
+		v := osheet.DataValidation{
+			Range:        dv.Sqref,
+			Kind:         dv.Type,
+			Operator:     dv.Operator,
+			Formula1:     dv.Formula1,
+			Formula2:     dv.Formula2,
+			AllowBlank:   dv.AllowBlank,
+			ErrorStyle:   validationErrorStyle(dv.ErrorStyle),
+			InputTitle:   derefString(dv.PromptTitle),
+			InputMessage: derefString(dv.Prompt),
+			ErrorTitle:   derefString(dv.ErrorTitle),
+			ErrorMessage: derefString(dv.Error),
+		}
+		if v.Kind == osheet.ValidationList && len(v.Formula1) >= 2 && strings.HasPrefix(v.Formula1, `"`) && strings.HasSuffix(v.Formula1, `"`) {
+			v.Values = strings.Split(v.Formula1[1:len(v.Formula1)-1], ",")
+			v.Formula1 = ""
+		}
+		if v.Kind == osheet.ValidationList || v.Kind == osheet.ValidationCustom {
+			v.Operator = ""
+		}
+		out = append(out, v)
+	}
+	return out, nil
+}
+
+// conditionalOperators maps excelize criteria back to comparison operators.
+var conditionalOperators = map[string]string{
+	"between":                  "between",
+	"not between":              "notBetween",
+	"equal to":                 "equal",
+	"not equal to":             "notEqual",
+	"greater than":             "greaterThan",
+	"less than":                "lessThan",
+	"greater than or equal to": "greaterThanOrEqual",
+	"less than or equal to":    "lessThanOrEqual",
+}
+
+// conditionalFormats reads cell value and formula rules, colour scales and
+// data bars; other rule types are outside the model and dropped. excelize
+// groups rules by range, so ranges come back sorted.
+func (r *bookReader) conditionalFormats(name string) ([]osheet.ConditionalFormat, error) {
+	byRange, err := r.f.GetConditionalFormats(name)
+	if err != nil {
+		return nil, err
+	}
+	ranges := make([]string, 0, len(byRange))
+	for ref := range byRange {
+		ranges = append(ranges, ref)
+	}
+	sort.Strings(ranges)
+	var out []osheet.ConditionalFormat
+	for _, ref := range ranges {
+		for _, opts := range byRange[ref] {
+			cf := osheet.ConditionalFormat{Range: ref, StopIfTrue: opts.StopIfTrue}
+			switch opts.Type {
+			case "cell":
+				cf.Kind, cf.Operator = osheet.ConditionalCellValue, conditionalOperators[opts.Criteria]
+				cf.Formula1 = opts.Value
+				if cf.Operator == "between" || cf.Operator == "notBetween" {
+					cf.Formula1, cf.Formula2 = opts.MinValue, opts.MaxValue
+				}
+			case "formula":
+				cf.Kind, cf.Formula1 = osheet.ConditionalFormula, opts.Criteria
+			case "2_color_scale", "3_color_scale":
+				cf.Kind = osheet.ConditionalColorScale
+				for _, c := range []string{opts.MinColor, opts.MidColor, opts.MaxColor} {
+					if c = hexColor(c); c != "" {
+						cf.Colors = append(cf.Colors, c)
+					}
+				}
+			case "data_bar":
+				cf.Kind, cf.Colors = osheet.ConditionalDataBar, []string{hexColor(opts.BarColor)}
+			default:
+				continue
+			}
+			if opts.Format != nil {
+				st, err := r.f.GetConditionalStyle(*opts.Format)
+				if err != nil {
+					return nil, err
+				}
+				cf.Style = r.fromExcelizeStyle(st)
+			}
+			out = append(out, cf)
+		}
+	}
+	return out, nil
+}
+
+// images reads the pictures placed over cells. Only the types
+// osheet.CheckImage accepts are kept. excelize does not report how a picture
+// is scaled, so images come back at their own size.
+func (r *bookReader) images(name string) ([]osheet.Image, error) {
+	cells, err := r.f.GetPictureCells(name)
+	if err != nil {
+		return nil, err
+	}
+	var out []osheet.Image
+	for _, cell := range cells {
+		pics, err := r.f.GetPictures(name, cell)
+		if err != nil {
+			return nil, err
+		}
+		for _, pic := range pics {
+			if _, _, _, err := osheet.CheckImage(pic.File); err != nil {
+				continue
+			}
+			img := osheet.Image{Cell: cell, Data: pic.File}
+			if pic.Format != nil {
+				img.AltText = pic.Format.AltText
+			}
+			out = append(out, img)
+		}
+	}
+	return out, nil
+}
+
+// tables reads the sheet's tables. excelize does not report whether a table
+// shows its header row, so every table comes back with one.
+func (r *bookReader) tables(name string) ([]osheet.Table, error) {
+	tables, err := r.f.GetTables(name)
+	if err != nil {
+		return nil, err
+	}
+	var out []osheet.Table
+	for _, t := range tables {
+		out = append(out, osheet.Table{
+			Name:      t.Name,
+			Range:     strings.ReplaceAll(t.Range, "$", ""),
+			HeaderRow: t.ShowHeaderRow == nil || *t.ShowHeaderRow,
+			Style:     t.StyleName,
+		})
+	}
+	return out, nil
+}
+
+// autoFilter reads the sheet's autofilter range from the hidden
+// _xlnm._FilterDatabase name Excel keeps next to it.
+func (r *bookReader) autoFilter(name string) string {
+	for _, dn := range r.f.GetDefinedName() {
+		if dn.Name != "_xlnm._FilterDatabase" || dn.Scope != name {
+			continue
+		}
+		ref := dn.RefersTo[strings.LastIndex(dn.RefersTo, "!")+1:]
+		return strings.ReplaceAll(ref, "$", "")
+	}
+	return ""
+}
+
+func validationErrorStyle(style *string) string {
+	if s := derefString(style); s == "warning" || s == "information" {
+		return s
+	}
+	return ""
+}
+
+func derefString(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+// format resolves a style id into the osheet style and number format.
+func (r *bookReader) format(id int) cellFormat {
+	if id == 0 {
+		return cellFormat{}
+	}
+	if cf, ok := r.formats[id]; ok {
+		return cf
+	}
+	var cf cellFormat
+	if st, err := r.f.GetStyle(id); err == nil {
+		cf = cellFormat{style: r.fromExcelizeStyle(st)}
+		switch {
+		case st.CustomNumFmt != nil:
+			cf.numFmt = *st.CustomNumFmt
+		case st.NumFmt != 0:
+			cf.numFmt = builtinNumFmts[st.NumFmt]
+		}
+		cf.date = isDateFormat(cf.numFmt)
+	}
+	r.formats[id] = cf
+	return cf
+}
+
+// fromExcelizeStyle is the inverse of toExcelizeStyle. Font attributes equal
+// to the workbook default font are dropped; nil means default formatting.
+func (r *bookReader) fromExcelizeStyle(st *excelize.Style) *osheet.Style {
+	var s osheet.Style
+	if fn := st.Font; fn != nil {
+		s.Font = osheet.Font{
+			Bold:      fn.Bold,
+			Italic:    fn.Italic,
+			Underline: fn.Underline != "" && fn.Underline != "none",
+		}
+		if fn.Family != r.base.Family {
+			s.Font.Family = fn.Family
+		}
+		if fn.Size != r.base.Size {
+			s.Font.Size = fn.Size
+		}
+		if c := hexColor(fn.Color); c != hexColor(r.base.Color) {
+			s.Font.Color = c
+		}
+	}
+	if st.Fill.Type == "pattern" && st.Fill.Pattern == 1 && len(st.Fill.Color) > 0 {
+		s.Fill = hexColor(st.Fill.Color[0])
+	}
+	for _, b := range st.Border {
+		side := osheet.BorderSide{Style: borderStyleName(b.Style), Color: hexColor(b.Color)}
+		switch b.Type {
+		case "left":
+			s.Border.Left = side
+		case "right":
+			s.Border.Right = side
+		case "top":
+			s.Border.Top = side
+		case "bottom":
+			s.Border.Bottom = side
+		}
+	}
+	if a := st.Alignment; a != nil {
+		s.Alignment = osheet.Alignment{
+			Horizontal: a.Horizontal,
+			Vertical:   a.Vertical,
+			Wrap:       a.WrapText,
+			Indent:     a.Indent,
+		}
+	}
+	if p := st.Protection; p != nil {
+		s.Protection = osheet.CellProtection{Unlocked: !p.Locked, HideFormula: p.Hidden}
+	}
+	if s == (osheet.Style{}) {
+		return nil
+	}
+	return &s
+}
+
+// borderStyleName is the inverse of borderStyleIndex.
+func borderStyleName(idx int) string {
+	switch idx {
+	case 2:
+		return "medium"
+	case 3:
+		return "dashed"
+	case 4:
+		return "dotted"
+	case 5:
+		return "thick"
+	case 6:
+		return "double"
+	case 7:
+		return "hair"
+	default:
+		return "thin"
+	}
+}
+
+// hexColor normalises "#RRGGBB" and "AARRGGBB" to upper-case RRGGBB.
+func hexColor(c string) string {
+	c = strings.TrimPrefix(c, "#")
+	if len(c) == 8 {
+		c = c[2:]
+	}
+	return strings.ToUpper(c)
+}
+
+// isDateFormat reports whether a number format code renders dates or times:
+// it contains y, m, d, h or s outside quoted text, escapes and [colour] tags.
+func isDateFormat(code string) bool {
+	inQuote := false
+	for i := 0; i < len(code); i++ {
+		ch := code[i]
+		switch {
+		case inQuote:
+			inQuote = ch != '"'
+		case ch == '"':
+			inQuote = true
+		case ch == '\\' || ch == '_' || ch == '*':
+			i++
+		case ch == '[':
+			end := strings.IndexByte(code[i:], ']')
+			if end < 0 {
+				return false
+			}
+			// Elapsed time such as [h] or [mm] is a time format
+			if tag := strings.ToLower(code[i+1 : i+end]); tag != "" && strings.Trim(tag, "hms") == "" {
+				return true
+			}
+			i += end
+		default:
+			switch ch | 0x20 {
+			case 'y', 'm', 'd', 'h', 's':
+				return true
+			}
+		}
+	}
+	return false
+}
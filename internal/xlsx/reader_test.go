@@ -0,0 +1,229 @@
+package xlsx
+
+import (
+	"bytes"
+	"encoding/base64"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/xuri/excelize/v2"
+
+	osmodel "github.com/romanitalian/osheet2xlsx/v3/internal/osheet"
+)
+
+// roundTripPNG is a 1x1 PNG.
+var roundTripPNG, _ = base64.StdEncoding.DecodeString("iVBORw0KGgoAAAANSUhEUgAAAAEAAAABCAYAAAAfFcSJAAAADUlEQVR42mNk+M9QDwADhgGAWjR9awAAAABJRU5ErkJggg==")
+
+// roundTripBook covers document properties, every cell type, rich text, formulas, styles, number formats, layout, view, validations,
+// conditional formats, images, tables, defined names and cell protection.
+func roundTripBook() *osmodel.Book {
+	bold := &osmodel.Style{
+		Font:      osmodel.Font{Bold: true, Color: "FF0000"},
+		Fill:      "FFFF00",
+		Border:    osmodel.Border{Bottom: osmodel.BorderSide{Style: "double", Color: "0000FF"}},
+		Alignment: osmodel.Alignment{Horizontal: "center", Wrap: true},
+	}
+	return &osmodel.Book{Title: "Ledger", Properties: osmodel.DocProperties{
+		Author:      "Ann",
+		Description: "Paid shares",
+		Keywords:    "q3; sales",
+		Created:     time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC),
+		Modified:    time.Date(2024, 3, 2, 17, 30, 0, 0, time.UTC),
+		Custom: []osmodel.CustomProperty{
+			{Name: "Owner", Value: "Finance"},
+			{Name: "Pages", Value: 3.0},
+			{Name: "Final", Value: true},
+			{Name: "Reviewed", Value: time.Date(2024, 3, 3, 0, 0, 0, 0, time.UTC)},
+		},
+	}, Sheets: []osmodel.Sheet{
+		{
+			Name:   "Data",
+			Width:  4,
+			Height: 3,
+			Cells: [][]osmodel.Cell{
+				{
+					{Type: osmodel.ValueString, StringValue: "Name", Style: bold},
+					{Type: osmodel.ValueString, StringValue: "Amount", Style: bold},
+					{Type: osmodel.ValueString, StringValue: "Paid", Style: bold},
+					{Type: osmodel.ValueString, StringValue: "Due", Style: bold},
+				},
+				{
+					{Type: osmodel.ValueString, StringValue: "Ann", Style: &osmodel.Style{Protection: osmodel.CellProtection{Unlocked: true}}},
+					{Type: osmodel.ValueNumber, NumberValue: 0.125, NumFmt: "0.00%"},
+					{Type: osmodel.ValueBool, BoolValue: true},
+					{Type: osmodel.ValueDateTime, DateEpoch: 45293.5},
+				},
+				{
+					{
+						Type: osmodel.ValueString, StringValue: "Total due", Style: &osmodel.Style{Font: osmodel.Font{Size: 14}},
+						RichText: []osmodel.RichTextRun{{Text: "Total", Font: &osmodel.Font{Bold: true, Color: "00AA00"}}, {Text: " due"}},
+					},
+					{Formula: "SUM(B2:B2)", Style: &osmodel.Style{Protection: osmodel.CellProtection{HideFormula: true}}},
+					{},
+					{Type: osmodel.ValueDateTime, DateEpoch: 45294, NumFmt: "yyyy-mm-dd"},
+				},
+			},
+			Merges: []osmodel.Merge{{StartRow: 3, StartCol: 2, EndRow: 3, EndCol: 3}},
+			Cols:   []osmodel.ColSpec{{Index: 1, Width: 24}, {Index: 4, Width: 12.5}},
+			Rows:   []osmodel.RowSpec{{Index: 1, Height: 30}},
+			View:   osmodel.SheetView{FrozenRows: 1, TabColor: "3366FF", Zoom: 125},
+			Images: []osmodel.Image{{Cell: "E1", Data: roundTripPNG, AltText: "logo"}},
+			Validations: []osmodel.DataValidation{
+				{Range: "A2:A3", Kind: osmodel.ValidationList, Values: []string{"Ann", "Bob"}, InputTitle: "Name", InputMessage: "Pick one"},
+				{Range: "B2:B3", Kind: osmodel.ValidationDecimal, Operator: "between", Formula1: "0", Formula2: "1", AllowBlank: true, ErrorStyle: "warning", ErrorMessage: "0-100%"},
+			},
+			ConditionalFormats: []osmodel.ConditionalFormat{
+				{Range: "B2:B3", Kind: osmodel.ConditionalCellValue, Operator: "greaterThan", Formula1: "0.5", Style: &osmodel.Style{Fill: "FFC7CE", Font: osmodel.Font{Color: "9C0006"}}},
+				{Range: "C2:C3", Kind: osmodel.ConditionalColorScale, Colors: []string{"F8696B", "FFEB84", "63BE7B"}},
+				{Range: "D2:D3", Kind: osmodel.ConditionalFormula, Formula1: "D2<TODAY()", Style: &osmodel.Style{Font: osmodel.Font{Bold: true}}, StopIfTrue: true},
+				{Range: "E2:E3", Kind: osmodel.ConditionalDataBar, Colors: []string{"638EC6"}},
+			},
+			Tables: []osmodel.Table{{Name: "People", Range: "A1:D2", HeaderRow: true, Style: "TableStyleLight9"}},
+		},
+		{
+			Name:       "Notes",
+			Width:      1,
+			Height:     1,
+			Cells:      [][]osmodel.Cell{{{Formula: "Data!B2*2"}}},
+			View:       osmodel.SheetView{Visibility: osmodel.SheetHidden},
+			AutoFilter: "A1:A1",
+		},
+	}, DefinedNames: []osmodel.DefinedName{
+		{Name: "Amounts", RefersTo: "Data!$B$2:$B$3", Comment: "paid share"},
+		{Name: "Doubled", RefersTo: "Notes!$A$1", Scope: "Notes"},
+	}}
+}
+
+func TestReadBook_RoundTrip(t *testing.T) {
+	want := roundTripBook()
+	dir := t.TempDir()
+	xlsxPath := filepath.Join(dir, "book.xlsx")
+	if err := WriteBook(want, xlsxPath); err != nil {
+		t.Fatalf("WriteBook: %v", err)
+	}
+	got, err := ReadBook(xlsxPath)
+	if err != nil {
+		t.Fatalf("ReadBook: %v", err)
+	}
+	compareBooks(t, "xlsx", got, want)
+
+	// xlsx -> osheet -> model must be lossless too
+	osheetPath := filepath.Join(dir, "book.osheet")
+	if err := osmodel.WriteBook(got, osheetPath); err != nil {
+		t.Fatalf("osheet.WriteBook: %v", err)
+	}
+	back, err := osmodel.ReadBook(osheetPath)
+	if err != nil {
+		t.Fatalf("osheet.ReadBook: %v", err)
+	}
+	compareBooks(t, "osheet", back, want)
+}
+
+func compareBooks(t *testing.T, stage string, got, want *osmodel.Book) {
+	t.Helper()
+	if len(got.Sheets) != len(want.Sheets) {
+		t.Fatalf("%s: sheets = %d, want %d", stage, len(got.Sheets), len(want.Sheets))
+	}
+	if !reflect.DeepEqual(got.DefinedNames, want.DefinedNames) {
+		t.Errorf("%s: names = %+v, want %+v", stage, got.DefinedNames, want.DefinedNames)
+	}
+	if got.Title != want.Title || !reflect.DeepEqual(got.Properties, want.Properties) {
+		t.Errorf("%s: title = %q, properties = %+v, want %q, %+v", stage, got.Title, got.Properties, want.Title, want.Properties)
+	}
+	for i := range want.Sheets {
+		g, w := &got.Sheets[i], &want.Sheets[i]
+		if g.Name != w.Name || g.Width != w.Width || g.Height != w.Height {
+			t.Errorf("%s: sheet %d = %s %dx%d, want %s %dx%d", stage, i, g.Name, g.Width, g.Height, w.Name, w.Width, w.Height)
+			continue
+		}
+		for r := range w.Cells {
+			for c, wc := range w.Cells[r] {
+				gc := g.Cells[r][c]
+				if gc.Type != wc.Type || gc.StringValue != wc.StringValue && wc.Type == osmodel.ValueString ||
+					gc.NumberValue != wc.NumberValue || gc.BoolValue != wc.BoolValue || gc.DateEpoch != wc.DateEpoch ||
+					gc.Formula != wc.Formula || gc.NumFmt != wc.NumFmt || !reflect.DeepEqual(gc.Style, wc.Style) ||
+					!reflect.DeepEqual(gc.RichText, wc.RichText) {
+					t.Errorf("%s: %s!R%dC%d = %+v (style %+v), want %+v (style %+v)", stage, w.Name, r+1, c+1, gc, gc.Style, wc, wc.Style)
+				}
+			}
+		}
+		if !reflect.DeepEqual(g.Merges, w.Merges) {
+			t.Errorf("%s: merges = %+v, want %+v", stage, g.Merges, w.Merges)
+		}
+		if !reflect.DeepEqual(g.Cols, w.Cols) {
+			t.Errorf("%s: cols = %+v, want %+v", stage, g.Cols, w.Cols)
+		}
+		if !reflect.DeepEqual(g.Rows, w.Rows) {
+			t.Errorf("%s: rows = %+v, want %+v", stage, g.Rows, w.Rows)
+		}
+		if g.View != w.View {
+			t.Errorf("%s: view = %+v, want %+v", stage, g.View, w.View)
+		}
+		if !reflect.DeepEqual(g.Validations, w.Validations) {
+			t.Errorf("%s: validations = %+v, want %+v", stage, g.Validations, w.Validations)
+		}
+		if len(g.Images) != len(w.Images) {
+			t.Errorf("%s: images = %d, want %d", stage, len(g.Images), len(w.Images))
+		}
+		for j := 0; j < len(g.Images) && j < len(w.Images); j++ {
+			// Sources differ between formats
+			gi, wi := g.Images[j], w.Images[j]
+			if gi.Cell != wi.Cell || !bytes.Equal(gi.Data, wi.Data) || gi.Width != wi.Width || gi.Height != wi.Height || gi.AltText != wi.AltText {
+				t.Errorf("%s: image %d = %s %dx%d %q, want %s %dx%d %q", stage, j, gi.Cell, gi.Width, gi.Height, gi.AltText, wi.Cell, wi.Width, wi.Height, wi.AltText)
+			}
+		}
+		if !reflect.DeepEqual(g.ConditionalFormats, w.ConditionalFormats) {
+			t.Errorf("%s: conditional formats = %+v, want %+v", stage, g.ConditionalFormats, w.ConditionalFormats)
+		}
+		if g.AutoFilter != w.AutoFilter || !reflect.DeepEqual(g.Tables, w.Tables) {
+			t.Errorf("%s: autofilter = %q, tables = %+v, want %q, %+v", stage, g.AutoFilter, g.Tables, w.AutoFilter, w.Tables)
+		}
+	}
+}
+
+func TestReadBook_Dimension(t *testing.T) {
+	for dim, want := range map[string][2]int{
+		"A1:C3":         {3, 3}, // styled blank cells extend the grid
+		"A1:XFD1048576": {2, 1}, // but a sheet-sized dimension is ignored
+	} {
+		f := excelize.NewFile()
+		_ = f.SetSheetRow("Sheet1", "A1", &[]interface{}{1, "x"})
+		if err := f.SetSheetDimension("Sheet1", dim); err != nil {
+			t.Fatalf("%s: set dimension: %v", dim, err)
+		}
+		path := filepath.Join(t.TempDir(), "dim.xlsx")
+		if err := f.SaveAs(path); err != nil {
+			t.Fatalf("%s: save: %v", dim, err)
+		}
+		_ = f.Close()
+		book, err := ReadBook(path)
+		if err != nil {
+			t.Fatalf("%s: ReadBook: %v", dim, err)
+		}
+		if s := book.Sheets[0]; s.Width != want[0] || s.Height != want[1] || len(s.Cells) != want[1] {
+			t.Errorf("%s: sheet %dx%d with %d rows, want %dx%d", dim, s.Width, s.Height, len(s.Cells), want[0], want[1])
+		}
+	}
+}
+
+func TestIsDateFormat(t *testing.T) {
+	cases := map[string]bool{
+		"":                 false,
+		"0.00%":            false,
+		`"$"#,##0.00`:      false,
+		"[Red]0.00":        false,
+		`0 "days"`:         false,
+		"[$€-407] #,##0":   false,
+		"yyyy-mm-dd":       true,
+		"m/d/yy h:mm":      true,
+		"[h]:mm:ss":        true,
+		"[Blue]dd.mm.yyyy": true,
+	}
+	for code, want := range cases {
+		if got := isDateFormat(code); got != want {
+			t.Errorf("isDateFormat(%q) = %v, want %v", code, got, want)
+		}
+	}
+}
@@ -0,0 +1,260 @@
+package xlsx
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/xuri/excelize/v2"
+
+	"github.com/romanitalian/osheet2xlsx/v3/internal/osheet"
+)
+
+// WriteBookReader writes a workbook row by row from a BookReader, so neither
+// the source nor the output workbook is held in memory as a whole.
+// Rows are expected in ascending order (see osheet.ErrRowOrder).
+func WriteBookReader(br *osheet.BookReader, outPath string) error {
+	return WriteBookReaderWithOptions(br, outPath, Options{})
+}
+
+// WriteBookReaderWithOptions is WriteBookReader with options; StreamThreshold
+// is ignored since every sheet is streamed.
+func WriteBookReaderWithOptions(br *osheet.BookReader, outPath string, opts Options) error {
+	f := excelize.NewFile()
+	defer func() { _ = f.Close() }()
+
+	defaultSheet := f.GetSheetName(0)
+	if defaultSheet == "" {
+		defaultSheet = "Sheet1"
+	}
+	styles := newStyleCache(f)
+
+	meta := br.Book()
+	formulas := newFormulaTranslator(meta.Sheets, opts.Warn)
+	names := addSheets(f, defaultSheet, meta.Sheets)
+	active := activateSheet(f, meta.Sheets)
+	for i := range meta.Sheets {
+		s, name := &meta.Sheets[i], names[i]
+		if !opts.StripProtection {
+			protectSheet(f, name, s.Protection, opts.Warn)
+		}
+		rows, err := br.OpenSheet(i)
+		if err != nil {
+			return fmt.Errorf("open sheet %s: %w", name, err)
+		}
+		err = streamRows(f, styles, formulas, name, s, rows, opts.AsTable)
+		_ = rows.Close()
+		if err != nil {
+			return fmt.Errorf("stream sheet %s: %w", name, err)
+		}
+	}
+	addDefinedNames(f, formulas, meta.DefinedNames)
+	hideSheets(f, names, meta.Sheets, active, opts.Warn)
+	if !opts.StripProtection {
+		protectWorkbook(f, meta.Protection, opts.Warn)
+	}
+	setDocProps(f, meta, opts.Warn)
+	return saveWithProps(f, meta, outPath)
+}
+
+// streamSheet writes a large in-memory sheet through excelize's StreamWriter.
+func streamSheet(f *excelize.File, styles *styleCache, formulas *formulaTranslator, name string, s *osheet.Sheet, asTable bool) error {
+	// Register styles up front so the stream only references existing IDs
+	for r := 0; r < len(s.Cells); r++ {
+		for c := 0; c < len(s.Cells[r]); c++ {
+			styles.id(s.Cells[r][c])
+		}
+	}
+	return streamRows(f, styles, formulas, name, s, osheet.NewSheetReader(s), asTable)
+}
+
+// streamRows writes rows from a SheetReader through excelize's StreamWriter,
+// taking merges, default sizes, column widths and row specs from the sheet
+// metadata. The stream writer imposes an order: column widths before any
+// SetRow, rows strictly ascending (heights and visibility go into RowOpts),
+// and merges, data validations, conditional formats, images and the table
+// before Flush. Sheet-level settings made through the regular API must happen
+// before the stream writer is created, because Flush replaces the worksheet
+// part. With asTable a table is synthesized for a detected header row.
+func streamRows(f *excelize.File, styles *styleCache, formulas *formulaTranslator, name string, s *osheet.Sheet, rows osheet.SheetReader, asTable bool) error {
+	tables := planTables(formulas, name, s, true, asTable)
+	setSheetDefaults(f, name, s)
+	addAutoFilter(f, name, tables, formulas.warn)
+	setSheetView(f, name, s.View, formulas.warn)
+	sw, err := f.NewStreamWriter(name)
+	if err != nil {
+		return err
+	}
+
+	// Column widths must precede rows. The stream writer cannot hide columns,
+	// so hidden ones get zero width, which Excel and LibreOffice show as hidden.
+	for _, c := range s.Cols {
+		width := c.Width
+		if c.Hidden {
+			width = 0
+		}
+		if c.Index <= 0 || (width <= 0 && !c.Hidden) {
+			continue
+		}
+		if err := sw.SetColWidth(c.Index, c.Index, width); err != nil {
+			return err
+		}
+	}
+
+	// Rows that only carry a height or visibility are interleaved with data rows
+	specs := make(map[int]excelize.RowOpts, len(s.Rows))
+	var specOnly []int
+	for _, rh := range s.Rows {
+		if rh.Index <= 0 || (rh.Height <= 0 && !rh.Hidden) {
+			continue
+		}
+		if _, dup := specs[rh.Index]; !dup {
+			specOnly = append(specOnly, rh.Index)
+		}
+		specs[rh.Index] = excelize.RowOpts{Height: rh.Height, Hidden: rh.Hidden}
+	}
+	// The stream writer takes column names from the header row as written,
+	// so it gets the names tableHeader picks and is written even when empty
+	header := 0
+	if len(tables.tables) > 0 {
+		header = tables.tables[0].area.row1
+		if _, ok := specs[header]; !ok {
+			specOnly = append(specOnly, header)
+		}
+	}
+	sort.Ints(specOnly)
+
+	setRow := func(r int, values []interface{}) error {
+		var rowOpts []excelize.RowOpts
+		if opts, ok := specs[r]; ok {
+			rowOpts = append(rowOpts, opts)
+		}
+		return sw.SetRow(safeCoordinatesToCellName(1, r), values, rowOpts...)
+	}
+	flushSpecs := func(before int) error {
+		for len(specOnly) > 0 && specOnly[0] < before {
+			var values []interface{}
+			if specOnly[0] == header {
+				values = streamHeader(nil, nil, tables.tables[0].area, styles)
+			}
+			if err := setRow(specOnly[0], values); err != nil {
+				return err
+			}
+			specOnly = specOnly[1:]
+		}
+		return nil
+	}
+
+	var detector headerDetector
+	for rows.Next() {
+		r, row := rows.Row()
+		if tables.detect {
+			detector.row(r, row)
+		}
+		if err := flushSpecs(r); err != nil {
+			return err
+		}
+		if len(specOnly) > 0 && specOnly[0] == r {
+			specOnly = specOnly[1:]
+		}
+		values := make([]interface{}, len(row))
+		empty := true
+		for c := 0; c < len(row); c++ {
+			axis := safeCoordinatesToCellName(c+1, r)
+			formula, result := formulas.formula(name, axis, row[c]), formulas.result(name, axis)
+			if formulas.valuesOnly && result != nil {
+				formula = ""
+			}
+			values[c] = streamCellValue(row[c], formula, result, styles.id(row[c]))
+			annotateCell(f, formulas, name, axis, row[c])
+			empty = empty && values[c] == nil
+		}
+		if r == header {
+			values, empty = streamHeader(values, row, tables.tables[0].area, styles), false
+		}
+		if _, ok := specs[r]; empty && !ok {
+			continue
+		}
+		if err := setRow(r, values); err != nil {
+			return err
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	if err := flushSpecs(math.MaxInt); err != nil {
+		return err
+	}
+
+	for _, m := range validMerges(s.Merges) {
+		ax1 := safeCoordinatesToCellName(m.StartCol, m.StartRow)
+		ax2 := safeCoordinatesToCellName(m.EndCol, m.EndRow)
+		if err := sw.MergeCell(ax1, ax2); err != nil {
+			return err
+		}
+	}
+
+	addValidations(f, formulas, name, s.Validations)
+	addConditionalFormats(f, styles, formulas, name, s.ConditionalFormats)
+	addImages(f, formulas, name, s.Images)
+	if tables.detect {
+		if t, ok := detector.table(s.Merges); ok {
+			tables.tables = append(tables.tables, t)
+		}
+	}
+	for i := range tables.tables {
+		if err := sw.AddTable(excelizeTable(&tables.tables[i])); err != nil {
+			formulas.report(name, tables.tables[i].Range, fmt.Sprintf("table skipped: %v", err))
+		}
+	}
+	return sw.Flush()
+}
+
+// streamHeader replaces the header cells of a streamed table in values, the
+// stream values of row, with the column names tableHeader picks. Cells keep
+// their style.
+func streamHeader(values []interface{}, row []osheet.Cell, area cellArea, styles *styleCache) []interface{} {
+	for len(values) < area.col2 {
+		values = append(values, nil)
+	}
+	for j, text := range tableHeader(row, area) {
+		col := area.col1 + j
+		styleID := 0
+		if col <= len(row) {
+			styleID = styles.id(row[col-1])
+		}
+		values[col-1] = excelize.Cell{StyleID: styleID, Value: text}
+	}
+	return values
+}
+
+// streamCellValue converts a cell with its translated formula and computed
+// result into a stream value; nil skips the cell. A result without a formula
+// replaces the cell value. It mirrors the value rules of writeSheet.
+func streamCellValue(cell osheet.Cell, formula string, result interface{}, styleID int) interface{} {
+	out := excelize.Cell{StyleID: styleID}
+	if formula != "" || result != nil {
+		out.Formula, out.Value = formula, result
+		return out
+	}
+	switch cell.Type {
+	case osheet.ValueString:
+		if runs := richText(cell); runs != nil {
+			out.Value = runs
+		} else {
+			out.Value = cell.StringValue
+		}
+	case osheet.ValueNumber:
+		out.Value = cell.NumberValue
+	case osheet.ValueBool:
+		out.Value = cell.BoolValue
+	case osheet.ValueDateTime:
+		out.Value = cell.DateEpoch
+	default:
+		if cell.StringValue == "" && styleID == 0 {
+			return nil
+		}
+		out.Value = cell.StringValue
+	}
+	return out
+}
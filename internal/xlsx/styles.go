@@ -0,0 +1,177 @@
+package xlsx
+
+import (
+	"github.com/xuri/excelize/v2"
+
+	"github.com/romanitalian/osheet2xlsx/v3/internal/osheet"
+)
+
+// dateTimeNumFmt is the built-in Excel "m/d/yy h:mm" format used for date cells.
+const dateTimeNumFmt = 22
+
+// styleKey identifies a unique combination of cell style and value formatting.
+type styleKey struct {
+	style  osheet.Style
+	numFmt string
+	date   bool
+}
+
+// styleCache registers each distinct style once per workbook, keeping cell
+// styles and conditional format styles apart.
+type styleCache struct {
+	f           *excelize.File
+	ids         map[styleKey]int
+	conditional map[osheet.Style]int
+}
+
+func newStyleCache(f *excelize.File) *styleCache {
+	return &styleCache{f: f, ids: make(map[styleKey]int), conditional: make(map[osheet.Style]int)}
+}
+
+// id returns the excelize style ID for the cell, or 0 when default formatting applies.
+func (c *styleCache) id(cell osheet.Cell) int {
+	key := styleKey{numFmt: cell.NumFmt}
+	if key.numFmt == "" {
+		key.date = cell.Type == osheet.ValueDateTime && cell.Formula == ""
+	}
+	if cell.Style != nil {
+		key.style = *cell.Style
+	}
+	if key == (styleKey{}) {
+		return 0
+	}
+	if id, ok := c.ids[key]; ok {
+		return id
+	}
+	st := toExcelizeStyle(key.style)
+	if key.numFmt != "" {
+		numFmt := key.numFmt
+		st.CustomNumFmt = &numFmt
+	} else if key.date {
+		st.NumFmt = dateTimeNumFmt
+	}
+	id := safeNewStyle(c.f, st)
+	c.ids[key] = id
+	return id
+}
+
+// conditionalID returns the excelize conditional style ID for st. Conditional
+// formats only override font, fill and border.
+func (c *styleCache) conditionalID(st osheet.Style) (int, error) {
+	st.Alignment = osheet.Alignment{}
+	st.Protection = osheet.CellProtection{}
+	if id, ok := c.conditional[st]; ok {
+		return id, nil
+	}
+	id, err := c.f.NewConditionalStyle(toExcelizeStyle(st))
+	if err != nil {
+		return 0, err
+	}
+	c.conditional[st] = id
+	return id, nil
+}
+
+// toExcelizeStyle maps the osheet style model onto excelize's style definition.
+func toExcelizeStyle(s osheet.Style) *excelize.Style {
+	st := &excelize.Style{}
+	if s.Font != (osheet.Font{}) {
+		st.Font = toExcelizeFont(s.Font)
+	}
+	if s.Fill != "" {
+		st.Fill = excelize.Fill{Type: "pattern", Pattern: 1, Color: []string{s.Fill}}
+	}
+	sides := []struct {
+		name string
+		side osheet.BorderSide
+	}{{"left", s.Border.Left}, {"right", s.Border.Right}, {"top", s.Border.Top}, {"bottom", s.Border.Bottom}}
+	for _, b := range sides {
+		if b.side.Style == "" {
+			continue
+		}
+		color := b.side.Color
+		if color == "" {
+			color = "000000"
+		}
+		st.Border = append(st.Border, excelize.Border{Type: b.name, Color: color, Style: borderStyleIndex(b.side.Style)})
+	}
+	if s.Alignment != (osheet.Alignment{}) {
+		st.Alignment = &excelize.Alignment{
+			Horizontal: s.Alignment.Horizontal,
+			Vertical:   s.Alignment.Vertical,
+			WrapText:   s.Alignment.Wrap,
+			Indent:     s.Alignment.Indent,
+		}
+	}
+	if s.Protection != (osheet.CellProtection{}) {
+		st.Protection = &excelize.Protection{Locked: !s.Protection.Unlocked, Hidden: s.Protection.HideFormula}
+	}
+	return st
+}
+
+func toExcelizeFont(fn osheet.Font) *excelize.Font {
+	out := &excelize.Font{
+		Family: fn.Family,
+		Size:   fn.Size,
+		Bold:   fn.Bold,
+		Italic: fn.Italic,
+		Color:  fn.Color,
+	}
+	if fn.Underline {
+		out.Underline = "single"
+	}
+	return out
+}
+
+// richText returns the cell's runs for excelize, or nil for plain text. A run
+// font is complete in the file, so its missing family, size and color are
+// taken from the cell style.
+func richText(cell osheet.Cell) []excelize.RichTextRun {
+	if len(cell.RichText) == 0 {
+		return nil
+	}
+	var base osheet.Font
+	if cell.Style != nil {
+		base = cell.Style.Font
+	}
+	runs := make([]excelize.RichTextRun, len(cell.RichText))
+	for i, run := range cell.RichText {
+		runs[i].Text = run.Text
+		if run.Font == nil {
+			continue
+		}
+		fn := *run.Font
+		if fn.Family == "" {
+			fn.Family = base.Family
+		}
+		if fn.Size <= 0 {
+			fn.Size = base.Size
+		}
+		if fn.Color == "" {
+			fn.Color = base.Color
+		}
+		runs[i].Font = toExcelizeFont(fn)
+	}
+	return runs
+}
+
+// borderStyleIndex maps border style names to excelize border style indexes.
+func borderStyleIndex(name string) int {
+	switch name {
+	case "thin":
+		return 1
+	case "medium":
+		return 2
+	case "dashed":
+		return 3
+	case "dotted":
+		return 4
+	case "thick":
+		return 5
+	case "double":
+		return 6
+	case "hair":
+		return 7
+	default:
+		return 1
+	}
+}
@@ -0,0 +1,310 @@
+package xlsx
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/xuri/excelize/v2"
+
+	"github.com/romanitalian/osheet2xlsx/v3/internal/osheet"
+)
+
+// detectedTableStyle is the style of tables synthesized by Options.AsTable.
+const detectedTableStyle = "TableStyleMedium2"
+
+// cellArea is an inclusive 1-based cell range.
+type cellArea struct{ col1, row1, col2, row2 int }
+
+// parseArea reads an "A1:B2" range (or a single cell) in any corner order.
+func parseArea(ref string) (cellArea, bool) {
+	first, last, ok := strings.Cut(ref, ":")
+	if !ok {
+		last = first
+	}
+	c1, r1, err1 := excelize.CellNameToCoordinates(strings.ReplaceAll(first, "$", ""))
+	c2, r2, err2 := excelize.CellNameToCoordinates(strings.ReplaceAll(last, "$", ""))
+	if err1 != nil || err2 != nil {
+		return cellArea{}, false
+	}
+	return cellArea{min(c1, c2), min(r1, r2), max(c1, c2), max(r1, r2)}, true
+}
+
+func (a cellArea) overlaps(b cellArea) bool {
+	return a.col1 <= b.col2 && b.col1 <= a.col2 && a.row1 <= b.row2 && b.row1 <= a.row2
+}
+
+func (a cellArea) String() string {
+	return safeCoordinatesToCellName(a.col1, a.row1) + ":" + safeCoordinatesToCellName(a.col2, a.row2)
+}
+
+// sheetTable is a table accepted for writing, with its parsed range.
+type sheetTable struct {
+	osheet.Table
+	area cellArea
+}
+
+// sheetTables holds what planTables accepted for one sheet.
+type sheetTables struct {
+	tables     []sheetTable
+	autoFilter string
+	// detect is set when a table should be synthesized from the sheet's
+	// header row, see headerDetector.
+	detect bool
+}
+
+// planTables checks the sheet's tables and autofilter before anything is
+// written. Tables with an invalid range or overlapping another table or a
+// merged cell are reported through the translator's warn and skipped, as
+// are autofilters overlapping a table. The stream writer supports a single
+// table per sheet, always with a header row; a table without a header row
+// cannot start on row 1 either.
+func planTables(formulas *formulaTranslator, name string, s *osheet.Sheet, streamed, asTable bool) sheetTables {
+	var plan sheetTables
+	merges := mergeAreas(s.Merges)
+	for _, t := range s.Tables {
+		area, ok := parseArea(t.Range)
+		problem := ""
+		switch {
+		case !ok:
+			problem = "table skipped: invalid range"
+		case !t.HeaderRow && streamed:
+			problem = "table skipped: tables without a header row cannot be streamed"
+		case !t.HeaderRow && area.row1 == 1:
+			problem = "table skipped: a table without a header row cannot start on row 1"
+		case streamed && len(plan.tables) > 0:
+			problem = "table skipped: streamed sheets hold a single table"
+		case overlapsAny(area, merges):
+			problem = "table skipped: overlaps merged cells"
+		}
+		for _, other := range plan.tables {
+			if problem == "" && area.overlaps(other.area) {
+				problem = "table skipped: overlaps table " + other.area.String()
+			}
+		}
+		if problem != "" {
+			formulas.report(name, t.Range, problem)
+			continue
+		}
+		plan.tables = append(plan.tables, sheetTable{Table: t, area: area})
+	}
+	if s.AutoFilter != "" {
+		area, ok := parseArea(s.AutoFilter)
+		switch {
+		case !ok:
+			formulas.report(name, s.AutoFilter, "autofilter skipped: invalid range")
+		case overlapsAny(area, tableAreas(plan.tables)):
+			formulas.report(name, s.AutoFilter, "autofilter skipped: overlaps a table")
+		default:
+			plan.autoFilter = area.String()
+		}
+	}
+	plan.detect = asTable && len(s.Tables) == 0 && s.AutoFilter == ""
+	return plan
+}
+
+func mergeAreas(merges []osheet.Merge) []cellArea {
+	var out []cellArea
+	for _, m := range validMerges(merges) {
+		out = append(out, cellArea{m.StartCol, m.StartRow, m.EndCol, m.EndRow})
+	}
+	return out
+}
+
+func tableAreas(tables []sheetTable) []cellArea {
+	out := make([]cellArea, len(tables))
+	for i := range tables {
+		out[i] = tables[i].area
+	}
+	return out
+}
+
+func overlapsAny(a cellArea, areas []cellArea) bool {
+	for _, b := range areas {
+		if a.overlaps(b) {
+			return true
+		}
+	}
+	return false
+}
+
+// addAutoFilter puts filter buttons on the first row of the plan's
+// autofilter range. excelize replaces the sheet properties while doing so,
+// so it runs before setSheetView, and for streamed sheets before the stream
+// writer is created.
+func addAutoFilter(f *excelize.File, name string, plan sheetTables, warn func(string)) {
+	if plan.autoFilter == "" {
+		return
+	}
+	if err := f.AutoFilter(name, plan.autoFilter, nil); err != nil {
+		if warn != nil {
+			warn(fmt.Sprintf("%s!%s: cannot add autofilter: %v", name, plan.autoFilter, err))
+		}
+	}
+}
+
+// excelizeTable converts a planned table into its excelize form. excelize
+// keeps a row above a table without a header row, so the range starts one
+// row higher.
+func excelizeTable(t *sheetTable) *excelize.Table {
+	area := t.area
+	out := &excelize.Table{Name: t.Name, StyleName: t.Style}
+	if !t.HeaderRow {
+		area.row1--
+		hidden := false
+		out.ShowHeaderRow = &hidden
+	}
+	out.Range = area.String()
+	return out
+}
+
+// addTables writes the plan's tables into an in-memory sheet, after its
+// cells. Header cells are rewritten as the column names (see tableHeader).
+// Tables excelize rejects, such as those with a duplicate or invalid name,
+// are reported through the translator's warn.
+func addTables(f *excelize.File, formulas *formulaTranslator, name string, s *osheet.Sheet, plan sheetTables) {
+	for i := range plan.tables {
+		t := &plan.tables[i]
+		if t.HeaderRow {
+			var row []osheet.Cell
+			if t.area.row1 <= len(s.Cells) {
+				row = s.Cells[t.area.row1-1]
+			}
+			for j, header := range tableHeader(row, t.area) {
+				col := t.area.col1 + j
+				if col <= len(row) && isHeaderText(row[col-1], header) {
+					continue
+				}
+				safeSetCellStr(f, name, safeCoordinatesToCellName(col, t.area.row1), header)
+			}
+		}
+		if err := f.AddTable(name, excelizeTable(t)); err != nil {
+			formulas.report(name, t.Range, fmt.Sprintf("table skipped: %v", err))
+		}
+	}
+}
+
+// tableHeader returns the column names of a table whose header is row
+// (0-based cells). Excel wants unique, non-empty text, so like excelize it
+// names other columns ColumnN after their position. Numbers and booleans
+// keep their text.
+func tableHeader(row []osheet.Cell, area cellArea) []string {
+	out := make([]string, 0, area.col2-area.col1+1)
+	seen := make(map[string]bool)
+	for col := area.col1; col <= area.col2; col++ {
+		text := ""
+		if col <= len(row) {
+			text = headerText(row[col-1])
+		}
+		if text == "" || seen[strings.ToLower(text)] {
+			text = "Column" + strconv.Itoa(col-area.col1+1)
+		}
+		seen[strings.ToLower(text)] = true
+		out = append(out, text)
+	}
+	return out
+}
+
+// headerText is the text a header cell shows, or "" for empty and formula
+// cells.
+func headerText(cell osheet.Cell) string {
+	if cell.Formula != "" {
+		return ""
+	}
+	switch cell.Type {
+	case osheet.ValueString:
+		return cell.StringValue
+	case osheet.ValueNumber:
+		return strconv.FormatFloat(cell.NumberValue, 'f', -1, 64)
+	case osheet.ValueBool:
+		return strings.ToUpper(strconv.FormatBool(cell.BoolValue))
+	default:
+		return ""
+	}
+}
+
+// isHeaderText reports whether the cell is written as the header text already.
+func isHeaderText(cell osheet.Cell, header string) bool {
+	return cell.Formula == "" && cell.Type == osheet.ValueString && cell.StringValue == header
+}
+
+// headerDetector finds the table of a sheet fed row by row for
+// Options.AsTable. The header is the first non-empty row, whose cells from
+// the first to the last non-empty one must all be distinct text; the table
+// spans its columns down to the last non-empty row below it.
+type headerDetector struct {
+	header  cellArea // zero until found
+	names   map[string]bool
+	lastRow int
+	failed  bool
+}
+
+// row feeds the r-th (1-based) row.
+func (d *headerDetector) row(r int, cells []osheet.Cell) {
+	if d.failed {
+		return
+	}
+	first, last := 0, 0
+	for c := range cells {
+		if !isEmptyCell(cells[c]) {
+			if first == 0 {
+				first = c + 1
+			}
+			last = c + 1
+		}
+	}
+	if first == 0 {
+		return
+	}
+	if d.header.row1 > 0 {
+		d.lastRow = r
+		return
+	}
+	d.names = make(map[string]bool)
+	for c := first; c <= last; c++ {
+		cell := cells[c-1]
+		key := strings.ToLower(strings.TrimSpace(cell.StringValue))
+		if cell.Type != osheet.ValueString || cell.Formula != "" || key == "" || d.names[key] {
+			d.failed = true
+			return
+		}
+		d.names[key] = true
+	}
+	d.header = cellArea{first, r, last, r}
+}
+
+// table returns the detected table, if the header has data rows below it
+// and no merged cell overlaps it.
+func (d *headerDetector) table(merges []osheet.Merge) (sheetTable, bool) {
+	if d.failed || d.header.row1 == 0 || d.lastRow == 0 {
+		return sheetTable{}, false
+	}
+	area := d.header
+	area.row2 = d.lastRow
+	if overlapsAny(area, mergeAreas(merges)) {
+		return sheetTable{}, false
+	}
+	t := osheet.Table{Range: area.String(), HeaderRow: true, Style: detectedTableStyle}
+	return sheetTable{Table: t, area: area}, true
+}
+
+// detectTable runs a headerDetector over in-memory cells.
+func detectTable(s *osheet.Sheet) (sheetTable, bool) {
+	var d headerDetector
+	for r := range s.Cells {
+		d.row(r+1, s.Cells[r])
+	}
+	return d.table(s.Merges)
+}
+
+func isEmptyCell(cell osheet.Cell) bool {
+	if cell.Formula != "" {
+		return false
+	}
+	switch cell.Type {
+	case osheet.ValueNumber, osheet.ValueBool, osheet.ValueDateTime:
+		return false
+	default:
+		return cell.StringValue == ""
+	}
+}
@@ -0,0 +1,88 @@
+package xlsx
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/xuri/excelize/v2"
+
+	"github.com/romanitalian/osheet2xlsx/v3/internal/osheet"
+)
+
+// addValidations writes the sheet's data validation rules. Like annotations
+// they live outside the sheet data, so streamed sheets can add them until the
+// stream is flushed. Rules Excel cannot express are reported through the
+// translator's warn and skipped.
+func addValidations(f *excelize.File, formulas *formulaTranslator, name string, rules []osheet.DataValidation) {
+	for i := range rules {
+		v := &rules[i]
+		dv, problem := dataValidation(formulas, name, v)
+		if problem != "" {
+			formulas.report(name, v.Range, problem)
+			continue
+		}
+		if err := f.AddDataValidation(name, dv); err != nil {
+			formulas.report(name, v.Range, fmt.Sprintf("cannot add data validation: %v", err))
+		}
+	}
+}
+
+// dataValidation converts a rule into its excelize form, or explains why it
+// cannot be written.
+func dataValidation(formulas *formulaTranslator, sheet string, v *osheet.DataValidation) (*excelize.DataValidation, string) {
+	dv := excelize.NewDataValidation(v.AllowBlank)
+	dv.Sqref = v.Range
+	switch v.Kind {
+	case osheet.ValidationList:
+		if len(v.Values) > 0 {
+			if err := dv.SetDropList(v.Values); err != nil {
+				return nil, fmt.Sprintf("dropdown list: %v", err)
+			}
+			break
+		}
+		if v.Formula1 == "" {
+			return nil, "dropdown list has no values or source range"
+		}
+		dv.SetSqrefDropList(validationFormula(formulas.translate(sheet, v.Range, v.Formula1)))
+	case osheet.ValidationWhole, osheet.ValidationDecimal, osheet.ValidationDate, osheet.ValidationTime, osheet.ValidationTextLength:
+		operator := v.Operator
+		if operator == "" {
+			operator = "between"
+		}
+		if v.Formula1 == "" || (v.Formula2 == "" && (operator == "between" || operator == "notBetween")) {
+			return nil, fmt.Sprintf("%s validation is missing a bound", v.Kind)
+		}
+		dv.Type, dv.Operator = v.Kind, operator
+		dv.Formula1 = validationFormula(formulas.translate(sheet, v.Range, v.Formula1))
+		if operator == "between" || operator == "notBetween" {
+			dv.Formula2 = validationFormula(formulas.translate(sheet, v.Range, v.Formula2))
+		}
+	case osheet.ValidationCustom:
+		if v.Formula1 == "" {
+			return nil, "custom validation has no formula"
+		}
+		dv.Type = osheet.ValidationCustom
+		dv.Formula1 = validationFormula(formulas.translate(sheet, v.Range, v.Formula1))
+	default:
+		return nil, fmt.Sprintf("unsupported validation type %q", v.Kind)
+	}
+	if v.InputTitle != "" || v.InputMessage != "" {
+		dv.SetInput(v.InputTitle, v.InputMessage)
+	}
+	// Excel only rejects invalid entries when the error message is shown
+	style := excelize.DataValidationErrorStyleStop
+	switch v.ErrorStyle {
+	case "warning":
+		style = excelize.DataValidationErrorStyleWarning
+	case "information":
+		style = excelize.DataValidationErrorStyleInformation
+	}
+	dv.SetError(style, v.ErrorTitle, v.ErrorMessage)
+	return dv, ""
+}
+
+// validationFormula escapes a formula for excelize, which writes validation
+// formulas into the sheet XML verbatim.
+func validationFormula(formula string) string {
+	return strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;").Replace(formula)
+}
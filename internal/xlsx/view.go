@@ -0,0 +1,96 @@
+package xlsx
+
+import (
+	"fmt"
+
+	"github.com/xuri/excelize/v2"
+
+	"github.com/romanitalian/osheet2xlsx/v3/internal/osheet"
+)
+
+// setSheetView applies frozen panes, tab colour and zoom. The stream writer
+// copies these worksheet parts when it starts, so streamed sheets must call
+// this before NewStreamWriter.
+func setSheetView(f *excelize.File, name string, v osheet.SheetView, warn func(string)) {
+	if v.FrozenRows > 0 || v.FrozenCols > 0 {
+		if err := f.SetPanes(name, frozenPanes(v.FrozenRows, v.FrozenCols)); err != nil {
+			if warn != nil {
+				warn(fmt.Sprintf("%s: cannot freeze panes: %v", name, err))
+			}
+		}
+	}
+	if v.TabColor != "" {
+		color := "FF" + v.TabColor
+		if err := f.SetSheetProps(name, &excelize.SheetPropsOptions{TabColorRGB: &color}); err != nil {
+			if warn != nil {
+				warn(fmt.Sprintf("%s: cannot set tab color: %v", name, err))
+			}
+		}
+	}
+	if v.Zoom > 0 {
+		if err := f.SetSheetView(name, 0, &excelize.ViewOptions{ZoomScale: &v.Zoom}); err != nil {
+			if warn != nil {
+				warn(fmt.Sprintf("%s: cannot set zoom: %v", name, err))
+			}
+		}
+	}
+}
+
+// frozenPanes locks the given number of top rows and left columns, with the
+// scrollable pane active.
+func frozenPanes(rows, cols int) *excelize.Panes {
+	pane := "bottomRight"
+	switch {
+	case cols == 0:
+		pane = "bottomLeft"
+	case rows == 0:
+		pane = "topRight"
+	}
+	topLeft := safeCoordinatesToCellName(cols+1, rows+1)
+	return &excelize.Panes{
+		Freeze:      true,
+		XSplit:      cols,
+		YSplit:      rows,
+		TopLeftCell: topLeft,
+		ActivePane:  pane,
+		Selection:   []excelize.Selection{{SQRef: topLeft, ActiveCell: topLeft, Pane: pane}},
+	}
+}
+
+// activateSheet selects the first visible sheet and returns its index. It must
+// run before any sheet is written, since the stream writer stores each
+// sheet's selected state. A workbook needs a visible sheet, so when every
+// sheet is hidden the first one is selected.
+func activateSheet(f *excelize.File, sheets []osheet.Sheet) int {
+	for i := range sheets {
+		if sheets[i].View.Visibility == "" {
+			if i > 0 {
+				f.SetActiveSheet(i)
+			}
+			return i
+		}
+	}
+	return 0
+}
+
+// hideSheets applies sheet visibility once every sheet exists. The active
+// sheet always stays visible.
+func hideSheets(f *excelize.File, names []string, sheets []osheet.Sheet, active int, warn func(string)) {
+	for i := range sheets {
+		visibility := sheets[i].View.Visibility
+		if visibility == "" {
+			continue
+		}
+		if i == active {
+			if warn != nil {
+				warn(fmt.Sprintf("%s: every sheet is hidden; keeping this one visible", names[i]))
+			}
+			continue
+		}
+		if err := f.SetSheetVisible(names[i], false, visibility == osheet.SheetVeryHidden); err != nil {
+			if warn != nil {
+				warn(fmt.Sprintf("%s: cannot hide sheet: %v", names[i], err))
+			}
+		}
+	}
+}
@@ -3,6 +3,8 @@ package xlsx
 import (
 	"fmt"
 	"regexp"
+	"strings"
+	"unicode/utf8"
 
 	"github.com/xuri/excelize/v2"
 
@@ -55,6 +57,13 @@ func safeSetCellStr(f *excelize.File, sheet, cell, value string) {
 	}
 }
 
+func safeSetCellRichText(f *excelize.File, sheet, cell string, runs []excelize.RichTextRun) {
+	if err := f.SetCellRichText(sheet, cell, runs); err != nil {
+		// Log error but continue - this is not critical
+		fmt.Printf("Warning: failed to set rich text in %s!%s: %v\n", sheet, cell, err)
+	}
+}
+
 func safeSetCellFloat(f *excelize.File, sheet, cell string, value float64, precision int, bitSize int) {
 	if err := f.SetCellFloat(sheet, cell, value, precision, bitSize); err != nil {
 		// Log error but continue - this is not critical
@@ -106,6 +115,20 @@ func safeSetRowHeight(f *excelize.File, sheet string, row int, height float64) {
 	}
 }
 
+func safeSetColVisible(f *excelize.File, sheet, col string, visible bool) {
+	if err := f.SetColVisible(sheet, col, visible); err != nil {
+		// Log error but continue - this is not critical
+		fmt.Printf("Warning: failed to set column visibility in %s!%s: %v\n", sheet, col, err)
+	}
+}
+
+func safeSetRowVisible(f *excelize.File, sheet string, row int, visible bool) {
+	if err := f.SetRowVisible(sheet, row, visible); err != nil {
+		// Log error but continue - this is not critical
+		fmt.Printf("Warning: failed to set row visibility in %s!%d: %v\n", sheet, row, err)
+	}
+}
+
 // WriteEmptyBook creates a minimal xlsx file at the given path.
 func WriteEmptyBook(path string) error {
 	f := excelize.NewFile()
@@ -113,8 +136,54 @@ func WriteEmptyBook(path string) error {
 	return f.SaveAs(path)
 }
 
-// WriteBook writes a parsed Osheet book into an XLSX file.
+// DefaultStreamThreshold is the number of cells above which a sheet is
+// written through excelize's StreamWriter instead of the in-memory model.
+const DefaultStreamThreshold = 100_000
+
+// Options controls how a book is written.
+type Options struct {
+	// StreamThreshold selects the streaming writer for sheets with more cells.
+	// Zero means DefaultStreamThreshold; a negative value disables streaming.
+	StreamThreshold int
+	// Warn receives conversion warnings such as formulas using functions
+	// Excel does not know or that cannot be evaluated, and sheet features
+	// excelize fails to write; nil discards them.
+	Warn func(string)
+	// Formulas is FormulasKeep (default), FormulasCache or FormulasValues.
+	// Evaluating modes compute results in memory and write every sheet with
+	// the streaming writer, the only one that stores typed cached values.
+	Formulas string
+	// AsTable turns the data of sheets without tables or an autofilter into
+	// an Excel table when their first non-empty row reads as a header.
+	AsTable bool
+	// StripProtection drops sheet and workbook protection so the output is
+	// editable. Cell lock flags are kept; they only matter once a sheet is
+	// protected again.
+	StripProtection bool
+}
+
+// streams reports whether the sheet should be written with the streaming writer.
+func (o Options) streams(s *osheet.Sheet) bool {
+	threshold := o.StreamThreshold
+	if threshold == 0 {
+		threshold = DefaultStreamThreshold
+	}
+	if threshold < 0 {
+		return false
+	}
+	return cellCount(s) > threshold
+}
+
+// WriteBook writes a parsed Osheet book into an XLSX file with default options.
 func WriteBook(book *osheet.Book, outPath string) error {
+	return WriteBookWithOptions(book, outPath, Options{})
+}
+
+// WriteBookWithOptions writes a parsed Osheet book into an XLSX file.
+func WriteBookWithOptions(book *osheet.Book, outPath string, opts Options) error {
+	if err := opts.Validate(); err != nil {
+		return err
+	}
 	f := excelize.NewFile()
 	defer func() { _ = f.Close() }()
 
@@ -124,84 +193,208 @@ func WriteBook(book *osheet.Book, outPath string) error {
 		defaultSheet = "Sheet1"
 	}
 
+	styles := newStyleCache(f)
+	formulas := newFormulaTranslator(book.Sheets, opts.Warn)
+	if opts.evaluates() {
+		formulas.evaluate(book)
+		formulas.valuesOnly = opts.Formulas == FormulasValues
+	}
+
 	// Create sheets in order
-	for i, s := range book.Sheets {
-		name := sanitizeSheetName(s.Name)
-		if name == "" {
-			name = fmt.Sprintf("Sheet%d", i+1)
+	names := addSheets(f, defaultSheet, book.Sheets)
+	active := activateSheet(f, book.Sheets)
+	for i := range book.Sheets {
+		s := &book.Sheets[i]
+		if !opts.StripProtection {
+			protectSheet(f, names[i], s.Protection, opts.Warn)
 		}
+		if opts.streams(s) || opts.evaluates() {
+			if err := streamSheet(f, styles, formulas, names[i], s, opts.AsTable); err != nil {
+				return fmt.Errorf("stream sheet %s: %w", names[i], err)
+			}
+			continue
+		}
+		writeSheet(f, styles, formulas, names[i], s, opts.AsTable)
+	}
+	addDefinedNames(f, formulas, book.DefinedNames)
+	hideSheets(f, names, book.Sheets, active, opts.Warn)
+	if !opts.StripProtection {
+		protectWorkbook(f, book.Protection, opts.Warn)
+	}
+	setDocProps(f, book, opts.Warn)
+
+	return saveWithProps(f, book, outPath)
+}
+
+// addSheets creates every sheet up front (renaming the default one for the
+// first) and returns their final names.
+func addSheets(f *excelize.File, defaultSheet string, sheets []osheet.Sheet) []string {
+	names := sheetNames(sheets)
+	for i, name := range names {
 		if i == 0 {
 			// rename default sheet
 			safeSetSheetName(f, defaultSheet, name)
 		} else {
 			safeNewSheet(f, name)
 		}
-		// Write cells
-		for r := 0; r < len(s.Cells); r++ {
-			row := s.Cells[r]
-			for c := 0; c < len(row); c++ {
-				cell := row[c]
-				axis := safeCoordinatesToCellName(c+1, r+1)
-				// If formula present, prefer writing formula
-				if cell.Formula != "" {
-					safeSetCellFormula(f, name, axis, cell.Formula)
-					continue
-				}
-				switch cell.Type {
-				case osheet.ValueString:
-					if len(cell.StringValue) > 0 && cell.StringValue[0] == '=' {
-						safeSetCellFormula(f, name, axis, cell.StringValue)
-					} else {
-						safeSetCellStr(f, name, axis, cell.StringValue)
-					}
-				case osheet.ValueNumber:
-					safeSetCellFloat(f, name, axis, cell.NumberValue, -1, 64)
-				case osheet.ValueBool:
-					if cell.BoolValue {
-						safeSetCellBool(f, name, axis, true)
-					} else {
-						safeSetCellBool(f, name, axis, false)
-					}
-				case osheet.ValueDateTime:
-					safeSetCellFloat(f, name, axis, cell.DateEpoch, -1, 64)
-					// Apply a basic date-time style
-					styleID := safeNewStyle(f, &excelize.Style{NumFmt: 22})
-					safeSetCellStyle(f, name, axis, axis, styleID)
-				default:
+	}
+	return names
+}
+
+// sheetNames returns the output name of every sheet: sanitised, SheetN when
+// nothing is left, and made unique with a _2, _3 suffix since Excel compares
+// sheet names case-insensitively.
+func sheetNames(sheets []osheet.Sheet) []string {
+	names := make([]string, len(sheets))
+	used := make(map[string]bool, len(sheets))
+	for i := range sheets {
+		base := sanitizeSheetName(sheets[i].Name)
+		if base == "" {
+			base = fmt.Sprintf("Sheet%d", i+1)
+		}
+		name := base
+		for n := 2; used[strings.ToLower(name)]; n++ {
+			suffix := fmt.Sprintf("_%d", n)
+			name = truncateRunes(base, maxSheetNameLen-len(suffix)) + suffix
+		}
+		used[strings.ToLower(name)] = true
+		names[i] = name
+	}
+	return names
+}
+
+// writeSheet writes a sheet cell by cell through the in-memory workbook model.
+// With asTable a table is synthesized for a detected header row.
+func writeSheet(f *excelize.File, styles *styleCache, formulas *formulaTranslator, name string, s *osheet.Sheet, asTable bool) {
+	tables := planTables(formulas, name, s, false, asTable)
+	if tables.detect {
+		if t, ok := detectTable(s); ok {
+			tables.tables = append(tables.tables, t)
+		}
+	}
+	setSheetDefaults(f, name, s)
+	addAutoFilter(f, name, tables, formulas.warn)
+	setSheetView(f, name, s.View, formulas.warn)
+	// Write cells
+	for r := 0; r < len(s.Cells); r++ {
+		row := s.Cells[r]
+		for c := 0; c < len(row); c++ {
+			cell := row[c]
+			axis := safeCoordinatesToCellName(c+1, r+1)
+			if styleID := styles.id(cell); styleID != 0 {
+				safeSetCellStyle(f, name, axis, axis, styleID)
+			}
+			annotateCell(f, formulas, name, axis, cell)
+			// If formula present, prefer writing formula
+			if formula := formulas.formula(name, axis, cell); formula != "" {
+				safeSetCellFormula(f, name, axis, formula)
+				continue
+			}
+			switch cell.Type {
+			case osheet.ValueString:
+				if runs := richText(cell); runs != nil {
+					safeSetCellRichText(f, name, axis, runs)
+				} else {
 					safeSetCellStr(f, name, axis, cell.StringValue)
 				}
+			case osheet.ValueNumber:
+				safeSetCellFloat(f, name, axis, cell.NumberValue, -1, 64)
+			case osheet.ValueBool:
+				if cell.BoolValue {
+					safeSetCellBool(f, name, axis, true)
+				} else {
+					safeSetCellBool(f, name, axis, false)
+				}
+			case osheet.ValueDateTime:
+				// Date-time style is applied through the style cache
+				safeSetCellFloat(f, name, axis, cell.DateEpoch, -1, 64)
+			default:
+				safeSetCellStr(f, name, axis, cell.StringValue)
 			}
 		}
-		// Apply merges
-		for _, m := range s.Merges {
-			if m.StartRow <= 0 || m.StartCol <= 0 || m.EndRow < m.StartRow || m.EndCol < m.StartCol {
-				continue
-			}
-			ax1 := safeCoordinatesToCellName(m.StartCol, m.StartRow)
-			ax2 := safeCoordinatesToCellName(m.EndCol, m.EndRow)
-			safeMergeCell(f, name, ax1, ax2)
+	}
+	// Apply merges
+	for _, m := range validMerges(s.Merges) {
+		ax1 := safeCoordinatesToCellName(m.StartCol, m.StartRow)
+		ax2 := safeCoordinatesToCellName(m.EndCol, m.EndRow)
+		safeMergeCell(f, name, ax1, ax2)
+	}
+	// Apply column widths and visibility
+	for _, c := range s.Cols {
+		if c.Index <= 0 {
+			continue
 		}
-		// Apply column widths
-		for _, c := range s.Cols {
-			if c.Index <= 0 || c.Width <= 0 {
-				continue
-			}
+		if c.Width > 0 {
 			safeSetColWidth(f, name, columnName(c.Index), columnName(c.Index), c.Width)
 		}
-		// Apply row heights
-		for _, rh := range s.Rows {
-			if rh.Index <= 0 || rh.Height <= 0 {
-				continue
-			}
+		if c.Hidden {
+			safeSetColVisible(f, name, columnName(c.Index), false)
+		}
+	}
+	// Apply row heights and visibility
+	for _, rh := range s.Rows {
+		if rh.Index <= 0 {
+			continue
+		}
+		if rh.Height > 0 {
 			safeSetRowHeight(f, name, rh.Index, rh.Height)
 		}
+		if rh.Hidden {
+			safeSetRowVisible(f, name, rh.Index, false)
+		}
+	}
+	// Apply data validations, conditional formats and images
+	addValidations(f, formulas, name, s.Validations)
+	addConditionalFormats(f, styles, formulas, name, s.ConditionalFormats)
+	addImages(f, formulas, name, s.Images)
+	addTables(f, formulas, name, s, tables)
+}
+
+// setSheetDefaults applies the sheet's default column width and row height.
+func setSheetDefaults(f *excelize.File, name string, s *osheet.Sheet) {
+	var props excelize.SheetPropsOptions
+	if s.DefaultColWidth > 0 {
+		props.DefaultColWidth = &s.DefaultColWidth
+	}
+	if s.DefaultRowHeight > 0 {
+		custom := true
+		props.DefaultRowHeight, props.CustomHeight = &s.DefaultRowHeight, &custom
+	}
+	if props == (excelize.SheetPropsOptions{}) {
+		return
+	}
+	if err := f.SetSheetProps(name, &props); err != nil {
+		// Log error but continue - this is not critical
+		fmt.Printf("Warning: failed to set default sizes in %s: %v\n", name, err)
+	}
+}
+
+// validMerges drops merge ranges that are empty or not 1-based.
+func validMerges(merges []osheet.Merge) []osheet.Merge {
+	out := make([]osheet.Merge, 0, len(merges))
+	for _, m := range merges {
+		if m.StartRow <= 0 || m.StartCol <= 0 || m.EndRow < m.StartRow || m.EndCol < m.StartCol {
+			continue
+		}
+		out = append(out, m)
 	}
+	return out
+}
 
-	return f.SaveAs(outPath)
+// cellCount returns the number of cells materialised in the sheet.
+func cellCount(s *osheet.Sheet) int {
+	n := 0
+	for r := 0; r < len(s.Cells); r++ {
+		n += len(s.Cells[r])
+	}
+	return n
 }
 
 var invalidSheetChars = regexp.MustCompile(`[\[\]\*\?/\\:]`)
 
+// maxSheetNameLen is Excel's sheet name limit in characters.
+const maxSheetNameLen = 31
+
 func sanitizeSheetName(in string) string {
 	if in == "" {
 		return in
@@ -209,10 +402,15 @@ func sanitizeSheetName(in string) string {
 	// Replace invalid characters
 	cleaned := invalidSheetChars.ReplaceAllString(in, "_")
 	// Trim to Excel's 31-char sheet name limit
-	if len(cleaned) > 31 {
-		cleaned = cleaned[:31]
+	return truncateRunes(cleaned, maxSheetNameLen)
+}
+
+// truncateRunes cuts s to at most n characters.
+func truncateRunes(s string, n int) string {
+	if utf8.RuneCountInString(s) <= n {
+		return s
 	}
-	return cleaned
+	return string([]rune(s)[:n])
 }
 
 // columnName converts 1-based column index to Excel column label.
@@ -2,13 +2,22 @@ package xlsx
 
 import (
 	"archive/zip"
+	"bytes"
 	"encoding/json"
+	"image"
+	"image/png"
+	"io"
 	"os"
 	"path/filepath"
+	"reflect"
+	"regexp"
 	"runtime"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/xuri/excelize/v2"
+
 	osmodel "github.com/romanitalian/osheet2xlsx/v3/internal/osheet"
 )
 
@@ -56,3 +65,966 @@ func TestJSONLogsAreDirect_NotNested(t *testing.T) {
 		t.Fatalf("marshal: %v", err)
 	}
 }
+
+func TestWriteBook_StylesDeduplicated(t *testing.T) {
+	bold := &osmodel.Style{
+		Font:      osmodel.Font{Bold: true, Color: "FF0000"},
+		Fill:      "FFFF00",
+		Border:    osmodel.Border{Bottom: osmodel.BorderSide{Style: "thick"}},
+		Alignment: osmodel.Alignment{Horizontal: "center", Wrap: true},
+	}
+	same := *bold
+	book := &osmodel.Book{Title: "t", Sheets: []osmodel.Sheet{{
+		Name: "S",
+		Cells: [][]osmodel.Cell{{
+			{Type: osmodel.ValueString, StringValue: "a", Style: bold},
+			{Type: osmodel.ValueString, StringValue: "b", Style: &same},
+			{Type: osmodel.ValueString, StringValue: "c"},
+		}},
+	}}}
+	out := filepath.Join(t.TempDir(), "styled.xlsx")
+	if err := WriteBook(book, out); err != nil {
+		t.Fatalf("WriteBook: %v", err)
+	}
+	f, err := excelize.OpenFile(out)
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	defer func() { _ = f.Close() }()
+
+	idA, err := f.GetCellStyle("S", "A1")
+	if err != nil {
+		t.Fatalf("GetCellStyle: %v", err)
+	}
+	idB, _ := f.GetCellStyle("S", "B1")
+	idC, _ := f.GetCellStyle("S", "C1")
+	if idA == 0 || idA != idB {
+		t.Fatalf("equal styles should share an ID: A1=%d B1=%d", idA, idB)
+	}
+	if idC != 0 {
+		t.Fatalf("unstyled cell got style %d", idC)
+	}
+	st, err := f.GetStyle(idA)
+	if err != nil {
+		t.Fatalf("GetStyle: %v", err)
+	}
+	if st.Font == nil || !st.Font.Bold || st.Font.Color != "FF0000" {
+		t.Fatalf("font not written: %+v", st.Font)
+	}
+	if len(st.Fill.Color) != 1 || st.Fill.Color[0] != "FFFF00" {
+		t.Fatalf("fill not written: %+v", st.Fill)
+	}
+	if st.Alignment == nil || st.Alignment.Horizontal != "center" || !st.Alignment.WrapText {
+		t.Fatalf("alignment not written: %+v", st.Alignment)
+	}
+	if len(st.Border) != 1 || st.Border[0].Type != "bottom" || st.Border[0].Style != 5 {
+		t.Fatalf("border not written: %+v", st.Border)
+	}
+}
+
+func TestWriteBook_NumberFormats(t *testing.T) {
+	book := &osmodel.Book{Title: "t", Sheets: []osmodel.Sheet{{
+		Name: "S",
+		Cells: [][]osmodel.Cell{{
+			{Type: osmodel.ValueNumber, NumberValue: 0.12, NumFmt: "0%"},
+			{Type: osmodel.ValueNumber, NumberValue: 1200.5, NumFmt: `"$"#,##0.00`},
+			{Type: osmodel.ValueNumber, NumberValue: 7},
+		}},
+	}}}
+	out := filepath.Join(t.TempDir(), "numfmt.xlsx")
+	if err := WriteBook(book, out); err != nil {
+		t.Fatalf("WriteBook: %v", err)
+	}
+	f, err := excelize.OpenFile(out)
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	defer func() { _ = f.Close() }()
+
+	for axis, want := range map[string]string{"A1": "12%", "B1": "$1,200.50", "C1": "7"} {
+		got, err := f.GetCellValue("S", axis)
+		if err != nil {
+			t.Fatalf("GetCellValue(%s): %v", axis, err)
+		}
+		if got != want {
+			t.Errorf("%s formatted = %q, want %q", axis, got, want)
+		}
+	}
+	raw, err := f.GetCellValue("S", "A1", excelize.Options{RawCellValue: true})
+	if err != nil || raw != "0.12" {
+		t.Errorf("A1 raw = %q (%v), want 0.12", raw, err)
+	}
+}
+
+func TestWriteBookWithOptions_StreamingMatchesInMemory(t *testing.T) {
+	bold := &osmodel.Style{Font: osmodel.Font{Bold: true}}
+	book := &osmodel.Book{Title: "t", Sheets: []osmodel.Sheet{{
+		Name: "Big",
+		Cells: [][]osmodel.Cell{{
+			{Type: osmodel.ValueString, StringValue: "name", Style: bold},
+			{Type: osmodel.ValueNumber, NumberValue: 0.5, NumFmt: "0%"},
+			{Type: osmodel.ValueBool, BoolValue: true},
+		}, {
+			{Type: osmodel.ValueDateTime, DateEpoch: 45569},
+			{Formula: "SUM(B1:B1)"},
+			{Type: osmodel.ValueEmpty},
+		}},
+		Merges: []osmodel.Merge{{StartRow: 3, StartCol: 1, EndRow: 3, EndCol: 2}},
+		Cols:   []osmodel.ColSpec{{Index: 2, Width: 30}},
+		Rows:   []osmodel.RowSpec{{Index: 2, Height: 25}, {Index: 4, Height: 40}},
+	}, {
+		Name:  "Small",
+		Cells: [][]osmodel.Cell{{{Type: osmodel.ValueString, StringValue: "x"}}},
+	}}}
+	dir := t.TempDir()
+	streamed := filepath.Join(dir, "streamed.xlsx")
+	inMemory := filepath.Join(dir, "memory.xlsx")
+	if err := WriteBookWithOptions(book, streamed, Options{StreamThreshold: 3}); err != nil {
+		t.Fatalf("streamed: %v", err)
+	}
+	if err := WriteBookWithOptions(book, inMemory, Options{StreamThreshold: -1}); err != nil {
+		t.Fatalf("in-memory: %v", err)
+	}
+	fs, err := excelize.OpenFile(streamed)
+	if err != nil {
+		t.Fatalf("open streamed: %v", err)
+	}
+	defer func() { _ = fs.Close() }()
+	fm, err := excelize.OpenFile(inMemory)
+	if err != nil {
+		t.Fatalf("open in-memory: %v", err)
+	}
+	defer func() { _ = fm.Close() }()
+
+	for _, axis := range []string{"A1", "B1", "C1", "A2", "B2"} {
+		want, _ := fm.GetCellValue("Big", axis)
+		got, _ := fs.GetCellValue("Big", axis)
+		if got != want {
+			t.Errorf("%s = %q, want %q", axis, got, want)
+		}
+	}
+	if formula, _ := fs.GetCellFormula("Big", "B2"); formula != "SUM(B1:B1)" {
+		t.Errorf("B2 formula = %q", formula)
+	}
+	if id, _ := fs.GetCellStyle("Big", "A1"); id == 0 {
+		t.Errorf("A1 style lost in streaming")
+	}
+	if w, _ := fs.GetColWidth("Big", "B"); w != 30 {
+		t.Errorf("col B width = %v, want 30", w)
+	}
+	if h, _ := fs.GetRowHeight("Big", 4); h != 40 {
+		t.Errorf("row 4 height = %v, want 40", h)
+	}
+	merges, err := fs.GetMergeCells("Big")
+	if err != nil || len(merges) != 1 || merges[0].GetStartAxis() != "A3" || merges[0].GetEndAxis() != "B3" {
+		t.Errorf("merges = %v (%v)", merges, err)
+	}
+	if got, _ := fs.GetCellValue("Small", "A1"); got != "x" {
+		t.Errorf("Small!A1 = %q", got)
+	}
+}
+
+func TestWriteBookWithOptions_HiddenAndDefaultSizes(t *testing.T) {
+	book := &osmodel.Book{Title: "t", Sheets: []osmodel.Sheet{{
+		Name:             "L",
+		Cells:            [][]osmodel.Cell{{{Type: osmodel.ValueString, StringValue: "a"}, {Type: osmodel.ValueString, StringValue: "b"}}},
+		Cols:             []osmodel.ColSpec{{Index: 2, Width: 20, Hidden: true}},
+		Rows:             []osmodel.RowSpec{{Index: 1, Height: 30}, {Index: 3, Hidden: true}},
+		DefaultColWidth:  11,
+		DefaultRowHeight: 18,
+	}}}
+	for _, threshold := range []int{-1, 1} {
+		out := filepath.Join(t.TempDir(), "out.xlsx")
+		if err := WriteBookWithOptions(book, out, Options{StreamThreshold: threshold}); err != nil {
+			t.Fatalf("threshold %d: %v", threshold, err)
+		}
+		f, err := excelize.OpenFile(out)
+		if err != nil {
+			t.Fatalf("open: %v", err)
+		}
+		// The stream writer hides columns by giving them zero width, which
+		// GetColWidth reports as the default, so check the sheet XML instead
+		if threshold < 0 {
+			if visible, _ := f.GetColVisible("L", "B"); visible {
+				t.Errorf("threshold %d: col B visible", threshold)
+			}
+		} else if xml := readZipEntry(t, out, "xl/worksheets/sheet1.xml"); !strings.Contains(xml, `<col min="2" max="2" width="0"`) {
+			t.Errorf("threshold %d: col B not zero width in %s", threshold, xml)
+		}
+		if visible, _ := f.GetRowVisible("L", 3); visible {
+			t.Errorf("threshold %d: row 3 visible", threshold)
+		}
+		if h, _ := f.GetRowHeight("L", 1); h != 30 {
+			t.Errorf("threshold %d: row 1 height = %v, want 30", threshold, h)
+		}
+		props, err := f.GetSheetProps("L")
+		if err != nil || props.DefaultColWidth == nil || *props.DefaultColWidth != 11 ||
+			props.DefaultRowHeight == nil || *props.DefaultRowHeight != 18 {
+			t.Errorf("threshold %d: sheet props = %+v (%v)", threshold, props, err)
+		}
+		_ = f.Close()
+	}
+}
+
+func TestWriteBookWithOptions_SheetView(t *testing.T) {
+	cells := [][]osmodel.Cell{{{Type: osmodel.ValueString, StringValue: "a"}}, {{Type: osmodel.ValueNumber, NumberValue: 1}}}
+	book := &osmodel.Book{Title: "t", Sheets: []osmodel.Sheet{
+		{Name: "Hidden", Cells: cells, View: osmodel.SheetView{Visibility: osmodel.SheetHidden}},
+		{Name: "Main", Cells: cells, View: osmodel.SheetView{FrozenRows: 1, FrozenCols: 2, TabColor: "FF8800", Zoom: 150}},
+		{Name: "Secret", Cells: cells, View: osmodel.SheetView{Visibility: osmodel.SheetVeryHidden}},
+	}}
+	for _, threshold := range []int{-1, 1} {
+		out := filepath.Join(t.TempDir(), "out.xlsx")
+		if err := WriteBookWithOptions(book, out, Options{StreamThreshold: threshold}); err != nil {
+			t.Fatalf("threshold %d: %v", threshold, err)
+		}
+		f, err := excelize.OpenFile(out)
+		if err != nil {
+			t.Fatalf("open: %v", err)
+		}
+		if got := f.GetActiveSheetIndex(); got != 1 {
+			t.Errorf("threshold %d: active sheet = %d, want 1", threshold, got)
+		}
+		for name, want := range map[string]bool{"Hidden": false, "Main": true, "Secret": false} {
+			if visible, _ := f.GetSheetVisible(name); visible != want {
+				t.Errorf("threshold %d: %s visible = %v, want %v", threshold, name, visible, want)
+			}
+		}
+		if xml := readZipEntry(t, out, "xl/workbook.xml"); !regexp.MustCompile(`name="Secret"[^>]*state="veryHidden"`).MatchString(xml) {
+			t.Errorf("threshold %d: Secret not very hidden in %s", threshold, xml)
+		}
+		panes, err := f.GetPanes("Main")
+		if err != nil || !panes.Freeze || panes.XSplit != 2 || panes.YSplit != 1 || panes.TopLeftCell != "C2" {
+			t.Errorf("threshold %d: panes = %+v (%v)", threshold, panes, err)
+		}
+		if props, _ := f.GetSheetProps("Main"); props.TabColorRGB == nil || *props.TabColorRGB != "FFFF8800" {
+			t.Errorf("threshold %d: tab color = %v", threshold, props.TabColorRGB)
+		}
+		if view, _ := f.GetSheetView("Main", 0); view.ZoomScale == nil || *view.ZoomScale != 150 {
+			t.Errorf("threshold %d: zoom = %v", threshold, view.ZoomScale)
+		}
+		_ = f.Close()
+	}
+
+	// A workbook keeps one sheet visible even when the source hides them all
+	var warnings []string
+	hidden := &osmodel.Book{Sheets: []osmodel.Sheet{{Name: "Only", Cells: cells, View: osmodel.SheetView{Visibility: osmodel.SheetHidden}}}}
+	out := filepath.Join(t.TempDir(), "hidden.xlsx")
+	if err := WriteBookWithOptions(hidden, out, Options{Warn: func(w string) { warnings = append(warnings, w) }}); err != nil {
+		t.Fatalf("all hidden: %v", err)
+	}
+	if len(warnings) != 1 || !strings.HasPrefix(warnings[0], "Only: ") {
+		t.Errorf("warnings = %q", warnings)
+	}
+}
+
+func TestWriteBookWithOptions_Annotations(t *testing.T) {
+	book := &osmodel.Book{Title: "t", Sheets: []osmodel.Sheet{{
+		Name: "Tasks",
+		Cells: [][]osmodel.Cell{{
+			{Type: osmodel.ValueString, StringValue: "T-1", Link: &osmodel.Hyperlink{URL: "https://example.com/T-1", Tooltip: "open"}},
+			{Type: osmodel.ValueString, StringValue: "notes", Link: &osmodel.Hyperlink{Location: "$'Other sheet'.B2"}},
+			{Comment: &osmodel.Comment{Author: "Ann", Text: "needs review"}},
+		}, {
+			{Type: osmodel.ValueNumber, NumberValue: 1, Comment: &osmodel.Comment{Text: "anonymous"}},
+		}},
+	}, {
+		Name:  "Other sheet",
+		Cells: [][]osmodel.Cell{{{Type: osmodel.ValueString, StringValue: "x"}}},
+	}}}
+	for _, threshold := range []int{-1, 1} {
+		out := filepath.Join(t.TempDir(), "out.xlsx")
+		if err := WriteBookWithOptions(book, out, Options{StreamThreshold: threshold}); err != nil {
+			t.Fatalf("threshold %d: %v", threshold, err)
+		}
+		f, err := excelize.OpenFile(out)
+		if err != nil {
+			t.Fatalf("open: %v", err)
+		}
+		if ok, target, err := f.GetCellHyperLink("Tasks", "A1"); !ok || target != "https://example.com/T-1" || err != nil {
+			t.Errorf("threshold %d: A1 link = %v %q (%v)", threshold, ok, target, err)
+		}
+		// Internal locations are translated like formula references
+		if ok, target, err := f.GetCellHyperLink("Tasks", "B1"); !ok || target != "'Other sheet'!B2" || err != nil {
+			t.Errorf("threshold %d: B1 link = %v %q (%v)", threshold, ok, target, err)
+		}
+		comments, err := f.GetComments("Tasks")
+		if err != nil || len(comments) != 2 {
+			t.Fatalf("threshold %d: comments = %+v (%v)", threshold, comments, err)
+		}
+		byCell := map[string]excelize.Comment{comments[0].Cell: comments[0], comments[1].Cell: comments[1]}
+		c1 := byCell["C1"]
+		var text string
+		for _, run := range c1.Paragraph {
+			text += run.Text
+		}
+		if c1.Author != "Ann" || text != "Ann:\nneeds review" || len(c1.Paragraph) == 0 || !c1.Paragraph[0].Font.Bold {
+			t.Errorf("threshold %d: C1 comment = %+v (%q)", threshold, c1, text)
+		}
+		if c := byCell["A2"]; c.Text != "anonymous" {
+			t.Errorf("threshold %d: A2 comment = %+v", threshold, c)
+		}
+		if got, _ := f.GetCellValue("Tasks", "A2"); got != "1" {
+			t.Errorf("threshold %d: A2 = %q", threshold, got)
+		}
+		_ = f.Close()
+	}
+}
+
+func TestWriteBookWithOptions_RichText(t *testing.T) {
+	book := &osmodel.Book{Title: "t", Sheets: []osmodel.Sheet{{
+		Name: "Notes",
+		Cells: [][]osmodel.Cell{{{
+			Type: osmodel.ValueString, StringValue: "Pay now please",
+			Style: &osmodel.Style{Font: osmodel.Font{Family: "Arial", Size: 9, Color: "333333"}},
+			RichText: []osmodel.RichTextRun{
+				{Text: "Pay "},
+				{Text: "now", Font: &osmodel.Font{Bold: true, Color: "FF0000"}},
+				{Text: " please"},
+			},
+		}}},
+	}}}
+	for _, threshold := range []int{-1, 1} {
+		out := filepath.Join(t.TempDir(), "out.xlsx")
+		if err := WriteBookWithOptions(book, out, Options{StreamThreshold: threshold}); err != nil {
+			t.Fatalf("threshold %d: %v", threshold, err)
+		}
+		f, err := excelize.OpenFile(out)
+		if err != nil {
+			t.Fatalf("open: %v", err)
+		}
+		if got, _ := f.GetCellValue("Notes", "A1"); got != "Pay now please" {
+			t.Errorf("threshold %d: A1 = %q", threshold, got)
+		}
+		runs, err := f.GetCellRichText("Notes", "A1")
+		if err != nil || len(runs) != 3 {
+			t.Fatalf("threshold %d: runs = %+v (%v)", threshold, runs, err)
+		}
+		// Runs without a font keep the cell's; a run font completes itself from it
+		if runs[0].Font != nil || runs[0].Text != "Pay " {
+			t.Errorf("threshold %d: run 0 = %+v", threshold, runs[0])
+		}
+		if fn := runs[1].Font; fn == nil || !fn.Bold || fn.Color != "FF0000" || fn.Family != "Arial" || fn.Size != 9 {
+			t.Errorf("threshold %d: run 1 font = %+v", threshold, fn)
+		}
+		_ = f.Close()
+	}
+}
+
+func TestWriteBookWithOptions_DefinedNames(t *testing.T) {
+	book := &osmodel.Book{Title: "t", Sheets: []osmodel.Sheet{{
+		Name: "Q1/Sales",
+		Cells: [][]osmodel.Cell{
+			{{Type: osmodel.ValueString, StringValue: "amount"}, {Formula: "SUM(Revenue)"}},
+			{{Type: osmodel.ValueNumber, NumberValue: 10}},
+			{{Type: osmodel.ValueNumber, NumberValue: 30}},
+		},
+	}}, DefinedNames: []osmodel.DefinedName{
+		{Name: "Revenue", RefersTo: "$'Q1/Sales'.$A$2:$A$3", Comment: "monthly"},
+		{Name: "TaxRate", RefersTo: "0.5", Scope: "Q1/Sales"},
+		{Name: "B2", RefersTo: "1"},
+		{Name: "R1C1", RefersTo: "1"},
+		{Name: "Bad name", RefersTo: "1"},
+		{Name: "Orphan", RefersTo: "1", Scope: "Missing"},
+	}}
+	// Evaluating writes through the stream writer
+	for _, mode := range []string{FormulasKeep, FormulasCache} {
+		var warnings []string
+		out := filepath.Join(t.TempDir(), "out.xlsx")
+		opts := Options{StreamThreshold: -1, Formulas: mode, Warn: func(w string) { warnings = append(warnings, w) }}
+		if err := WriteBookWithOptions(book, out, opts); err != nil {
+			t.Fatalf("%s: %v", mode, err)
+		}
+		f, err := excelize.OpenFile(out)
+		if err != nil {
+			t.Fatalf("open: %v", err)
+		}
+		want := []excelize.DefinedName{
+			{Name: "Revenue", RefersTo: "Q1_Sales!$A$2:$A$3", Comment: "monthly", Scope: "Workbook"},
+			{Name: "TaxRate", RefersTo: "0.5", Scope: "Q1_Sales"},
+		}
+		if got := f.GetDefinedName(); !reflect.DeepEqual(got, want) {
+			t.Errorf("%s: names = %+v, want %+v", mode, got, want)
+		}
+		// The calc engine resolves range names
+		if got, _ := f.GetCellValue("Q1_Sales", "B1"); mode == FormulasCache && got != "40" {
+			t.Errorf("%s: B1 = %q, want 40", mode, got)
+		}
+		if len(warnings) != 4 || !strings.Contains(warnings[0], "name B2: name reads as a cell reference") ||
+			!strings.Contains(warnings[1], "name R1C1:") || !strings.Contains(warnings[2], "name Bad name: skipped") ||
+			!strings.Contains(warnings[3], `name Orphan: scope "Missing" is not a sheet`) {
+			t.Errorf("%s: warnings = %q", mode, warnings)
+		}
+		_ = f.Close()
+	}
+}
+
+func TestWriteBookWithOptions_Tables(t *testing.T) {
+	str := func(s string) osmodel.Cell { return osmodel.Cell{Type: osmodel.ValueString, StringValue: s} }
+	num := func(v float64) osmodel.Cell { return osmodel.Cell{Type: osmodel.ValueNumber, NumberValue: v} }
+	book := &osmodel.Book{Title: "t", Sheets: []osmodel.Sheet{{
+		Name: "Orders",
+		Cells: [][]osmodel.Cell{
+			{str("id"), {}, str("ID"), num(2024)},
+			{num(1), num(2), num(3), num(4)},
+		},
+		Merges:     []osmodel.Merge{{StartRow: 20, StartCol: 1, EndRow: 20, EndCol: 2}},
+		AutoFilter: "B2:C9",
+		Tables: []osmodel.Table{
+			{Name: "Orders", Range: "A1:D3", HeaderRow: true, Style: "TableStyleMedium9"},
+			{Name: "Overlap", Range: "C2:E5", HeaderRow: true},
+			{Range: "F10:G12"},
+			{Name: "Merged", Range: "A19:B21", HeaderRow: true},
+			{Name: "Bad name", Range: "J1:K3", HeaderRow: true},
+		},
+	}, {
+		Name:       "Log",
+		Cells:      [][]osmodel.Cell{{str("when")}},
+		View:       osmodel.SheetView{TabColor: "00AA00"},
+		AutoFilter: "A1:C10",
+	}}}
+	for _, threshold := range []int{-1, 1} {
+		var warnings []string
+		out := filepath.Join(t.TempDir(), "out.xlsx")
+		opts := Options{StreamThreshold: threshold, Warn: func(w string) { warnings = append(warnings, w) }}
+		if err := WriteBookWithOptions(book, out, opts); err != nil {
+			t.Fatalf("threshold %d: %v", threshold, err)
+		}
+		f, err := excelize.OpenFile(out)
+		if err != nil {
+			t.Fatalf("open: %v", err)
+		}
+		tables, err := f.GetTables("Orders")
+		if err != nil || len(tables) == 0 || tables[0].Name != "Orders" || tables[0].Range != "A1:D3" || tables[0].StyleName != "TableStyleMedium9" {
+			t.Fatalf("threshold %d: tables = %+v (%v)", threshold, tables, err)
+		}
+		// Header cells become the unique column names Excel requires
+		for axis, want := range map[string]string{"A1": "id", "B1": "Column2", "C1": "Column3", "D1": "2024"} {
+			if got, _ := f.GetCellValue("Orders", axis); got != want {
+				t.Errorf("threshold %d: %s = %q, want %q", threshold, axis, got, want)
+			}
+		}
+		if typ, _ := f.GetCellType("Orders", "D1"); typ != excelize.CellTypeSharedString && typ != excelize.CellTypeInlineString {
+			t.Errorf("threshold %d: D1 type = %v, want text", threshold, typ)
+		}
+		want := []string{
+			"Orders!C2:E5: table skipped: overlaps table A1:D3",
+			"Orders!A19:B21: table skipped: overlaps merged cells",
+			"Orders!B2:C9: autofilter skipped: overlaps a table",
+			"Orders!J1:K3: table skipped: ",
+		}
+		if threshold > 0 {
+			// The stream writer holds a single table, with a header row
+			want = []string{
+				"Orders!C2:E5: table skipped: streamed sheets hold a single table",
+				"Orders!F10:G12: table skipped: tables without a header row cannot be streamed",
+				"Orders!A19:B21: table skipped: streamed sheets hold a single table",
+				"Orders!J1:K3: table skipped: streamed sheets hold a single table",
+				"Orders!B2:C9: autofilter skipped: overlaps a table",
+			}
+		} else if len(tables) != 2 || tables[1].Range != "F10:G12" {
+			t.Errorf("threshold %d: tables = %+v", threshold, tables)
+		}
+		if len(warnings) != len(want) {
+			t.Fatalf("threshold %d: warnings = %q", threshold, warnings)
+		}
+		for i := range want {
+			if !strings.HasPrefix(warnings[i], want[i]) {
+				t.Errorf("threshold %d: warning %d = %q, want %q", threshold, i, warnings[i], want[i])
+			}
+		}
+
+		filter := excelize.DefinedName{Name: "_xlnm._FilterDatabase", RefersTo: "'Log'!$A$1:$C$10", Scope: "Log"}
+		if names := f.GetDefinedName(); len(names) != 1 || names[0] != filter {
+			t.Errorf("threshold %d: names = %+v", threshold, names)
+		}
+		if !strings.Contains(readZipEntry(t, out, "xl/worksheets/sheet2.xml"), `<autoFilter ref="$A$1:$C$10">`) {
+			t.Errorf("threshold %d: Log has no autofilter", threshold)
+		}
+		// Adding the filter keeps the tab colour
+		if props, _ := f.GetSheetProps("Log"); props.TabColorRGB == nil || *props.TabColorRGB != "FF00AA00" {
+			t.Errorf("threshold %d: tab color = %v", threshold, props.TabColorRGB)
+		}
+		_ = f.Close()
+	}
+}
+
+func TestWriteBookWithOptions_AsTable(t *testing.T) {
+	str := func(s string) osmodel.Cell { return osmodel.Cell{Type: osmodel.ValueString, StringValue: s} }
+	num := func(v float64) osmodel.Cell { return osmodel.Cell{Type: osmodel.ValueNumber, NumberValue: v} }
+	book := &osmodel.Book{Title: "t", Sheets: []osmodel.Sheet{
+		{Name: "Data", Cells: [][]osmodel.Cell{
+			{},
+			{{}, str("Name"), str("Qty")},
+			{{}, str("a"), num(1)},
+			{},
+			{{}, str("b"), num(2), str("note")},
+			{},
+		}},
+		{Name: "Repeated", Cells: [][]osmodel.Cell{{str("x"), str("X")}, {num(1), num(2)}}},
+		{Name: "Numbers", Cells: [][]osmodel.Cell{{num(1), num(2)}, {num(3), num(4)}}},
+		{Name: "HeaderOnly", Cells: [][]osmodel.Cell{{str("a"), str("b")}}},
+		{Name: "Filtered", Cells: [][]osmodel.Cell{{str("a")}, {num(1)}}, AutoFilter: "A1:A2"},
+	}}
+	for _, threshold := range []int{-1, 1} {
+		out := filepath.Join(t.TempDir(), "out.xlsx")
+		if err := WriteBookWithOptions(book, out, Options{StreamThreshold: threshold, AsTable: true}); err != nil {
+			t.Fatalf("threshold %d: %v", threshold, err)
+		}
+		f, err := excelize.OpenFile(out)
+		if err != nil {
+			t.Fatalf("open: %v", err)
+		}
+		tables, err := f.GetTables("Data")
+		if err != nil || len(tables) != 1 || tables[0].Range != "B2:C5" || tables[0].StyleName != "TableStyleMedium2" {
+			t.Errorf("threshold %d: Data tables = %+v (%v)", threshold, tables, err)
+		}
+		for _, name := range []string{"Repeated", "Numbers", "HeaderOnly", "Filtered"} {
+			if tables, _ := f.GetTables(name); len(tables) != 0 {
+				t.Errorf("threshold %d: %s tables = %+v", threshold, name, tables)
+			}
+		}
+		_ = f.Close()
+	}
+}
+
+func TestWriteBookWithOptions_Protection(t *testing.T) {
+	unlocked := &osmodel.Style{Protection: osmodel.CellProtection{Unlocked: true}}
+	book := &osmodel.Book{
+		Protection: &osmodel.WorkbookProtection{Structure: true, PasswordHash: "FEF1"},
+		Sheets: []osmodel.Sheet{{
+			Name:       "Form",
+			Cells:      [][]osmodel.Cell{{{Type: osmodel.ValueString, StringValue: "input", Style: unlocked}, {Formula: "1+1"}}},
+			Protection: &osmodel.SheetProtection{PasswordHash: "CBEB", Allow: []string{osmodel.AllowSelectUnlockedCells, osmodel.AllowFormatCells, "pasteValues"}},
+		}, {
+			Name:       "Notes",
+			Cells:      [][]osmodel.Cell{{{Type: osmodel.ValueString, StringValue: "x"}}},
+			Protection: &osmodel.SheetProtection{PasswordHash: "1234"},
+		}},
+	}
+	for _, threshold := range []int{-1, 1} {
+		var warnings []string
+		out := filepath.Join(t.TempDir(), "out.xlsx")
+		opts := Options{StreamThreshold: threshold, Warn: func(w string) { warnings = append(warnings, w) }}
+		if err := WriteBookWithOptions(book, out, opts); err != nil {
+			t.Fatalf("threshold %d: %v", threshold, err)
+		}
+		form := readZipEntry(t, out, "xl/worksheets/sheet1.xml")
+		// The source hash is written as is; allowed actions are not locked
+		for _, want := range []string{`password="CBEB"`, `sheet="true"`, `selectLockedCells="true"`, `selectUnlockedCells="false"`, `formatCells="false"`, `sort="true"`} {
+			if !strings.Contains(form, want) {
+				t.Errorf("threshold %d: Form has no %s", threshold, want)
+			}
+		}
+		notes := readZipEntry(t, out, "xl/worksheets/sheet2.xml")
+		if !strings.Contains(notes, "<sheetProtection") || strings.Contains(notes, "password=") {
+			t.Errorf("threshold %d: Notes protection: %s", threshold, notes)
+		}
+		if wb := readZipEntry(t, out, "xl/workbook.xml"); !strings.Contains(wb, `lockStructure="true"`) || strings.Contains(wb, "workbookHashValue") {
+			t.Errorf("threshold %d: workbook protection: %s", threshold, wb)
+		}
+		want := []string{
+			`Form: unknown protection action "pasteValues" ignored`,
+			`Notes: invalid protection password hash "1234"; protecting without a password`,
+			"workbook protection password dropped: only sheet passwords can be carried over",
+		}
+		if !reflect.DeepEqual(warnings, want) {
+			t.Errorf("threshold %d: warnings = %q", threshold, warnings)
+		}
+
+		f, err := excelize.OpenFile(out)
+		if err != nil {
+			t.Fatalf("open: %v", err)
+		}
+		id, _ := f.GetCellStyle("Form", "A1")
+		if st, err := f.GetStyle(id); err != nil || st.Protection == nil || st.Protection.Locked {
+			t.Errorf("threshold %d: A1 protection = %+v (%v)", threshold, st, err)
+		}
+		_ = f.Close()
+	}
+
+	// Stripping protection keeps the cell lock flags
+	out := filepath.Join(t.TempDir(), "stripped.xlsx")
+	if err := WriteBookWithOptions(book, out, Options{StripProtection: true}); err != nil {
+		t.Fatalf("strip: %v", err)
+	}
+	if form := readZipEntry(t, out, "xl/worksheets/sheet1.xml"); strings.Contains(form, "<sheetProtection") {
+		t.Errorf("stripped sheet is protected: %s", form)
+	}
+	if wb := readZipEntry(t, out, "xl/workbook.xml"); strings.Contains(wb, "workbookProtection") {
+		t.Errorf("stripped workbook is protected: %s", wb)
+	}
+	if styles := readZipEntry(t, out, "xl/styles.xml"); !strings.Contains(styles, `locked="false"`) {
+		t.Errorf("stripped styles lost the unlocked cell: %s", styles)
+	}
+}
+
+func TestWriteBookWithOptions_DataValidations(t *testing.T) {
+	book := &osmodel.Book{Title: "t", Sheets: []osmodel.Sheet{{
+		Name:  "Orders",
+		Cells: [][]osmodel.Cell{{{Type: osmodel.ValueString, StringValue: "status"}}},
+		Validations: []osmodel.DataValidation{
+			{Range: "A2:A9", Kind: osmodel.ValidationList, Values: []string{"Open", "R&D"}},
+			{Range: "B2:B9", Kind: osmodel.ValidationList, Formula1: "$'Lists sheet'.A1:A3"},
+			{Range: "C2:C9 E2", Kind: osmodel.ValidationWhole, Operator: "between", Formula1: "1", Formula2: "10", ErrorStyle: "warning", ErrorMessage: "1 to 10"},
+			{Range: "D2:D9", Kind: osmodel.ValidationCustom, Formula1: "=AND(D2>0;D2<C2)"},
+			{Range: "F2", Kind: osmodel.ValidationDate, Operator: "between", Formula1: "45292"},
+			{Range: "G2", Kind: "any"},
+		},
+	}, {
+		Name:  "Lists sheet",
+		Cells: [][]osmodel.Cell{{{Type: osmodel.ValueString, StringValue: "a"}}},
+	}}}
+	for _, threshold := range []int{-1, 1} {
+		var warnings []string
+		out := filepath.Join(t.TempDir(), "out.xlsx")
+		opts := Options{StreamThreshold: threshold, Warn: func(msg string) { warnings = append(warnings, msg) }}
+		if err := WriteBookWithOptions(book, out, opts); err != nil {
+			t.Fatalf("threshold %d: %v", threshold, err)
+		}
+		f, err := excelize.OpenFile(out)
+		if err != nil {
+			t.Fatalf("open: %v", err)
+		}
+		dvs, err := f.GetDataValidations("Orders")
+		if err != nil || len(dvs) != 4 {
+			t.Fatalf("threshold %d: validations = %+v (%v)", threshold, dvs, err)
+		}
+		want := []struct{ sqref, typ, operator, formula1, formula2 string }{
+			{"A2:A9", "list", "", `"Open,R&D"`, ""},
+			{"B2:B9", "list", "", "'Lists sheet'!A1:A3", ""},
+			{"C2:C9 E2", "whole", "between", "1", "10"},
+			{"D2:D9", "custom", "", "AND(D2>0,D2<C2)", ""},
+		}
+		for i, w := range want {
+			dv := dvs[i]
+			if dv.Sqref != w.sqref || dv.Type != w.typ || dv.Operator != w.operator || dv.Formula1 != w.formula1 || dv.Formula2 != w.formula2 {
+				t.Errorf("threshold %d: validation %d = %+v, want %+v", threshold, i, dv, w)
+			}
+			if !dv.ShowErrorMessage {
+				t.Errorf("threshold %d: validation %d is not enforced", threshold, i)
+			}
+		}
+		if dvs[2].ErrorStyle == nil || *dvs[2].ErrorStyle != "warning" || dvs[2].Error == nil || *dvs[2].Error != "1 to 10" {
+			t.Errorf("threshold %d: error message = %v %v", threshold, dvs[2].ErrorStyle, dvs[2].Error)
+		}
+		wantWarnings := []string{
+			"Orders!F2: date validation is missing a bound",
+			`Orders!G2: unsupported validation type "any"`,
+		}
+		if !reflect.DeepEqual(warnings, wantWarnings) {
+			t.Errorf("threshold %d: warnings = %q, want %q", threshold, warnings, wantWarnings)
+		}
+		_ = f.Close()
+	}
+}
+
+func TestWriteBookWithOptions_ConditionalFormats(t *testing.T) {
+	red := &osmodel.Style{Fill: "FFC7CE", Font: osmodel.Font{Color: "9C0006"}}
+	book := &osmodel.Book{Title: "t", Sheets: []osmodel.Sheet{{
+		Name:  "Tasks",
+		Cells: [][]osmodel.Cell{{{Type: osmodel.ValueString, StringValue: "due"}}},
+		ConditionalFormats: []osmodel.ConditionalFormat{
+			{Range: "A2:A50", Kind: osmodel.ConditionalFormula, Formula1: "=AND(A2<>\"\";A2<TODAY())", Style: red},
+			{Range: "B2:B50", Kind: osmodel.ConditionalCellValue, Operator: "between", Formula1: "1", Formula2: "10", Style: red},
+			{Range: "C2:C50", Kind: osmodel.ConditionalColorScale, Colors: []string{"F8696B", "63BE7B"}},
+			{Range: "D2:D50", Kind: osmodel.ConditionalDataBar, Colors: []string{"638EC6"}},
+			{Range: "E2", Kind: osmodel.ConditionalCellValue, Operator: "greaterThan"},
+			{Range: "F2", Kind: "iconSet"},
+		},
+	}}}
+	for _, threshold := range []int{-1, 1} {
+		var warnings []string
+		out := filepath.Join(t.TempDir(), "out.xlsx")
+		opts := Options{StreamThreshold: threshold, Warn: func(msg string) { warnings = append(warnings, msg) }}
+		if err := WriteBookWithOptions(book, out, opts); err != nil {
+			t.Fatalf("threshold %d: %v", threshold, err)
+		}
+		f, err := excelize.OpenFile(out)
+		if err != nil {
+			t.Fatalf("open: %v", err)
+		}
+		cfs, err := f.GetConditionalFormats("Tasks")
+		if err != nil || len(cfs) != 4 {
+			t.Fatalf("threshold %d: conditional formats = %+v (%v)", threshold, cfs, err)
+		}
+		if rules := cfs["A2:A50"]; len(rules) != 1 || rules[0].Type != "formula" || rules[0].Criteria != `AND(A2<>"",A2<TODAY())` || rules[0].Format == nil {
+			t.Errorf("threshold %d: formula rule = %+v", threshold, rules)
+		}
+		if rules := cfs["B2:B50"]; len(rules) != 1 || rules[0].Criteria != "between" || rules[0].MinValue != "1" || rules[0].MaxValue != "10" {
+			t.Errorf("threshold %d: cell rule = %+v", threshold, rules)
+		}
+		// Rules sharing a style share its conditional style
+		if a, b := cfs["A2:A50"][0].Format, cfs["B2:B50"][0].Format; a == nil || b == nil || *a != *b {
+			t.Errorf("threshold %d: formats = %v, %v", threshold, a, b)
+		} else if st, err := f.GetConditionalStyle(*a); err != nil || len(st.Fill.Color) == 0 || st.Fill.Color[0] != "FFC7CE" {
+			t.Errorf("threshold %d: conditional style = %+v (%v)", threshold, st, err)
+		}
+		if rules := cfs["C2:C50"]; len(rules) != 1 || rules[0].Type != "2_color_scale" || rules[0].MinColor != "#F8696B" || rules[0].MaxColor != "#63BE7B" {
+			t.Errorf("threshold %d: color scale = %+v", threshold, rules)
+		}
+		if rules := cfs["D2:D50"]; len(rules) != 1 || rules[0].Type != "data_bar" || rules[0].BarColor != "#638EC6" {
+			t.Errorf("threshold %d: data bar = %+v", threshold, rules)
+		}
+		wantWarnings := []string{
+			"Tasks!E2: cell value rule needs an operator and a value",
+			`Tasks!F2: unsupported conditional format type "iconSet"`,
+		}
+		if !reflect.DeepEqual(warnings, wantWarnings) {
+			t.Errorf("threshold %d: warnings = %q, want %q", threshold, warnings, wantWarnings)
+		}
+		_ = f.Close()
+	}
+}
+
+// testPNG encodes a blank w x h PNG.
+func testPNG(t *testing.T, w, h int) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, w, h))); err != nil {
+		t.Fatalf("png: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func TestWriteBookWithOptions_Images(t *testing.T) {
+	thumb := testPNG(t, 10, 20)
+	book := &osmodel.Book{Title: "t", Sheets: []osmodel.Sheet{{
+		Name:  "Catalogue",
+		Cells: [][]osmodel.Cell{{{Type: osmodel.ValueString, StringValue: "sku"}, {Type: osmodel.ValueString, StringValue: "photo"}}},
+		Images: []osmodel.Image{
+			{Cell: "B2", Source: "media/a.png", Data: thumb, Width: 40, AltText: "Mug"},
+			{Cell: "B3", Data: thumb},
+			{Cell: "B4", Source: "media/evil.png", Data: []byte("MZ\x90\x00 not an image")},
+			{Cell: "B5", Source: "media/missing.png"},
+		},
+	}}}
+	for _, threshold := range []int{-1, 1} {
+		var warnings []string
+		out := filepath.Join(t.TempDir(), "out.xlsx")
+		opts := Options{StreamThreshold: threshold, Warn: func(msg string) { warnings = append(warnings, msg) }}
+		if err := WriteBookWithOptions(book, out, opts); err != nil {
+			t.Fatalf("threshold %d: %v", threshold, err)
+		}
+		f, err := excelize.OpenFile(out)
+		if err != nil {
+			t.Fatalf("open: %v", err)
+		}
+		cells, err := f.GetPictureCells("Catalogue")
+		if err != nil || !reflect.DeepEqual(cells, []string{"B2", "B3"}) {
+			t.Fatalf("threshold %d: picture cells = %v (%v)", threshold, cells, err)
+		}
+		pics, err := f.GetPictures("Catalogue", "B2")
+		if err != nil || len(pics) != 1 || !bytes.Equal(pics[0].File, thumb) || pics[0].Format.AltText != "Mug" {
+			t.Errorf("threshold %d: B2 pictures = %+v (%v)", threshold, pics, err)
+		}
+		// A width alone scales both sides: 40x80 px ends 40 px into column B
+		// and 80 px (four 20 px rows and 8 px) below row 2
+		if drawing := readZipEntry(t, out, "xl/drawings/drawing1.xml"); !strings.Contains(drawing, `<xdr:to><xdr:col>1</xdr:col><xdr:colOff>381000</xdr:colOff><xdr:row>5</xdr:row><xdr:rowOff>76200</xdr:rowOff>`) {
+			t.Errorf("threshold %d: B2 picture is not 40x80 px: %s", threshold, drawing)
+		}
+		if len(warnings) != 2 || !strings.HasPrefix(warnings[0], "Catalogue!B4: image media/evil.png skipped: unsupported content type") ||
+			warnings[1] != "Catalogue!B5: image media/missing.png skipped: no image data" {
+			t.Errorf("threshold %d: warnings = %q", threshold, warnings)
+		}
+		_ = f.Close()
+	}
+}
+
+func TestWriteBookReader_FromDocumentJSON(t *testing.T) {
+	dir := t.TempDir()
+	in := filepath.Join(dir, "in.osheet")
+	zf, err := os.Create(in)
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	zw := zip.NewWriter(zf)
+	w, err := zw.Create("document.json")
+	if err != nil {
+		t.Fatalf("zip entry: %v", err)
+	}
+	doc := `{"title":"Stock","properties":{"author":"Ann","custom":{"Batch":7}},"protection":true,"sheets":[{"name":"S","cells":[[{"t":"n","v":1},"12%"],[],[{"f":"SUM(A1:B1)"}]],"rowHeights":[{"Index":5,"Height":33}],"protection":{"allow":["sort"]}}]}`
+	if _, err := w.Write([]byte(doc)); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatalf("close zip: %v", err)
+	}
+	if err := zf.Close(); err != nil {
+		t.Fatalf("close file: %v", err)
+	}
+
+	br, err := osmodel.OpenBookReader(in)
+	if err != nil {
+		t.Fatalf("OpenBookReader: %v", err)
+	}
+	defer br.Close()
+	out := filepath.Join(dir, "out.xlsx")
+	if err := WriteBookReader(br, out); err != nil {
+		t.Fatalf("WriteBookReader: %v", err)
+	}
+
+	f, err := excelize.OpenFile(out)
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	defer func() { _ = f.Close() }()
+	if got, _ := f.GetCellValue("S", "B1"); got != "12%" {
+		t.Errorf("B1 = %q, want 12%%", got)
+	}
+	if got, _ := f.GetCellFormula("S", "A3"); got != "SUM(A1:B1)" {
+		t.Errorf("A3 formula = %q", got)
+	}
+	if h, _ := f.GetRowHeight("S", 5); h != 33 {
+		t.Errorf("row 5 height = %v, want 33", h)
+	}
+	if sheet := readZipEntry(t, out, "xl/worksheets/sheet1.xml"); !strings.Contains(sheet, `sort="false"`) {
+		t.Errorf("sheet protection not written")
+	}
+	if wb := readZipEntry(t, out, "xl/workbook.xml"); !strings.Contains(wb, `lockStructure="true"`) {
+		t.Errorf("workbook protection not written")
+	}
+	if props, err := f.GetDocProps(); err != nil || props.Title != "Stock" || props.Creator != "Ann" {
+		t.Errorf("doc props = %+v (%v)", props, err)
+	}
+	if custom := customProps(f); len(custom) != 1 || custom[0].Name != "Batch" || custom[0].Value != 7.0 {
+		t.Errorf("custom props = %+v", custom)
+	}
+}
+
+func TestWriteBookWithOptions_TranslatesFormulas(t *testing.T) {
+	book := &osmodel.Book{Sheets: []osmodel.Sheet{{
+		Name: "Q1/Q2",
+		Cells: [][]osmodel.Cell{{
+			{Type: osmodel.ValueNumber, NumberValue: 1},
+			{Formula: "=SUMME(A1;2,5)"},
+			{Type: osmodel.ValueString, StringValue: "=SYNO_FN(A1)"},
+		}},
+	}, {
+		Name:  "Sum",
+		Cells: [][]osmodel.Cell{{{Formula: "'Q1/Q2'.A1+[$'Q1/Q2'.B1]"}}},
+	}}}
+	for _, threshold := range []int{-1, 1} {
+		var warnings []string
+		out := filepath.Join(t.TempDir(), "out.xlsx")
+		opts := Options{StreamThreshold: threshold, Warn: func(w string) { warnings = append(warnings, w) }}
+		if err := WriteBookWithOptions(book, out, opts); err != nil {
+			t.Fatalf("threshold %d: %v", threshold, err)
+		}
+		f, err := excelize.OpenFile(out)
+		if err != nil {
+			t.Fatalf("open: %v", err)
+		}
+		for _, c := range []struct{ sheet, axis, want string }{
+			{"Q1_Q2", "B1", "SUM(A1,2.5)"},
+			{"Q1_Q2", "C1", "SYNO_FN(A1)"},
+			{"Sum", "A1", "Q1_Q2!A1+Q1_Q2!B1"},
+		} {
+			if got, _ := f.GetCellFormula(c.sheet, c.axis); got != c.want {
+				t.Errorf("threshold %d: %s!%s formula = %q, want %q", threshold, c.sheet, c.axis, got, c.want)
+			}
+		}
+		_ = f.Close()
+		if len(warnings) != 1 || warnings[0] != "Q1_Q2!C1: unknown function SYNO_FN" {
+			t.Errorf("threshold %d: warnings = %q", threshold, warnings)
+		}
+	}
+}
+
+func TestWriteBookWithOptions_FormulaResults(t *testing.T) {
+	book := &osmodel.Book{Sheets: []osmodel.Sheet{{
+		Name: "Calc",
+		Cells: [][]osmodel.Cell{{
+			{Type: osmodel.ValueNumber, NumberValue: 2},
+			{Type: osmodel.ValueNumber, NumberValue: 3},
+			{Formula: "SUMME(A1;B1)"},
+			{Formula: "A1>B1"},
+			{Formula: `"n="&C1`},
+			{Formula: "NOSUCHFN(A1)"},
+			{Formula: `"00"&"123"`},
+			{Formula: `"1."&"50"`},
+			{Formula: "Rate*A1"},
+		}},
+	}, {
+		Name:  "Rates",
+		Cells: [][]osmodel.Cell{{{Type: osmodel.ValueNumber, NumberValue: 10}}},
+	}}, DefinedNames: []osmodel.DefinedName{{Name: "Rate", RefersTo: "Rates.$A$1"}}}
+	for _, mode := range []string{FormulasCache, FormulasValues} {
+		var warnings []string
+		out := filepath.Join(t.TempDir(), mode+".xlsx")
+		opts := Options{Formulas: mode, Warn: func(w string) { warnings = append(warnings, w) }}
+		if err := WriteBookWithOptions(book, out, opts); err != nil {
+			t.Fatalf("%s: %v", mode, err)
+		}
+		f, err := excelize.OpenFile(out)
+		if err != nil {
+			t.Fatalf("open: %v", err)
+		}
+		for _, c := range []struct {
+			axis, value, formula string
+			typ                  excelize.CellType
+		}{
+			{"C1", "5", "SUM(A1,B1)", excelize.CellTypeUnset}, // numbers carry no t attribute
+			{"D1", "FALSE", "A1>B1", excelize.CellTypeBool},
+			{"E1", "n=5", `"n="&C1`, excelize.CellTypeFormula},
+			// Text results stay text even when they read as a number
+			{"G1", "00123", `"00"&"123"`, excelize.CellTypeFormula},
+			{"H1", "1.50", `"1."&"50"`, excelize.CellTypeFormula},
+			// Defined names pull the sheets they refer to into the evaluation
+			{"I1", "20", "Rate*A1", excelize.CellTypeUnset},
+		} {
+			value, _ := f.GetCellValue("Calc", c.axis)
+			formula, _ := f.GetCellFormula("Calc", c.axis)
+			typ, _ := f.GetCellType("Calc", c.axis)
+			wantFormula, wantType := c.formula, c.typ
+			if mode == FormulasValues {
+				wantFormula = ""
+				if wantType == excelize.CellTypeFormula {
+					wantType = excelize.CellTypeInlineString
+				}
+			}
+			if value != c.value || formula != wantFormula || typ != wantType {
+				t.Errorf("%s: %s = %q %q type %v, want %q %q type %v", mode, c.axis, value, formula, typ, c.value, wantFormula, wantType)
+			}
+		}
+		// Unevaluable formulas are kept and reported
+		if formula, _ := f.GetCellFormula("Calc", "F1"); formula != "NOSUCHFN(A1)" {
+			t.Errorf("%s: F1 formula = %q", mode, formula)
+		}
+		_ = f.Close()
+		if len(warnings) != 2 || warnings[0] != "Calc!F1: cannot evaluate NOSUCHFN(A1): not support NOSUCHFN function" ||
+			warnings[1] != "Calc!F1: unknown function NOSUCHFN" {
+			t.Errorf("%s: warnings = %q", mode, warnings)
+		}
+	}
+	if err := WriteBookWithOptions(book, filepath.Join(t.TempDir(), "x.xlsx"), Options{Formulas: "eval"}); err == nil {
+		t.Errorf("unknown formulas mode accepted")
+	}
+}
+
+// readZipEntry returns one part of the xlsx package at path as text.
+func readZipEntry(t *testing.T, path, name string) string {
+	t.Helper()
+	zr, err := zip.OpenReader(path)
+	if err != nil {
+		t.Fatalf("open zip: %v", err)
+	}
+	defer func() { _ = zr.Close() }()
+	for _, entry := range zr.File {
+		if entry.Name != name {
+			continue
+		}
+		rc, err := entry.Open()
+		if err != nil {
+			t.Fatalf("open %s: %v", name, err)
+		}
+		defer func() { _ = rc.Close() }()
+		b, err := io.ReadAll(rc)
+		if err != nil {
+			t.Fatalf("read %s: %v", name, err)
+		}
+		return string(b)
+	}
+	t.Fatalf("%s not found in %s", name, path)
+	return ""
+}
@@ -1,6 +1,12 @@
 package xlsx
 
-import "testing"
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	osmodel "github.com/romanitalian/osheet2xlsx/v3/internal/osheet"
+)
 
 func TestColumnName(t *testing.T) {
 	cases := map[int]string{1: "A", 2: "B", 26: "Z", 27: "AA", 52: "AZ", 53: "BA", 702: "ZZ", 703: "AAA"}
@@ -10,3 +16,15 @@ func TestColumnName(t *testing.T) {
 		}
 	}
 }
+
+func TestSheetNames(t *testing.T) {
+	long := strings.Repeat("ä", 40)
+	var sheets []osmodel.Sheet
+	for _, name := range []string{"a/b", "a:b", "A?B", "", "Sheet4", long, long} {
+		sheets = append(sheets, osmodel.Sheet{Name: name})
+	}
+	want := []string{"a_b", "a_b_2", "A_B_3", "Sheet4", "Sheet4_2", strings.Repeat("ä", 31), strings.Repeat("ä", 29) + "_2"}
+	if got := sheetNames(sheets); !reflect.DeepEqual(got, want) {
+		t.Errorf("sheetNames = %q, want %q", got, want)
+	}
+}
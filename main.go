@@ -25,6 +25,9 @@ func mapErrorToExitCode(err error) int {
 	if errors.Is(err, appcmd.ErrValidateStructure) {
 		return 4 // structural issues in input
 	}
+	if errors.Is(err, appcmd.ErrOutputCollision) {
+		return 2 // batch layout needs different flags
+	}
 	// simple mapping heuristic; specific errors could be wrapped/types later
 	msg := err.Error()
 	if msg == "partial failure" {